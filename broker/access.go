@@ -0,0 +1,316 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// The access levels that a binding can ask for on its database.
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+	AccessAdmin = "admin"
+
+	// DefaultAccess is used when a binding does not ask for an access
+	// level.  Bindings made before access levels existed were granted it
+	// as well.
+	DefaultAccess = AccessWrite
+)
+
+// accessLevels lists the access levels in the order their roles are made.
+var accessLevels = []string{AccessRead, AccessWrite, AccessAdmin}
+
+var accessActions = map[string][]string{
+	AccessRead:  {"read"},
+	AccessWrite: {"read", "write"},
+	AccessAdmin: {"all"},
+}
+
+// AccessActions returns the Stardog actions on a database that an access
+// level grants.
+func AccessActions(access string) ([]string, error) {
+	if access == "" {
+		access = DefaultAccess
+	}
+	actions, ok := accessActions[access]
+	if !ok {
+		return nil, NewBadRequestError("The access level %s is not one of read, write or admin", access)
+	}
+	return actions, nil
+}
+
+// InstanceRoleName returns the name of the role that grants an access level
+// on the database of a service instance.
+func InstanceRoleName(dbName string, access string) string {
+	if access == "" {
+		access = DefaultAccess
+	}
+	return fmt.Sprintf("%s_%s", dbName, access)
+}
+
+// CreateInstanceRoles creates a role for each access level on the database
+// of a service instance.  Bound users are assigned to one of the roles
+// rather than being granted permissions of their own.  If a role cannot be
+// made the roles already created are deleted again, even if ctx has been
+// cancelled.
+func CreateInstanceRoles(ctx context.Context, client StardogClient, dbName string) error {
+	var created []string
+	for _, access := range accessLevels {
+		role := InstanceRoleName(dbName, access)
+		err := client.CreateRole(ctx, role)
+		if err == nil {
+			created = append(created, role)
+			err = client.GrantRolePermissions(ctx, dbName, role, accessActions[access])
+		}
+		if err != nil {
+			for _, r := range created {
+				client.DeleteRole(context.Background(), r)
+			}
+			return fmt.Errorf("Failed to create the role %s: %s", role, err)
+		}
+	}
+	return nil
+}
+
+// DeleteInstanceRoles deletes the roles of a service instance.  Every role
+// is tried and the first failure is returned.
+func DeleteInstanceRoles(ctx context.Context, client StardogClient, dbName string) error {
+	var firstErr error
+	for _, access := range accessLevels {
+		role := InstanceRoleName(dbName, access)
+		err := client.DeleteRole(ctx, role)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("Failed to delete the role %s: %s", role, err)
+		}
+	}
+	return firstErr
+}
+
+// UserBindParameters are the parameters that a bind request can set.
+type UserBindParameters struct {
+	Password string `json:"password,omitempty"`
+	Username string `json:"username,omitempty"`
+	Access   string `json:"access,omitempty"`
+}
+
+// UserBinding describes the Stardog user made for a binding.  It is sent
+// to the platform as the credentials of the binding and kept with it.
+type UserBinding struct {
+	DbName     string `json:"db_name"`
+	StardogURL string `json:"url"`
+	Password   string `json:"password"`
+	Username   string `json:"username"`
+	Access     string `json:"access,omitempty"`
+	Role       string `json:"role,omitempty"`
+}
+
+// InstanceAccess makes and removes the database of a service instance and
+// the users bound to it.  The plans differ in where the Stardog server and
+// its credentials come from, not in what they do with them.
+type InstanceAccess struct {
+	Client     StardogClient
+	DbName     string
+	StardogURL string
+	// Roles is set on instances whose bound users are assigned to the
+	// instance's roles.  Older instances grant permissions to each user.
+	Roles     bool
+	Passwords *PasswordGenerator
+	Logger    SdLogger
+	// AuthFailure makes the error that the platform gets when Stardog
+	// rejects the credentials that the plan used.
+	AuthFailure func(err error) error
+}
+
+// StardogError makes the error that the platform gets when a request to
+// Stardog fails.
+func (a *InstanceAccess) StardogError(err error) error {
+	if IsStardogAuthFailure(err) {
+		return a.AuthFailure(err)
+	}
+	return err
+}
+
+// CreateDatabase creates the database of the instance and its roles.  If
+// the roles cannot be made the database is deleted again.
+func (a *InstanceAccess) CreateDatabase(ctx context.Context, options map[string]interface{}) (int, error) {
+	err := a.Client.CreateDatabase(ctx, a.DbName, options)
+	if StardogStatus(err) == http.StatusConflict {
+		return http.StatusConflict, NewConflictError("The database %s already exists", a.DbName)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, a.StardogError(err)
+	}
+	err = CreateInstanceRoles(ctx, a.Client, a.DbName)
+	if err != nil {
+		a.Logger.Logf(WARN, "Failed to create the roles of %s: %s", a.DbName, err)
+		a.Client.DeleteDatabase(context.Background(), a.DbName)
+		return http.StatusInternalServerError, a.StardogError(err)
+	}
+	return http.StatusCreated, nil
+}
+
+// DeleteDatabase deletes the database of the instance and its roles.  A
+// database that is already gone is not an error.
+func (a *InstanceAccess) DeleteDatabase(ctx context.Context) (int, error) {
+	err := a.Client.DeleteDatabase(ctx, a.DbName)
+	if StardogStatus(err) == http.StatusNotFound {
+		a.Logger.Logf(WARN, "The database %s was already deleted", a.DbName)
+	} else if err != nil {
+		return http.StatusInternalServerError, a.StardogError(err)
+	}
+	if a.Roles {
+		// The database is gone so a role that is left behind does not
+		// grant anything
+		err = DeleteInstanceRoles(ctx, a.Client, a.DbName)
+		if err != nil {
+			a.Logger.Logf(WARN, "%s", err)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Bind makes a Stardog user with the access to the instance's database
+// that the bind parameters ask for.
+func (a *InstanceAccess) Bind(ctx context.Context, requestContext *RequestContext, parameters interface{}) (int, *UserBinding, error) {
+	var params UserBindParameters
+
+	err := ReSerializeInterface(parameters, &params)
+	if err != nil {
+		return http.StatusBadRequest, nil, NewBadRequestError("The parameters were not properly formed")
+	}
+
+	if params.Username == "" {
+		params.Username = GetRandomName(requestContext.NamePrefix("stardog"), 8)
+	}
+	err = ValidateUsername(params.Username)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if params.Access == "" {
+		params.Access = DefaultAccess
+	}
+	actions, err := AccessActions(params.Access)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if params.Password == "" {
+		params.Password, err = a.Passwords.Generate()
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+	}
+
+	binding := &UserBinding{
+		Username:   params.Username,
+		Password:   params.Password,
+		Access:     params.Access,
+		DbName:     a.DbName,
+		StardogURL: a.StardogURL,
+	}
+	e, err := a.Client.UserExists(ctx, binding.Username)
+	if err != nil {
+		a.Logger.Logf(WARN, "UserExists check failed: %s", err)
+		return http.StatusInternalServerError, nil, a.StardogError(err)
+	}
+	if e {
+		return http.StatusConflict, nil, NewConflictError("Failed to create the user because %s already exists", binding.Username)
+	}
+	err = a.Client.NewUser(ctx, binding.Username, binding.Password)
+	if StardogStatus(err) == http.StatusConflict {
+		return http.StatusConflict, nil, NewConflictError("Failed to create the user because %s already exists", binding.Username)
+	}
+	if err != nil {
+		a.Logger.Logf(WARN, "Failed to create the user %s", err)
+		return http.StatusInternalServerError, nil, a.StardogError(err)
+	}
+	if a.Roles {
+		binding.Role = InstanceRoleName(binding.DbName, binding.Access)
+		err = a.Client.AssignRole(ctx, binding.Username, binding.Role)
+	} else {
+		err = a.Client.GrantUserPermissions(ctx, binding.DbName, binding.Username, actions)
+	}
+	if err != nil {
+		a.Logger.Logf(INFO, "Failed to grant access on %s to the user %s: %s", binding.DbName, binding.Username, err)
+		// The user is removed even when the request was cancelled so
+		// that no user without a binding is left in Stardog.
+		delErr := a.Client.DeleteUser(context.Background(), binding.Username)
+		if delErr != nil {
+			a.Logger.Logf(WARN, "Failed to delete the user %s: %s", binding.Username, delErr)
+		}
+		return http.StatusInternalServerError, nil, a.StardogError(err)
+	}
+	return http.StatusOK, binding, nil
+}
+
+// UnBind deletes the Stardog user of a binding.
+func (a *InstanceAccess) UnBind(ctx context.Context, binding interface{}) (int, error) {
+	var userBinding UserBinding
+	err := ReSerializeInterface(binding, &userBinding)
+	if err != nil {
+		a.Logger.Logf(WARN, "Failed to inflate the parameters %s", err)
+		return http.StatusInternalServerError, err
+	}
+	// Users assigned to a role lose it when they are deleted.  Users of
+	// older instances had permissions granted to them directly, and those
+	// made before access levels existed were granted the default.
+	if userBinding.Role == "" {
+		actions, err := AccessActions(userBinding.Access)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		err = a.Client.RevokeUserPermissions(ctx, userBinding.DbName, userBinding.Username, actions)
+		if err != nil {
+			a.Logger.Logf(WARN, "Failed to revoke user accesss %s", err)
+			return http.StatusInternalServerError, a.StardogError(err)
+		}
+	}
+
+	err = a.Client.DeleteUser(ctx, userBinding.Username)
+	if StardogStatus(err) == http.StatusNotFound {
+		a.Logger.Logf(WARN, "The user %s was already deleted", userBinding.Username)
+	} else if err != nil {
+		a.Logger.Logf(WARN, "Failed to delete user %s: %s", userBinding.Username, err)
+		return http.StatusInternalServerError, a.StardogError(err)
+	}
+	return http.StatusOK, nil
+}
+
+// EqualUserBinding reports if a bind request asks for the user that a
+// binding already has.
+func EqualUserBinding(bindInstance *BindInstance, bindRequest *BindRequest) bool {
+	var bindParams UserBindParameters
+	err := ReSerializeInterface(bindRequest.Parameters, &bindParams)
+	if err != nil {
+		return false
+	}
+	var userBinding UserBinding
+	err = ReSerializeInterface(bindInstance.PlanParams, &userBinding)
+	if err != nil {
+		return false
+	}
+
+	if bindParams.Access == "" {
+		bindParams.Access = DefaultAccess
+	}
+	if userBinding.Access == "" {
+		userBinding.Access = DefaultAccess
+	}
+	return bindParams.Password == userBinding.Password && bindParams.Username == userBinding.Username &&
+		bindParams.Access == userBinding.Access
+}
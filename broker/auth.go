@@ -0,0 +1,215 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Authenticator checks the HTTP basic authentication sent by platforms
+// against the broker credentials.  Several credentials can be valid at
+// once so that they can be rotated without downtime.
+type Authenticator struct {
+	credentials []brokerCredential
+	// dummyHash is checked when the username is not known so that the
+	// time taken does not reveal which usernames exist
+	dummyHash []byte
+	// verified caches the credentials that passed a bcrypt check so that
+	// the expensive check is not repeated on every request.  Entries are
+	// only added after a successful check, so there is at most one for
+	// each credential.  They are keyed by an HMAC of the username and
+	// password with verifiedKey, a random key made at startup, so that a
+	// copy of the cache cannot be used to guess passwords at the speed of
+	// plain SHA-256.  The trade-off is that a password that was sent once
+	// is accepted without bcrypt for the life of the process, which is
+	// why expiry is still checked on every request.
+	verified    map[[sha256.Size]byte]int
+	verifiedKey []byte
+	lock        sync.Mutex
+	now         func() time.Time
+}
+
+type brokerCredential struct {
+	username     []byte
+	passwordHash []byte
+	password     []byte
+	expires      time.Time
+	platform     string
+}
+
+type brokerPlatformKey struct{}
+
+// NewAuthenticator loads the credentials from the configuration.  The
+// plain text broker_username and broker_password are still honored next
+// to the hashed credentials.
+func NewAuthenticator(conf *ServerConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		verified:    make(map[[sha256.Size]byte]int),
+		verifiedKey: make([]byte, sha256.Size),
+		now:         time.Now,
+	}
+	_, err := rand.Read(a.verifiedKey)
+	if err != nil {
+		return nil, err
+	}
+	if conf.BrokerUsername != "" || conf.BrokerPassword != "" {
+		a.credentials = append(a.credentials, brokerCredential{
+			username: []byte(conf.BrokerUsername),
+			password: []byte(conf.BrokerPassword),
+		})
+	}
+	for _, cred := range conf.Credentials {
+		if cred.Username == "" {
+			return nil, fmt.Errorf("Every broker credential must have a username")
+		}
+		cost, err := bcrypt.Cost([]byte(cred.PasswordHash))
+		if err != nil {
+			return nil, fmt.Errorf("The password hash for %s is not a bcrypt hash: %s", cred.Username, err)
+		}
+		bc := brokerCredential{
+			username:     []byte(cred.Username),
+			passwordHash: []byte(cred.PasswordHash),
+			platform:     cred.Platform,
+		}
+		if cred.Expires != "" {
+			bc.expires, err = time.Parse(time.RFC3339, cred.Expires)
+			if err != nil {
+				return nil, fmt.Errorf("The expiry of the credential for %s is not an RFC 3339 time: %s", cred.Username, err)
+			}
+		}
+		a.credentials = append(a.credentials, bc)
+		if a.dummyHash == nil {
+			a.dummyHash, err = bcrypt.GenerateFromPassword([]byte("dummy"), cost)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(a.credentials) == 0 {
+		return nil, fmt.Errorf("At least one broker credential is required")
+	}
+	return a, nil
+}
+
+// Authenticate checks the credentials sent with a request.  It returns
+// the platform label of the credential that matched.
+func (a *Authenticator) Authenticate(r *http.Request) (string, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return "", fmt.Errorf("The request did not use HTTP basic authentication")
+	}
+	digest := a.verifiedDigest(username, password)
+	now := a.now()
+
+	a.lock.Lock()
+	i, cached := a.verified[digest]
+	a.lock.Unlock()
+	if cached {
+		return a.credentials[i].check(now)
+	}
+
+	var err error
+	known := false
+	for i, cred := range a.credentials {
+		if subtle.ConstantTimeCompare(digestOf([]byte(username)), digestOf(cred.username)) != 1 {
+			continue
+		}
+		known = true
+		if cred.passwordHash != nil {
+			if bcrypt.CompareHashAndPassword(cred.passwordHash, []byte(password)) != nil {
+				continue
+			}
+			a.lock.Lock()
+			a.verified[digest] = i
+			a.lock.Unlock()
+		} else if subtle.ConstantTimeCompare(digestOf([]byte(password)), digestOf(cred.password)) != 1 {
+			continue
+		}
+		platform, expiredErr := cred.check(now)
+		if expiredErr == nil {
+			return platform, nil
+		}
+		// An older credential with the same username may have expired
+		// while the new one is still valid
+		err = expiredErr
+	}
+	if !known && a.dummyHash != nil {
+		bcrypt.CompareHashAndPassword(a.dummyHash, []byte(password))
+	}
+	if err == nil {
+		err = fmt.Errorf("The username or password sent by %s is not valid", username)
+	}
+	return "", err
+}
+
+func (a *Authenticator) verifiedDigest(username string, password string) [sha256.Size]byte {
+	var digest [sha256.Size]byte
+	mac := hmac.New(sha256.New, a.verifiedKey)
+	mac.Write([]byte(username))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	copy(digest[:], mac.Sum(nil))
+	return digest
+}
+
+func (c *brokerCredential) check(now time.Time) (string, error) {
+	if !c.expires.IsZero() && now.After(c.expires) {
+		return "", fmt.Errorf("The credential for %s expired at %s", string(c.username), c.expires.Format(time.RFC3339))
+	}
+	return c.platform, nil
+}
+
+// digestOf hashes a secret so that it can be compared in constant time
+// with one of a different length.
+func digestOf(b []byte) []byte {
+	d := sha256.Sum256(b)
+	return d[:]
+}
+
+// GetBrokerPlatform returns the platform label of the broker credential
+// that a request was authenticated with.  It is empty when the credential
+// does not have a label.
+func GetBrokerPlatform(r *http.Request) string {
+	platform, _ := r.Context().Value(brokerPlatformKey{}).(string)
+	return platform
+}
+
+// authenticate checks the broker credentials of a request and sends a 401
+// if they are not valid.  The request is returned with the platform label
+// of the credential attached so that it can be used for auditing.
+func (c *ControllerImpl) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	platform, err := c.auth.Authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="service-broker"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return r, err
+	}
+	if platform != "" {
+		c.logger.Logf(INFO, "%s %s authenticated for platform %s", r.Method, r.URL.Path, platform)
+	}
+	ctx := context.WithValue(r.Context(), brokerPlatformKey{}, platform)
+	return r.WithContext(ctx), nil
+}
@@ -0,0 +1,175 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func hashPassword(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return string(hash)
+}
+
+func basicAuthRequest(username string, password string) *http.Request {
+	r := httptest.NewRequest("GET", "/v2/catalog", nil)
+	r.SetBasicAuth(username, password)
+	return r
+}
+
+func TestAuthenticateBcrypt(t *testing.T) {
+	a, err := NewAuthenticator(&ServerConfig{Credentials: []BrokerCredential{
+		{Username: "cf", PasswordHash: hashPassword(t, "cfpw"), Platform: "cloudfoundry-prod"},
+	}})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	for i := 0; i < 2; i++ {
+		// The second time the cached check is used
+		platform, err := a.Authenticate(basicAuthRequest("cf", "cfpw"))
+		if err != nil || platform != "cloudfoundry-prod" {
+			t.Fatalf("The right password should be accepted: %v", err)
+		}
+	}
+	bad := [][2]string{{"cf", "wrong"}, {"cf", ""}, {"other", "cfpw"}, {"", ""}}
+	for _, b := range bad {
+		_, err = a.Authenticate(basicAuthRequest(b[0], b[1]))
+		if err == nil {
+			t.Fatalf("%s/%s should be refused", b[0], b[1])
+		}
+	}
+	_, err = a.Authenticate(httptest.NewRequest("GET", "/v2/catalog", nil))
+	if err == nil {
+		t.Fatalf("A request without basic authentication should be refused")
+	}
+	if len(a.verified) != 1 {
+		t.Fatalf("Only the successful check should be cached, found %d", len(a.verified))
+	}
+
+	bad2 := []BrokerCredential{
+		{PasswordHash: hashPassword(t, "pw")},
+		{Username: "cf", PasswordHash: "plain"},
+		{Username: "cf", PasswordHash: hashPassword(t, "pw"), Expires: "tomorrow"},
+	}
+	for _, c := range bad2 {
+		_, err = NewAuthenticator(&ServerConfig{Credentials: []BrokerCredential{c}})
+		if err == nil {
+			t.Fatalf("The credential %v should be rejected", c)
+		}
+	}
+	_, err = NewAuthenticator(&ServerConfig{})
+	if err == nil {
+		t.Fatalf("At least one credential should be required")
+	}
+}
+
+func TestAuthenticateExpiry(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	a, err := NewAuthenticator(&ServerConfig{Credentials: []BrokerCredential{
+		{Username: "cf", PasswordHash: hashPassword(t, "cfpw"), Expires: now.Add(time.Hour).Format(time.RFC3339)},
+	}})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	a.now = func() time.Time { return now }
+	_, err = a.Authenticate(basicAuthRequest("cf", "cfpw"))
+	if err != nil {
+		t.Fatalf("The credential has not expired yet: %s", err)
+	}
+	now = now.Add(2 * time.Hour)
+	_, err = a.Authenticate(basicAuthRequest("cf", "cfpw"))
+	if err == nil {
+		t.Fatalf("An expired credential should be refused even when it is cached")
+	}
+}
+
+func TestAuthenticateRotation(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	a, err := NewAuthenticator(&ServerConfig{
+		BrokerUsername: "legacy",
+		BrokerPassword: "legacypw",
+		Credentials: []BrokerCredential{
+			{Username: "cf", PasswordHash: hashPassword(t, "old"), Expires: now.Add(time.Hour).Format(time.RFC3339), Platform: "old"},
+			{Username: "cf", PasswordHash: hashPassword(t, "new"), Platform: "new"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	a.now = func() time.Time { return now }
+
+	// Both passwords work while the platform is being moved over
+	platform, err := a.Authenticate(basicAuthRequest("cf", "old"))
+	if err != nil || platform != "old" {
+		t.Fatalf("The old password should still be accepted: %v", err)
+	}
+	platform, err = a.Authenticate(basicAuthRequest("cf", "new"))
+	if err != nil || platform != "new" {
+		t.Fatalf("The new password should be accepted: %v", err)
+	}
+	_, err = a.Authenticate(basicAuthRequest("legacy", "legacypw"))
+	if err != nil {
+		t.Fatalf("The plain text credential should still be accepted: %s", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	_, err = a.Authenticate(basicAuthRequest("cf", "old"))
+	if err == nil {
+		t.Fatalf("The old password should be refused once it expired")
+	}
+	platform, err = a.Authenticate(basicAuthRequest("cf", "new"))
+	if err != nil || platform != "new" {
+		t.Fatalf("The new password should outlive the old one: %v", err)
+	}
+}
+
+func TestBrokerPlatformInRequestContext(t *testing.T) {
+	auth, err := NewAuthenticator(&ServerConfig{Credentials: []BrokerCredential{
+		{Username: "k8s", PasswordHash: hashPassword(t, "pw"), Platform: "kubernetes-east"},
+	}})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	c := &ControllerImpl{auth: auth, logger: getLogger(t)}
+	r, err := c.authenticate(httptest.NewRecorder(), basicAuthRequest("k8s", "pw"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if GetBrokerPlatform(r) != "kubernetes-east" {
+		t.Fatalf("The platform label was not attached to the request")
+	}
+	rc, err := newRequestContext(r, nil, "", "")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if rc.String() != "an unknown user through the platform kubernetes-east" {
+		t.Fatalf("The audit description should name the platform, got %s", rc.String())
+	}
+
+	w := httptest.NewRecorder()
+	_, err = c.authenticate(w, basicAuthRequest("k8s", "wrong"))
+	if err == nil || w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("A wrong password should get a 401 challenge")
+	}
+}
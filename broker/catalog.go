@@ -0,0 +1,163 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// The description of the service that is offered when the configuration
+// does not list any services.
+const (
+	defaultServiceName        = "Stardog"
+	defaultServiceDescription = "Provides access to a Stardog Knowledge Graph"
+)
+
+var defaultServiceMetadata = CatalogMetadata{
+	DisplayName:     "Stardog",
+	LongDescription: "Stardog Knowledge Graphs for PCF",
+	ImageURL:        "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAQAAAAEACAYAAABccqhmAAAgAElEQVR4nOx9d3hb5dn+azuEkCE5e9L2o0ChEKA/vrZ0MBIgsR1m23R/BVoKBDI8dOQ4YZgZRiDx0HAmgUCCQwhkeTuOM7w0LWtP27G8tzziSLp/f5xzZMnxkHda9FzXc8lDOudIOvf9Ps/zPoOQgARkDKREb4wqVOt/y/4OIDg+Pj54Mq8pIAEJyDgLgCBCCClWmz+XGcrrpToTla5Wz/H6f4AIAhKQ/xgZJlgBBBNCSKHGHFFquYRS8yVItbYSidr812ytdq7nsAwRsIQRkIAE5FoSHm+G5+f4+GDiJ1BZQKempt4gM1pPaytqoTBWQGmqcMv1liKp0Rx5pkD+oz6vCRBBQAJyTQgDxJk80UouT/DR7NiEH3j+tzY1hBAyJFBZK0CqNN4n0ZnrFcZySLRmV5nNDnV5NSRas0qiNm8rURh/lpqaGuL9uoB7EJCATKYwAORGCW/i8MX13FiReVZM8vPz+Htn+TxnCKCyJCDTGf8p01tdUp0FxWrjFYnW7FLb7Ciz2aEw2WqleuuhEmP5H3IUiqV9Xh/EkEHAMghIQCZQPIDj8IRfhL5+ANzNu8DhC9O5lHDtPD7flwjW9q7g3uJt0hdrzJukOotbqrNCojW7JFqTS6qzuBSmCmjKq6EwVaBEZy6SaEyvFapUd/VzrJCAixCQgEyUMKv7jI0JD4fyUxxcSgTulj3g8MXdnNhdBVy+8IXp/A+WeJ4PBPUXJwAQxAJXojZtkBlsLpneihKNyS3RmhkyMLskWrO7zGqH2mZHqflSudxoPSZRm/9aaDQu8wY+7SIE4gUBCcg4Sy/AuJRgb+ir+8GhhJe5fBFCt+wBNzYFHEqo41KizTOiP767z4tpMmCO4Q1Wmda6rcxaBanO4pZozSwJgPnZJdGaXaXmSpTZ7PRz1EajTGtJKC6zPH7uXOnsvteYmpoasAwCEpBxEcYKmPPyxzdy+UJJ6NZ94FDJVzhUsotLCcGNTUHo1r0IpVKqQjfv/nRmpOAPoZE7Qn2OsTY1hNBWQDAh9ApeojGJyqx2dvVHP+qSaM0uqc4ChbEcmvIayIzlTqWpPF+utX5SrDE8cObMmWnep2F2EQKWQUACMqbCugLR4ru5fKE5dOtecGKETg5P5ObECN0cSuDibt4FxkLo5vJFpZxo0RvcGOG9JD5+mu+h4qcQQki+RLJYbqhQqG12SDSmgUiAtQpYywCaihpoymsg1VtaSnTmomKtMU5isP7y2JkzPqTDBg8DZBCQgIyFMCQwfdPOn3MosYkbtxscntDJpYTgUkJwYoRuTozQyeWLwI3bTbsIfFELN1b0DTc2Zd3sTcKrgnpyY/kbmvIalGgHJQAfMiihycKlMJZDZamCynIJcoOlW6q3ZcvMla8XyLUPeW8pEtJrGUzURxWQgPx3CkMCoZTgnlmUUMeN2w2PK+BNBJTAxeGJ3NxYMUJf3Q9u3G5wecIKDl/0FSc66e8PRH98IyGEmKqqfq0wWJsUxnKUaM2uEo3JHxLo6yK4ZXorNBU1MNobIdVYmqQ6c3aRRscvVqjvSE1NvWFyP7SABOS/SZjtvhuiEn8RSgmruJt3gUMJfEjAhwx4yS5OjNDNjduF0C17wIkVXZkXm1JJopJ2Pbvz0L+zpBpbmc2OUqPNJdVZINGaUaIxedQfq4C1DIo1JqfCWA5tRS3kBhuUpvImqc6apjSUb5Rpbb8KWAEBCchYCEMC3E1Jf+JSojZurBicGKG7PxLwkAElcHF4tIsQunkX5m7dB8IXY+W2z1xvHDyNvVmFOKPUQaa3QK63QmGwQqo1o9h/IvBYBsVas1OiNUNhLIfKWgV9VT2kemtnUZnpH4T0JicFJCABGZkEsXv9syiROHTL3gGtgH6tAkrgmkUlu+ZSQpCoJJBXdoLEivG79z8H78ApHMgpQq5Mg0KNGSpTOZQGKyRak49l0KuDxgvcEq3ZVVRmuKK/VAeFwVqQn2+cT4jvtmRAAhKQ4YpXPIBDievpnAD/SMBDBjyRez5fiBtjRZjHE4BsTATZlIjQ6GTc9cZeRH16EgcyC3C8qBQSnQWlRhsUBivkeitkOgtkOgukjMtQPLjb4JbqzG6pzuIuKTP8nhBCUoF+MxcDEpCA+CPsCsrjzeDwki/SAcHhEYC3zuELsZAvwmK+EDN5QpDoZJANCSCRSbjv3QPg7/oGbx9Kx46T53DkvByFZQYUqU0o1pohN1g95CDTWfolghKdxamtqEWJ2rzHKx8hYAUEJCAjEhY88YKZXJ4wa7QE4K1zKSHm84VYzBACiUwCWbcDZH0CSLQAd8SJ8cQ7n+LPyUfAO5iOTzMv4uRFBdJKynC2VA+l0Qa53uJDAlIdvXUo09vsJQbDTfRbCMQCAhKQkQlDAPP4e2fN4iWdH0sC8NZQSoj5fBGWxopwI1+EhTwBbR1sSgLZlEi7DesTQCgh7n7vM/xLdBTbUzNxqrAUCrrewMsNsEBurnQWqy1/p99CgAACEpCRCRMDmMkTLeDyd1lmx+3GbErgmkMJMXuMScBbZ/tYByIs4guxgC8ElyegA4obE0D+733w9h1HCbut6LVDoLbZITPYjhAScAECEpCRC5PSe1O06LFlm3c75vNFmBEtcM+IEWAuT4BljE/PGUcy8LYS5lBCzOMLsTRWjKWUEIu2pODbiwooDD5WgEtlrYLSYNOWlHrcgAAJBCQgfgsQ5FP7HynYSWLEIFFJrht4AsxiA3gb6QDejbEizOGPPwl4k8FCvghkQwJ4B06hWGumdwp63QC3VGeBRKV7iRBC+qYNByQgARlIvLr//GrtJ3PIqvgH7nl7v3zD/lPY/l2+a39OEQ7kFmHHiXxE7T+B/43fC/LKTsyOScaCCbIGuJQQ8yghgmMEWLQlBd9c8LUCirVmp7ayFhKN5VNCAhZAQALij3gSf+5/b998EimMJpHC89EH0npOFKkg01vdcr2VyeKzQKa3QKa34mSRCq8eTAOhRCCbEjGfEk4YCSzli0A2JiB673FIfGMBbrnBBqnOai8q099NSCAYGJCADCxeK+TNccm/IlGCghmv7oMwvRBFWguTpWf2yeFnf1cYrJDqrfgitxiPvP85SIwAcyeIAObyhZgenYzFcSlIPSfzsQJKNCa3prwaMp35JfotBgggIAG5WrxM/h/yxf8iMaL6G984gJT0ApfcYHXJdBZ3cT+Zd2zUvVhtgkxrhtJYjrePZINEJmER46ePNwFwKCGWxIpA1ifgn6KjuFBm9E4ScpdaLkGqM2ez1YIBVyAgAfGWXvAHzY8Vvs2hBFdCtuzBrtPnnXJm1R+sSKdEY4JUa4ZUZ0HyqXNYHJeC6TGCCQ8ILqKEINEC7Eq/6G0FuGV6K+QGW0+hzvgH+u0GrICABIQWr5V/Nk/w0eLNu0Aik/HukSyXVG/15N8PBX6Z3oLt3+aB8Oiin3n8iYsBsLqIT8cfVm37DLkKnSdDsFhrdqpt1ZDqbDmfKZUzCAlYAQEJiA/4Z8QkbVu2ZRfI+gSsEx91XVSbINMNDX4Jk58vTLsAEitCUFQSFk3gLkBfXcqnXYE3DmVAqvfELNwSrRlyYzmkBtOzhARiAQH5vgsL/vj4YC6VvH1hXApIpMB912t73aeKSqE0WNGfz9+XAGR6K3ZlFGDOZjGCo5OxKHbywM+lmIBgTDIWxKXgy7wSKI02mqg0JleZzQ6J3irLUCoX0G89QAIB+T6Kl/k7ixK+O3vLHizgC0E2Jrl3HM+H3DejbkDwKw02fH1Ohh9v3UUH/SZx5WeVQ9Gpw2RTIlZu+wzZMg0Ueg+ZucusdkjU5vfojyHgBgTk+yeemz40RhDF4QtcizaLQTbsdP096QjOlRo8EfTBwC/XW5Aj0yDi/c9BNiVi6SSv/H11CZsbsP8ECjVmSHV0erDcYEOJ1txWrDQ/TEggO9BfCaIVA2hgltt/jLBtv6MS/srhi7tnb07BPF6yi1Ai2mQ22IYAP51uW6QxYcPe4yCv7MSN1xj4uRRdSLSIosuLPziaA3Y3o1hrdmrKayDT2S6kq9VzCAnEA/pKkGf+m9e0F7+kd25cgBCuRWHy+kP5CSs4scI6btwuLKVETrIhAS+mHEMhE9EvGcL0l+ut+DJPAkIJMZsnwJxrAPD9uQLz+ELM4glAogTYl1FAxwPUJkh1Flep5RJKyswfsx/N990dCBps6CNJTQ35UXz8NPK3RA75W7yvrhXMvCM+depVr2EmxIz3hQfET2G+27mRO27n8MUG7pa9mMMTOqfHCDBnsxhfnCmB0g/fX6I1Q6azYP3ub0E2JOBGvmjSwT4YCSzgizA9Ohkkbhf2ZxWyQUG3RGeBVGdxFakNLxISsAJ8ZNor23/IiU68bzZP+PeZ0aI3OHzhIS5fdJYbKzZz+CkmVpnfNRwqOTM0Rpg8KyblmdANop+RtfG9hMAOkQzMgJ88Ycd/b942m8sTZoVu2QMOT+hcyBeBbEzEC8zqL/Fj9ZfpLMiWa/HEts9ANiZi8TVMACwJLOaLQCKT8OM39uKrczKUGm2QaM0umcEGqd7SUijXRBDyfZ5IHJ86deYG4YOzYgV8Ll+8j8sXKjibU7pCt+xB6Obd9LCHuN0I7Ud9/rdlDzixwgYuX3SEyxe+dMPzict8z+Mhgu/hhzxJwoD/R/Hx0ziU4DA95UfknEMJMYcSgPBF+OqspG8t/aDBv3SpGg+/s98T+Z9skA+koV66LFYEEpWEW97YiwM5RbQloLM4leZKyHU2i1xl+jX9cV2TI8aCCCHBhJAQLx27a5zFF5/jxIprQrfsoae7bN4FLvPFcnhCz0y4Xk2mH73/HiN0cykBuHwxQl/dR7+eLy7lxAiTZ8YkPxAaGe87RLJ3tPS19mH/N4nn853JE73BjdvNDPAQutnc+XW7jqHQj6Qf7wBgsdaMFwVfg6zbgZs2i8a1K9BodB6frhFYSNGximWUEGRTAqbF7cKejALIDDbIdGaXymqH1GApL9SYI9gP7hpxCVjgD/b/0eMndOtecDfTrZ45MUKnB9w8kZvDEw06BMLH3GLJghkmyd2yB9wtexAam9LJ4Ykvhm5OiZkes/PnV7kDARdhfIQ1/fnJL3BiRS4uXwROjNA9mxJiISUA4QnxaVYBSk22IZN++gYBj16Q4+4394FsSMAipm3XROb+D6WzKSHdR3DdDrrRaKwYhC8C2Sym05U3JuHVg2k4V6qHVG91qixVkOotLUVay3r245vE6cN9gR1KCHkgJCTkzyEhIc8QQn5PCLm5z/NHLpwYoXOoaS/DVQ5P6GYtBS5fTLsJr+5HKCWo4sYKv54Vk/w82ZC4zCfw6OlAc82ZYP950tvHbyWXErXRY73o2X5LYukuOs8lfIULZcYhI//9qVRnwdELcvw1MRUkMhlkYyLm8QRYFivCPP749gocTEMpIbg8AYI2i7Fp/wnsO30OX2YX4vBZCQ7nldB6VoJD2QV4/0gWPjl9HrkKHYq1ZqfCWAGFsRwSjVnADhUhhJ4rMIFE4L0QLiGE8ENCQvKCg4PrQ0JCwGpQUJCWELKVEDK9n9cNT8b7S+l1FZJd3NgUhL72KTixIhc3bpeFQ4m+DN2c/DQ3SniTz0X5Bg8DhDAcYVf+KOFNXL64lLuFHvHNrozzKXp/fOfxsyg1Dr7vP7DSlsDFMiOSTp7DX3ceplfZV3YiODoZC/h0cc4CPu0iTFSeQCglxEJKCBKVjHe/zkGppRIqUzlKjTYfVZnLITPYkK/U4aLKwL4vl1Rngaa8GgqjTVqgMf0pfv/+ad6fa/xwt8b9F29zn0sI4QUFBem9QR8cHOwODg52BQcHu73+tp+MlgQmkqE9o6UpoSdwyN28C5yYZG1orGjnzEjBH8hzifOvukhPXkJABhX2M1r7yQ2hlPC70K17fUZ7L+TT4LjvzX3Ilmkg0w+e9TdkLYCObhRyvsyAT7OLEHfgJO5+5wDdwntjAkhUEhZQ9ESguRPkIsylhLg+RoApPCF2p12ATG9FsdpEq6ZXJUxQk8kSZOMc7mKN2aWrqEGpqQIKvfVocZnl8TNnzkzr80GP5b3ofazfhoSE5PUBvbMP6H3+NmXKlO1erx8+OU0kAfhaBYyLQAnB3cxaBuLLnC17Srh88b5ZUQlPzqAEi3wYl7UKAgHE/qQ3xz82ZVsovfL7xHEWM000Yz9PQ4nWMmS5rz8kwJYElxptKNZZkFFShs+zCvF8yjH85o09dOPQV3ZiVkwylsaKMJ8/vo1C2P1/EpWE2Vt349BZCVQmW7+zBUs0Zp+/y3QWfJUvwwff5Lny5Fq3ylaNYoOtU2KqzDmvNq0XnSn40Vq1+uq8l5EL6wLfQAjhs6Y+s9L7rPZ9lSECd0hICAghVN97wG+ZDALw+cJ4IrePZbBlL0Lj9tBkwBOZZvKTE6ZHix6bvv6DJf1cfpAXIXy/Ze1aOtOPJ3yOEyu8wgb92M95NiWk5/LxRfg6X0oDdhTg9wGSF5EoDVbIDVYUaUzIlKqRcCIfLwm/BolLoYNy0clYSNE9/8fLNeBQTH+A6GTcHL8PqflSZnjI4O9BqjXjQpkR/INpWBCb4v5jwleujZ+dRmzqGbzy6Sn8JeVY1X3vHzx6Z/y+x0b5bXkvYD8ICQn52gvYzoFA3w8JuJjHHkLIk8zxhmedTDYBDGgZ8EXgxqaAu2UPuLFicGNTJJy43UIulbgudPOOH5G+bOeJGXwPrQMmmDo7KvF+DiWs7m+A59JYEcjLO/CK+BtcUBuZrb/Rg78/IvA0CtFZoDTYUKQx4+tzMrx1KB0/eX0P7SJEJ2MpXzRu1oB3ZeC9b+3HNxeVkPph0Sj0VuSX6vHk9i9BXt4BsjHJTTbscJHIJFxHicDZshezYsUN0yNT/h8hhIzANfU8f8qUKauDgoIU3j6+v+DvSxhTpkzRE0Ju63uOIWWyQT84GbA5BkKEbtmD0Nc+ZXIUxJZZlPC7mTzB+uv5O39C1gpm9nlbdHfb/0xXIYiABKEfJf29F+YGnL0p4QfcWLGCHuGd7AP+UEqIxRQd/BOdPj+K4N/IyaDUaINEZ0FasQqvH8rAkld3g2xMxCJKOG6BwsWxdLbjmg8OwlbXBPOlahT0Bv0GJIFSow0HzxRjOp8Omi7mizCHJ3LPogSuWTFCZ+jWfZjFE4lH8N0Gez1GBwcHt7EgHszc95cEQkJCviOEsC6Kf/f9ZAN9WJYB4yawGYh00pKwicNPOc3dLI6dHi16jMQlz+0XJBPvKgQR4gvg+HgSHI/44HjEB6emrg1hNT4+Pjg+3m/W9o2JEEJCI+NDOTHC032DfqwuYtJhw9//HDly7ZAlv+NBBrS7Qa+wUj29jfiM4GuQyCTMo+hmHmNJArMZ4JJ1O/BNgQoAcLnnCvQVdhSpjYMSgFRnxgWVAb/b+RVIZBIWMpZKKCUEh0p2hcbtBocv1nHXbZtNfxFD3lfeu1lLg4OD94/E5PczHvCy1zmHlskG97DJgNcngBibgtCtexH6+gFwKGEXhyco5sYk7+NQif+Yvv6DJaxv7AOaURYreYMZjHoADfpxpMcmhJDU1LUhicbw6+P3PzTt4OlwTmrWI9zvMh9dsn//Q2w0Osj7pguNTk4I3bq336GdoZSQnry7IQEff5vnV9rveFsFEsYiOF9mxGtfpoNsSsQcHt1IdKxIgA0E/nzbQVxqbAUrV1wuWO21KCwbxBLQmCDRWZBw/CzI+p1Y4OWqcGKEbm5sCjj8lPrQ6I9XMDfEQPeS73SlKVMeDgoKUvb14cdCveIBlWQ4rsBkA3rUZOBtHfBFNBls2QsOX9wdGre7gssXHZlJCTfMjBI+eMOGPvUJ7Jfnp4UQH0+Gs1KT+HgS/Jly1YxUxa+WpkvuX5xVsOb2jNJV92ZIV92brVnzSI728VW5ijW/y1SHvZhdtublLNWqD7NKwxIzFas/y1SGZafJV5/JUUVoz2gjrHm6iLpMVfi77I0Vz6z+HJ5oI4cSu7mUb9CP1fmMH/zYR1/gjFwL+QSv/oNZBHK9BQUaE+IPpYNEJmE2NXZJRD/aLAZ54WMknTyHbpcbAOB2049Olwvl1XUoUhuvmnEgYa5NabDh2HkZCCWiyclzzzEZsrFizOInU8wX7XtPsPdUr4QGB095J3jqjLaQaTMRHDJlVCa/HySwx+vcgy90kw3iMSUETyoyTQbcuF10FuKWveDwhN2cGEExhxKIuNGCF6eu/2T5AKj16ofQ++F5Az819aGZpySrfnmqeHVYmnLVv04pVj+XJl/1crpy1TsZ8lXvZSlXfZimWP1ZmjzsYKZy9XcZirCCDHn4+XT5amOGYnVtmiysLlsd4TprWIM83Rqc1dOap/NSbQTytBE4o41AriYCZ/VrkK1a3ZUmf9STsz6bl/g4hxJ19Rf041L06r+EEoJsSsKe9ItQDNHwY8KJQGOCXEeTwL93HWMqDEd/H4RSQvw4VgSybieyZVoAgIsBv7fUNDZDYSyHzHe8uCcYeKq4DLe9/SmCo5Mxz6vwiRMjdIa+uh+zYkT7PYDvD/jx8VM5/F3/N2313y+EEIJgWp0hwcEImRGKkKk3IGTKlPEggI4pU6asZq5icGt0skE7rmTgU6wk9KQkMwxu5vBFmbMo4bszKEEYd7PwJhKfyOn7+axdmxrCrrZZkkd+kKlc/W6uJkKSqQqryFKtbshQhDszFauvZJWG4YL5cVy0Po4LlsdxzvgYzhkfw1n9GpzRRiBPuwY5ZeHIUoUjWxWODEUY0uW0ZshXuftqmiLM5a3pslVX8g1rkKkKzyCEEMIT3Tlnc4qNTqS62u/nUuzYrET8U/A1Lo4w7Xe8tVhjgsJgxfECBULiUjB1DKYKzeOLMC86GeTV3SgwVvqs/t4/NzQ0IDllD3IKpCg1V6KYiQ2UaMyQ6czIK9Xj/5JSQTYm+FQ+cphAIJcS5JK/MfcMM0WZEELIvz6ZExqV/PQsSnSKwxe6uDwBZrzwlmta+D/c191xHw1WhhBCpk5HyLSZCLl+OkMI142WBNiYwrde4B/YCphsoE4IGfBEbjp2wBQ88UTu0LjdoAuhdoETt7uLEyuu4cQI07kxyVumR4sem7r+k+UPPdT7pZ6UrF6RUxZWls+AOlcdgWxVODIVYchUhiFTudqdJgtzZshXX0mThTnTWZWHOT0gVoa7MhSr3axmKla7M5W9mqEIw0Carghz5ajXIFfzsE184uU/kfWi/EWv7es36Mel6Iy4Gcy+/+e5Q7f7mkwrQKaz4LzKgH+IjoJsTMTCUZYZL4ylewKu+uAg9DWNAxKA2WwGIQR//Ns/kH2xBKXmSo9LINOaca7MiBdER0E2JGBxrBcBUAIXN243OHyBZlZM0m0eLK0Tzg6NFv2TwxMUczaLr4TG7QGXLwKHEju5sSn0DlZ0Amb8jcK01X/HdT/9JVjLIITVKVMQcsMshFw/MjJgXYvg4OBuQsiaIa2AyQbn5BACG0hMdnF4Ircn5yBuN0K37gOHJ3JyKJFh4RbxcbJ+13sv7Nn8fJpytemc4TGkKcJcafIwV7p8lTtDscrtDejBADxaTZeHIVMRjjTlyu71u9+sI+t3uRfxBQO+xwV8uiLun6JvUKg2jTrrb9wIgHks0poRfygD5JWdWBQ7uvyAJbEikFd24h8JqahqcQxIAAaDEYQQEEIQ9tiTOJGTD6WpwrN1eUFtwsvib0DW7/QlADYQSCXXcaIT7yPx8dO4fOELoZToPHsfsUThsT55zHfFF4G7OQXc2BRwXvkAM5/dihsef54mg8U3wctVQMiUqQiZwaWtg+um+k0IXsHFE6S3VqB/K2CywTjZ6slE9Op5wKVECI3bjUWvfwpC7UNC2itXzqhXIl0eNu5AH8QCQKYiDCdkj+I58dsgG1OwcAACmEMxJbFRyTh8puSa8/2vUo0JEr0FnxzNAXl5BxaPkgAWx4pA1u/E3xO+QmVL+yAEYAAhBEuW3QhCCH5+369x6NtTkOmtkOksOKcy9G8BMIvGLErYxaEEhzl8URF38y528aDvJd4gFbYsGcSm0I11KCG4UTvBWf8Rpv/+FVz/wNOYsvw3vmQQHEQTwPU3IGTqtEFjB15WwGVCCBsz6j94PdkAvBaVwxO65/GTXWSj2Lli2zbXkaInkVP6iDtdHj7hwPe2ALKUq/FNSQQe2/Gum2wSY8EABMCawH9K+Ar5pfprIvI/sAtAp+AWasx47cu0sbMA1tMWwKVBLQCaAKaEhGDJ0mUeMtjzRSoUBnqb8l+Cr68iAM99EiNyczfvoovaKOGAsZhBicBDBmLaCo0VgxsrBmf9R5jxNwo3PP0Srvt/K3zJgBCaBGZw6cfBA4KHBjT/AwQwsC6LFYC8kgL+gVeRU/bbSQV/hiIMafII5KpWQJz1LAiVhNk84YAdeZfxhSAbE5Hw7RmUmsrHLOd/fAiA7kh0XmXAv3YdA9mYMGYxgLAPv4ChtmlAAjCZTCCE4Prrr8fUqVMxb/4CxiUIRoJ4D/LLjPi36OhVLoAvCTDu5GAr/nDJgBLC00cjbjc4mz7BrJfew4y/RGPqL1Zhyo/v9iWDGzi0ZXDd1KsIICgoyE4I+X8DWgGTDbRrUT115dECvP3NBuSWrcCoCEAehkzFKmQqViFDPpLVPxy5qodxtDgCT+x8GyRKjHkDbJctYLL+7ntzH04UqSY18cdfAlAarMiQlOGm1/eCRCcP+N781Xl8EeZGJ4O8ugeFpkuD7gKsW7cOhBDMnz8fQUFB4M6bD0II7v7fX+HVwxlY/dGXIJGJo76m4auglxT4YoSyrfpiksHZsB3T/7Ae16/4A6Ysu7WXDIIIHUCcOg0hIcGeHQFCyGsBAhiGzuELERojAtmciO2nn0NO6cMjJoBMxWqclK6BzPIbyC2/xgnJGmQqVg/L9M8pfQSpBU/iz4J4kEgR5lIDl9QuZUp+16V8A9TLd1UAACAASURBVKnOMukAH1SZHYBirRmvHkxjdgBGXy48mxLiJr4Q5JUE5Mh1/eYBsCTwzTffgBCCn/zkJ5gzdy4WL14CQgii3vsEd23dBTIG25KjVm/rgC/qDSTyRZj14juY/udoTP3fRxBMCKYQgunXEXDnzsfUqVPZYOBFQshiBvJBAQIYQufyBZgSLcLtr+1ESu5fkF36yMgJQLka3xWvgb7ql6hqvAUnpcN9/aNIzngWv373Q5BIMRYMct1zKLoBJolKQtKpc5Drr00C8DQY1ZiwK6sQ/045hmk8Ia4fw0Ej/7NZDPL8duzPKoSLRny/BNDY2Ijo6GjPbgAhBC/+81m8fzQbc7bsxvTo5Guz8al3IDE2BXOiEjD/n69izoNPed7HlBtmuGfOmuUODg4G6d0SDA4QwBA6jy8AiRLjF/E7cCD/D8hWPjoKCyAMJyThMFffje6e65GrehSnZeF+WgHhyFQ+jNjDkSDrU7BsiNVxAV8IEp2Mu7ekIFtSBrn+2jT/2WuS6i1490g2yAsfYwmfJrCxqgVYyBeBRCZi1fZDqGluu8oN8P69pqYGiYmJeHTVKny8/SOYbOV4PzUH5PntuDlO7NNifLLvTVZDKdpSXcinYz6zKBFIDF3z8dOoD/HXjRRLBC7u7NkghCR5rf5BAQIYRH0JYO2oCIAO4K3GpYYfAyAoMtyPU1I6gcgfAsgqXYmPTqwDiRJ5tvcGuu5FTN7/4zsO4/w1H/2nff/TRSosf3MfgqKSxnS8+ByKbkVG1u3AyRJNvwTg/bfLly+jorISLqcTqkv1ILFiLI5OwjI+PVdgaawIi/kiLOSLMJ9Pdzaay6cTruZSAwdkxwzszHnmMf0Wl/JFmB2TTLde25CAYJ4AD76zH2+nZuFkcRnOKnTY/fkh/OLXv3ERQjB3wQI1IWR2gAD80Ll8AaZGi3DLqzshyv4bckbhAqTJw5GlXIWWjoUACEzVd+C0zL9jpcvDkaN6CAfzngWJEmHWIJF/LtW7/735YBo9FVc7tqm/bNecMSMCjQlSvQWJJ/JBNiQglCfAUqaz8HBW29kUDcaFfBGWMDqf+Z28shOrt32O6n7yAfqSAAB0O91471geyEs76Fbi0cl0Y5CXd4Csp8FGIhNBopIwJSYZ02IEmMZ+/nyaJObz6R6Ic6ih3we7ks/h0+CezwB8cSz9PhbzactuFiXENJ4AJCqZvo71CSBbduEvH3+JrZ+dxJ6sQpwv1UPC9GmU6y1QmipwIiff/Uh4BAghrbfeeusjV7kBkw22a1FnU0LMZoKAH536F3JUIwsCZirCcEoajkL9/bjinA6AoKHtx8MkgJU4fP4vmL1lJ6bEDNxcM5Ri9r/X7cDuk/lQjEPTD7ZOvpCpKxi9FcDmAJiw7etskBgByMZETIlOxjK+ED+IFWFJLA2GBUyX4SWxIiyKFeHGWBF+GCvCD/hCLOAJMC06mZ4FsDGRftyUiOkxAix/az9WfnIIaQp9v4Bnf2f/VtXQhK8vyPFZdhE+zSzE/oyL2HsyH8nHcrH14Gls2Hccv0s8grBPDuOmN/dj4Wt7MDs6mZ4/wJ4/MgnTY5IxlyfAYkqIG/kD61KKbtU2lydAcHQySJTXcTzvJQnBkUmYF5eCX334BWL2n8DeU/n4Mk+CXKUOSqMNCgb0rHXFaqn5ElJPprtuufU2EELeDRCAnwQwnycCoZLx3rfrkKtaOQoCCENF/U8AEABB6OhegLPqh5Dmx/HS5eHILn0URwqfxG8+fA8kUox5AyT/eAjgpU9wOPMiVGO4/8/m6+cpdfi2sHRM8wrYtFuJzozDeRL8U3AEP9mSArIpie4huH4nveoyoPb8/vIOkJc+AdmQCMIT4gdbduHxt/bhmZ2H8eLe4/jku7NIK1AgW6JGnlKHgjIDahqbfYHfxxJoae/wAMmnjbipHAqjDUVaMy6ojchT6pGr0CFTUoaMEhWyikqRmleCDfuO4/fbDuDB+L1YsiWl14LwIiUfcDNkQfgiEL4It2/dhf99fQ+efns//rH9CzwjPAre52n48Ns8HMuXIqtEhWyZBgVqE93unJ5u5AN47zZvnroGY7lzz8GvQAg57gX+oAABDKJL+UKQTUK8djgGuWUPDZsAspSrcVyyBsXG36DHORX0oJnr4HQRlNp+jpPSobcD0+VhyFKE4bh0FZ7b9RrIhpQBs/+8CeDLjAtjlgDEgr9IY8KneRKcVejGvKMQSwJyvRXFGhMOn5Nh+7EzePOzk3he8DX+npSKlR8cxKqPvsCzgiP4c+JXoPZ8i21fnMa21CwITl/A53kS5Mq1kGjp8WVSnQUKgw0Kve/KWFFTj87uyz7A7+7pgb2+iW4hzoCIbR9e4vVI9zk0Q66zQK63QGGwQmGwQmm0QW6wQqI142KZASeKVfgsrwQpGQVIOJGPD47m4MN+9IOjOfj42zykZBQgJaMAh/Nl+KZAgXyljn4fbLBU13suud7i2UFhr2vI709vdcmN5Ujavc9MCLmHEHoOYoAABlE2EzDuwGs4U/aA3wTAgvqkJAJn1SvQ1rkYAIHbfR3c7usAEFTU34mT0jC/AoHp8ghkqx7EtlQK5KVdWBw7NAEcyrgwJhYAGz9QGqzYl12Er8/Lme66Yx9YpFt008eV6y2MWWvDOZUBeaV6pEvUyJCqcValR65Sh2KtGSpTOZSGXvNX1ifvwXtlZN9PkdoIpcEG06VqWO21MF+qgcpc4WkO4s81DqQ0WOlmqAq9tZcgDLZB1OoDbvp90OfzuFn9nHeYn69bpre6pToLcs9L/koIIampTKeiyQbataqL+AKQjSn4Y8K7+LaEjsiflkXQ9fuDmOwnpRE4LQuD1PwrtHUu8IAfCIHbPQUAQVvnXJwpW4k0WcSQJJAuD0du2Qoknn4BJDoZi6j+A0veMYBdJ/MhH4MYANsgc19WIbZ9m+dJLBrrbsIDgZa1PmQ6MxR6i1uht7hlOrNnFfQ2e71BPlTgs1hjQmGZAQUqAwrLDJ4+AONx/SNXhnDG6LqKtSanwd6AMnP5m4QQAraJyWQD7VrVuXwBQqLFuO3tD/H5ud/jou63yNc8hAzFapySheOULByn+2iO6mHILfehsuE2XHERH/D3Kk0CqvJf4LgkAmn9HIfVUzKaUDIVq3HwwtP4zTvbQSLFmD+AG7CYmfv3yr7jKGRM1pHeQCVMW6zvChR4Ye9x5Mq147b6D3EdbonW7JLprcyUHyOtA6zAfdQl0ZpdzDFYhURLr9RS3Rjvalzb6iqz2SHRmY4plTUzPCQw2UC7VnU2JcQ8SghCJWF/zrM4p/0NpOZfwN70EzS0/Q/sjTejqvFWVDXeAnvjLWhq/zHauxbCyQC/d8UP6UeD4OiajarGW1DVeCvsjbcwP3tpw62obroFNU03oaL+Vhirb0HMvldB1otx4wBuAJsIdNuru5FWrILSYB22G8A27pTrLciSavDEzsPYl1mAUmP5ZOQUuBTGcpRaLkGiNbfJDNZWhcHmluutkDOEINNZaMtE10tcxYw5X2atQqm5EgpjBWR6KyRas7tEY3KVaEyufshhsgE67p+l0lQBmc5qydFofsgQQHCAAAbQUEqIBbECkJd3IfHEBmSVPoQMeQTyNStgq70dTicL9L46hVn1BwI/qwO9vledLoLa5psgM9+HfM0DeO/bl0BiaGLqLyEolBJiAUU3Aon69CQKNSYomPl/Pqod3J9V6K04rzLgLzsP4+WUY5DqLZBoJ3zld9Hgrawv0Zo/PqdUh6ks5Y/WtXcZGrquoKq53VXV3I6KhhbY6pthqWmE0V4HfWWNW1tuh8ZW1a00lZcoDDat1FheIdOa2+UGG4zVjdBfqoemogZl1iooTRVX9QT8b1SpzuySG2woK7e3F5Xp7/bEASYbaNeyLmDiAM/vjcNJ2Sp6W08WgRPScBQa7keLYyFj5k+D2z21H3N/MJ0CN67zVff1HhehuvlmFOjorMGT0jXIUKzC14VP4b53PgKJFg1YnTabIQISmYS4g2m4WGb0CTLJdLT/LNX2jWbbINNbIWW2+zbtOgbCF+NkUelkVBS6FKYKyLTmdonG9JR36npNS/s7Td1O2Fsc7urWDre9xQF7iwPVrR2obu1ATVuHq6GzBy09VxpM9vp7z5WU3nRRrf7FxTLtarnOslZprFin0JrflOnMe0o05kyJzmSS6K1dEp0FJWom6q8ecbDtWla3VGeB0lTZI9FZ1hJCSHzAAvCDACLFeHDbBzhR8gSySh/1RPpPSekof2uHb6BvpOrGFABB6HEGQXfpXqQz5/DOKchUrkTcoWiQDXQzkP6CgRyKqWbkCUBiBPhjYip2p1/EGZkGZxQ6XCgzolhnRoHGhPxSA/IUOuRI1UgrLIU4/QJiPk/D09u/AHl5B97/Ops2nccwGDWUlmhMbrnBBpmxwikp0z/P3qjxTNPNyrrWm6tbOivqHN2wN7d7CMBLXbWObrRc7mmqrGu9hQwgarV6qkRvn3eqWL3IXFH/l8pmR7fJXg9NeZW71FwBuYEmwxKNCUVqg8etuAaAPGICkGjNtMWjM70aiAH4oXP5QpBoEW6LTcbB/L8iy6ssOFO5Gicka1Covx+Xr9BZfu4hzf6BrQEgBE4XgcL6SxyXRPhsKXqyAksfxpfn/4hbX/8E10WLMHeAYCCHYpKZKLolOIlKwl2v7cGqjw/jXynHsPXgaUTuP4E/JB3Box8fwvL4fbiZR1sNJCoZZN0n+N1HXyBXoZvoegK3RGt2q6xVkOrNb7A3KRuxZh/tja1vtbsAe4vD1R8B1Dkuo87RVVfX2n0z87opAIJTvYIw3mRQ34HF1a2d1vrOHlS3drjsLQ5UNrbCWtsEo70O2vJqqCyVPkFIj4UwjjsiY61SncVVZq2CRGv+IpAJ6IeGsiCKFmLrkQ3ILn34qky/k9JwWGvvYPz2kVoBtNlvrVmOk5JB8g3kEchSPQj+57Eg68VYMkSTilBKiEVMXj1h01U3Jvhm10UmgUQnI4gnwGyKTk8lUcnY+d1ZKCdghqC3lugsTk1FDYp1lsNqZgx3vFef/VQghBBCrHV199Q5Ou0NnZdRdbUV4Gro7EFVY5umoqJiiSfY1UcYYgkmhJCaGsy41NL+VVO3y4dUWLeiurUDl5raUN7QAnNNA3SV1Sg1VXi2RYu9Ao+TDfJBVWNyacprINVbzgYIwE9dyheAbBDjGcFbyFA+wgCxlwBOy8KRr16By1c4I7IC3GBzA+YjV7USaYOUCtO1AY/gi/N/wL1vbweJFg24Jeitcyi6yGQBX4RFTD49W9m2gCmcmcdnZulFJWHVB5/jrFIHmX4CV3+NyVVms0NqsElOqdWLBgFuML1q9wgdAOwtDmdfAmjsuoJLzQ5ZrcOx0OPrDiBsQkxVSxvlAGBv7teq8CEEe4sDVc3tqGxsham6HppyO5Smckh1Fp8tSglt0bgk9E7D5BOA1uRS2+yQGWzq7Gzt3AAB+KFsHOC373yMLy8+hWzlKp+sQPrn1WhoW8oQwPCsADY7sLxuOU5IIpCp9KdAaAVeT90EslGM+dTY1Kl7XIaNiUg6kc+ktk7YiuaS6W2QGaytEp1phfdqPxABVDd1hNe0djlqHd19XQFX02Unqloc6WZzE9fbdRjgeCGEEFLR3PZkTVunu5o+Rn+xBVQ1t/sSghcpXGpqg7W2EfrKWqjMlXSQ1ViOMmsVFMZylOgszhKt2TnJJOAqtVyCXG8zS/T62wIE4If29gdMRsKpF3Cm7CGkySN8QHlaFgZr7W2ebcDhmv9uN0FZxb046Ue7sHR5OLKVj+Jo0ZN4+L2P6C5BflgBQxMd00tg+5fIlWshn7jV3y3Rmt2l5kpI1Ob3CPE1+/sBbBAhhBiNxuvtze15TZddVxFAc48b9qbWz9TAVD8IIJgQQmz29tur27o0zA7DgFbAYMqSQVWzw1Xd1gldRXVRic56Sm60NZfZ7FDb7J7EpskggBKNyU2PQrPUnpNrHwoQgJ+6JFYAsm4XXjsYh8zSB5DRpy7glCwMBvvdwyYA1l244iKQWX6BU0zWnz/1ATllD+LD714B2STE4lG+Pw5Fl96SV3Zix7EzE+37u1TWKkgN1ovpavWcoQDr/f+qhtZXmi+7wGwHuhkQOttcgL2x9a3hHEsikUxv7OpOc7iBqub2EREAS0D1HZfR1N3TYm9q+i0hhBSqNI+WaCwfSnUWhcpaBU1FzWTFC9z0ro6lo0Bj+lOAAPzU+XwBSGQKfvvBezhS+ARySn07BJ2ShcFQdc8ICCDEE/2XD4sA2GrDcPwh4R2QjWIs4QtG3E1nEdNJ+Ndv7UdaSZkneWgibkipzgKZ3tpTUGZ8nBCvIpXBQRtMiGdL0Fbf2eMdDHQ53MClpvYXmef6fbymjssHugBUNbf3jSsMiwBargA1Le1fkj4NOCVq8w8UauMf5Trb51Kd7Qq7Kk8kAUh1FrdUa0GR1rQ+QAB+6lxKiBt4IhAqCZ/l/R9yVCuuIgBz9fIRbgXSMQBD1c+G1TGY3hZcieSM50B4yeDEiDBnhK2rlzBzBDYfTGNTZidk379Ya3ZqKmpQojUfYff5h1qx+0pde3dCZy9o3TVtnahq7eiubG0NownFfwK41NrxakPXFTYG0G8cYAh117Z3oaqlvbG8peVe9tjeOw6EEKLT6WZVNjYrbPUtUBjLXWz6smT8Sdct1Zndcr0VRWXGqAAB+KmhFFMXECnG5kNRyFQ+7APEDMUqVDf/sB8LYArc7ilMKfBUT0mwd42A2z0VAEFl/V04LvHPAvBR5SN4eTfdK2CgUuHBdB5fCBIjwKJYMb67qJgw35/2Rysg01obJWrzb2mwDr36s8IGCatb2tfWtne6mCCdq7HbCXtrp85U03SnN7j9IYDyusY/1ji6O2vaOgcMBA6mVc3trtYrQFVL50fMcYP6ngdAkAS4rrrVcaLpsgtVzW0uk70eKkslTYrjnIFYrDU79ZfqoTTZPgwQwDB0EV8AEinCqg/fxykZ3ecvQ9Hb88+79NeNKYwlEIyBc/17yQEgaGxf5pNo5K8VkF36MD4/90f8+LUduC5aPGBy0IDvi+kj+G/xNyhQmzwdZsadAJg9/xKdeU9/YPEDtEGEEKLVaudWt3YUtTqBiqa2ng4AdY7uk2wegT/HZQnAWtd8T33HlZr6jssjCQS6Grp6UOforDRWN97hfVyvE3kSmiqbOpIbGWuDDhy2w1rbhDLrJWY7cXyIoFhjchrtjVDbqj4KEMAwdC5fiOtjRJi7OQmCzH8gR7USGXK6bPeC7kH0OGcCCPIAGiC4fCUYzY5FqGm6Hfam5ahuuhP1rT9GW+ccuN0sEdCdgq44CUqM93vKf/22AOR05+DXv4oGiRR5En/8eU+hlBCLGfP/QGbBRA4RdSmM5VCaK5ovqi2/6BcswyCBS43tOxu7nLC3OHo6ANS0dyQO55js87Tatrkt3U4zvTL7HwhktgddTd1O1Du6P/Tn3NUtnR8y19wbwGSOV17fDE253ZNXMJbfSbHW7DTYG6C0VuwIEMAwdQlfCLJBjOhP45ClehAZCrpe31B1FwNoesXv6ObAWH0Pigz3I0e1Aiel4ThesgYnJBFIV6zCWfVDUFp/DXvTTei50msl2OruwGnZMMx/r4Dg0eI1CP/gA5BI8YBTg/vqAqZ3/gPbPkO6RD1hwb8SncWpLq+GTGf5ViKRXOcN5pEQQFVz2xO1HZcd9R2XUePovlze3PbMMAkgiBBC6uows6a1o6D56q3FoSP/nT2wNzssWmv1jwZ7P+zfK1vaYhu7rqCq+eq8A3Y7kSUCic4yZsVJxVqzU1dZB7mh/GCAAIap85ikoF+/8xEOXfgdcksfxSlZGOpbb2ZW/JkwVi9HXtlKnJSG45SUbvjB1g6wK3uaLAInJRFIk69GkeG3qG+l4wddPdOZTkH+zg3wTg5aie0nXgKJEg45P4DVH8SKQNYn4LXPTk7YEBEmuOhWmipQrLf+aThAHQhMWm3b3Jr27jIHgMau7oralu6bRkIAarV6alVL+5dN3U5UD4MAqprb3S1XAHtTx5bBwO99TdVtbc80dvV4gocD5RXYWxyw1TdDbauiAUx3L2L7GQx7B4EOvNZCaaxMjY+PnxIggGFoKEVPYiEbRdj2zUakyR9GifEBOF0ELY7FKNA/gJPScA+ABzPlMxWrkS4PZyr+VkF36R44XQS22juZYODwCCBLuQqnZGH4U8LbGGx0OKuzKbodNaFEEKdfhHLiSn5dKmsVZHqrvECu/REhgyf+DCEeoNU2dyR3A6hubj9zBpg2FBD7IwBCCKlscnzIlhv7G/hr7nGhpr2rVFPfsdgb5AOci95xaHM8OxQB9CUCa20TyiyX3KWWS2CKelgyGBYBaCtroTCWH16bujbQD2C4Oo8vAIkWY8WH23C06DFUN92E2pb/QXbpw8w+/vBM+EzFamTIw3FSGg6F9ZdoaFuMi/oH/J4d4JMcpFoBYeZzIPwkzOWJBrUCFjJ7/4+/9xnOKnWQTlDwr1hjchqrGyHRmQXDAelQgKp1dP2tB0BVSxtvJMdln29vdmxq6naiutWvrUC3vcXhbujsQVVr53pCBk5h7nu9wyEAe7OHCNz1HT0wXKo+IdNa8sqsVVBZLqFYZ3b6aw30EsClw2sDDUGGr57A2QYRhOkvQm+/GenysGHM+xuICMJwQhKBIsP9UJXfi7RhjhFPZ45xSv4InhXGg6xPwZLYgZODFvHpzkGRB055tdMa99XfLdNbITXYOi+q9E97A2Kk4kkKam39hb25w1rZ2jmioKJnK7Cx5Y9MdH7olbnF4aTjBR0ldrt9HnOcoTIPGQJo858AvHIMalodrQ2tHT9PTEy8XqoxR0q1Fpu2opbN3xjSLQgQwBjoQr4AZJMYT25/H8dKhr/qD6ZpsnBklT6KLOWqQTsQD+QK5KpWYk/OP3DzlkSQmP47B7FDOKdtSsRXZ0ognyDzv4Sp+FNaq8ryJZLF/gDGD+DSK3dT1w8uNTZHVVTUL/H+u7/Crtz2uqb7G2kXYChguqtbO1Df0e2uamr9sze4h7je4VsAtKvh7ARQ29rxKfFyffLVxp9K1GahwlDhUJdXQ8JWIA5GABUBAhiVhlJ0/j3ZJMS736xHjmr4g0OGAvLIXsdYE6Ur8OfkN0E2ibG4HwKYxzQPXf7WfuTKNBPW8qtEZ3GW2eyQ6M0n+gJ4tAIgxA5MH0VAkc4FqGn4pb2ts9uPZCBnmwuoaev4FoDfWYwjcwHa3bXtXajr6O60NTWFE0JIPDDFO3FKojWukujN+UpTBV19SDc/7ZeEAxbAGChbJvzwtg9xtPApZI9igOhYEkeuaiU+O/tn3B3/MdMv4OprX8QXgWxMwPO7vsW5MiOT/DPuqz8kWrNbYSyHpEz/5khAOp7CAtPW3n57VXNHRZ/6gqtW/5q2TlS3d3XWtnQ/QsjQvn/f8wzTBXC19LjR0NF9lE1tRm9SkYfwPlMqZ8iN5W/IjdbmMqsdUp2F3S24ygKQG8u/ChDAKHVJrABkvRjUQQqZypXIUEweAdA7AatxrCQCT+14B2RjChYNUCB0IzM1N+lY7kS2+2YLf1xSle1p75t4DEE84uN5CMDefru9uaO8YRACqG5xOFudQFVz27CzGNnzVLW1PecnAbhr2jphb+5oNzPVhVedDwjytgYK1frfygzWPE15DWQGG4q9+hAEYgBjqPP4AgRHi7Bs607sz/vriKcIjx78rOn/IKiDfJBNYiwapGvwAp4AhC/Cp9mFUBltYzrsczACkBlskGnN7QVSw+2EEBIfP7oA4FgKCypFZeXSmtbOguYeN+z9ZwO6Grp6UO/oLNc3NNxGyPC2MT0WQHPX//lJAIyr0SXue639vQf2WkrLy2eXqHXvSA2WVm1lLRhLwB1wAcZYF/MFIBvF+GvymzghW4Us5eoJJ4F0eQRyVQ/ho2/Xg0TTvf3mDHC985ntv/vfOYDvikonctoPbf5rTIazJtONg93IkyHstRirq+fXtHfltF7pty+Au6q53d3QeQWXmjujmNcNd7eBSV92RA+529Dc7mrsuoLaji5jRW3tj/09n/dzio3WB+VG2/kyq52ef6A29gSCgGOono5BkUK8981G5JY9eFXDkPE2/XNUKyDKfAYzNycghOkTOND231Km8cdGwRFI2Fl/4w9+SDQmt9JcCYnOfC7faJzvDYZrQdhrUdbULKhr78pt6Z8AnM2XXahq6jhX3tIy2/t1w5XqJscHbC1A31Zj3rsM1a0dqGpse44513AsDU835exs7dwSnXGHwmDrVpoqoLZVQ24s/yqQCTgGyqGYhiHRYtz66k7sz/vzhLkCbDXgZ+f+iJ+/Tc8NHMjv9xAAn54fuPXz0xNZ/AMJkwEo0Vm+8plNd42INwFUt3Xm9UMArrqOy6ht62i22JsfIMT/wJ/XSTzVgPaWDmFjt28xkNeWH+wtDlebE6hsduz1CvgN+/Pyjg1IVfqnZVqzvqK5E6WmCtqlmGwA/Tcoh2LKhTel4KmEt/FNyRpmH3/8SIAG/yM4UvgEHv94G8iGwRN/uJSXtRKVjPe/zZuw/X/WAlBZqyDVWQ5fywTQ2AhOnaP7eJvLhwDc9uZ2d0sPUNXcEc88f8TViwCm2pscJxsH7j/obOlxo87RLbHU1i4c6flY8Y5RXJBIfqwwVBSUWi8FqgHHUj11AptE2HQgFhnKh5GpCB92Mo+/4M9SrsJJSQSeE8WDrBdjqR/NQObxhQiJEeCHfBG+yJNMWAGQNwFIdJavlDXXLgHEx8cH17V37Wa6DLnsLQ53dYvD2eYEqlsdJ9R1dTO9nz+Sc9jtmF7d5igdoAGps6HrCuxtjrqKurr7CfGvq5E/5wZjsch0uiUlRtvPCCEBAhhLnUMJMZcnBIlJxvvfvYTs0hXIQ3B5KQAAIABJREFUkIcjfczBH4YM5cNYvz8OZIMYS/3sAbCQSQD6zZYU5EjVE0oAJf8hFgAhJKi2vWsXQwBXqlscaL0C2Fscp+s7hi728ecc6rq6RXXtnRVMDMDlZfq76hzdsLd1dtlbHX8dzbkGuQbf4002aP6blEPRW4PTY0RYujUBgsxnruofODrw0/n+WaUrsfVwFEiUAAsoIeZQ/tX/s80/H357Py6U6iey9fd/TAyAMATgcAP2Foe7sduJmtbOzytbW9mOxaM2xS81dt5X3drRUOfo9uQaVDW3u6pbO9DYfQW1js4Y9prG4zPyOe5kg+a/TTkUUysQKcYv3/oYn+b9BdmlK8eGBOThyCp9CNu+exGESsasGBHmDaMb8CKm9/9jnxzGBZVhYuf+aUzuUnMlpBpLnkSv96twZiLFKwg4o66986suADVtnahpaX9HArBNS0a1GrMEUN3W9kxVa8fl6tZOdhfAVd9xGY1dPahoaN/KXs9Yr/79ymQD5r9ROZQQi2PpgqEH3vsQhy78HjmqlUjvM1BkeKt/BLJVKyDKfBbzNifh+hgRFgyzFThLAM+kHGP6/03s4E+FqQISnUV7ptC4jL3Jx/0G91N8dgFaO4rqO7qv2Fs6/g0GtGMBRk/lYmPLR0wOgMve3O5s6OxBbWtne3V7+7qBXjNuMtlg+W9VDiXE0lg6Sejx7e/hSMFTzPbg8EmA7fizO/dvuOeNTzzTgIY7B4CtAeAfTEOx1gypdmJ6ALAEIDPYINdZ2goMNjoTcCJWOD/F2z+vbnXsr6xv/Vvf/43VORo7L3/FTDfuaXUCNW0dVRXNbU96P0dutYZqtW1zCRlVw5ShZbKB8t+sHhLYIMZTn7zLkMDwLAF2IGjqxT9gxTa659/iIbb7+tNQlgA2JODdr7MnNgmIIQC2FqC4zPL4uN/YI5SaGsyw1NJDRQkZe/BbLLUL6zp7zrc6gcYuJ2ocHYXlTU2/YZ4TzEbq7S0d99pbOlLLm9p+QwhNluNiMU02SP7blUMJsYwvBNkoRtgH7+PA2T8hR8XsDgyxRUjv9a/CsZLH8PTOd0DWp2DZCMDvQwDrdyL5xFl6+OfEjqdyS5h04CKt6d0xv5HHWMYabGzSUGV980O1ju7u6rZOl7217WOtlp7S219SUUNH9ze1jq5uS23zP8brugIEMFEkwLgDD777EXbn/g1ZpSuRqRi49j9dHu7p8PPi3jiQjWIsHcUQULqTEZ0GnJpThFJT+UQVAXm0WGt2qm3VkBvKM0aT3TbeMtQ1jWQ1Zn35ekdnZF1bl9FW2/479n999/lZK6CmtXOjww3Y2zq7q9raKJBx+MwmGxzfF+VQQizhC0CixFi0dSc+OP4iskpX9Fs8ROcNhCOjdCVeS40E2STCQsq/Tr/+EMCR3CKoJoEAJMxWYJnlkuGCRMIWt1xzBOCvDLeaEUBQZXXrL3QtLf/D/t5fkM9TmtzW9qtaR2d1Y7cTjd1O2Js7t3sfa7TXTwgJEMBEk8BCvgDB0WIQfhI2fxmNk7JVyGGaibAuAd3YYwWS0v+J6zcnYvowt/sGJgAhbQHkTo4F4HEDTOUoURs8wztBSFB8PAmOjyfBGEDZ/8cTEkz6DN2cCOmt4utcdqmx8T6vv4+qA9Fg55LY7dPrOy6XNPe4YW9xOJsuu2BvbH+77/NGJZMNiu+bssVDHJ4IZJMIz4pex5cXnkauagUyFfTMgJzSh/H5ubW4K/5jkCh60MdowN9LACKQl3cgNaeQJgD1xI+o7h1MYTvx3XffzRrpfYt4EowJJAIWbFVVjTfWtLRn1bV379DpdLOY//lNAvHx8UO6D97/r2pu293U7YK9xeGsaetEdWuHu7q1/eW+zxuxTDYgvo/KoYSYy6ez+MgmMX797odISvsXMpQPI1e1Eselq/GX5LdANqaMauy3t9JpyvQcgKP5UpROXCOQq6wAqc4CicZ0ubiy/kFCCPnuiSdmHQu750dZYbetzlx9Oz97zU/fzV5z596za+48mL3mzu3p4ctfz1zz07+fDv/pz06uWT7b+/5NXUtCJooIWKDXtnckA3RtADsHkNnRGLvrYOsGGh2RdY5udjaAs77jMmpaHS3W+uaHCBmDOoHJBsP3VTmUl1m+SQzCE2Ddvi348sLvEXcohp7zR/k/588fApjDE4DwxZNNAHQswGZH0dnzB7PuJeuy1tx95twTd1flr7mzNW/Ncpx//C4UPXk3Sp66Gxcevwv5jy1HbsSd3Wcfu6s+97HlZdnhd+zMXrP8kdSH7pjJ3sfxtGswruJpHNrU+pfq9u5uhxuoae0yWqtpMI7lVh2bI2GtbV1d33m5x6tJqbPVCdQ5OkrUdXWLvK9rRDLZQPi+KxsXuD5GBLJJjNmvfQJuXAKmDTHYY7ja1wWYpCAgrRoTpMYKd/GXB3BuRQjOPX4P8h+/C2fWLEd2xJ3IWnOHKzPiTmdGxJ3OzIg7XNnhd7hz1tyJvMeWI/+x5bjwxF3ICb/zSnb4HcfTI277feraO+hJwIQEjac1wILbXN92a3P3ZQOdvnsF9pb2JntD21PMc8aEiNjj6BsabqvrvNxV297laR5S3drhbHUCl1oc73lf14hksgEQUNYloKv1ro8RgcPr7d0/5gQwuUFADwFI9DYUS+TunH8+5cpcudiV+djdruywO9zZ4Xe4syLuRDajWYxmh9/hzoy4w8VqdsSduPjE3ciLWN6THX7H8dPhyx/0gCd+fKwBr63LkLr2zvzWK8ClZsflpssuNDguN9kbmseMBFgLoKqq8cbqlg5zQ2ePd+Wgq9bRjZr2zjprXfM9ozrnZN/8AfXVOXzBmK781yQBaJnzGspx/vP9yLqPIPuxn3lA75eG3+HOjLjTmRNxJy48fhfOrLmzKWfNnVtPPH7vdELGiwS8gnONbbsa6Hx+t7253dnQdQVVze3Nl+qbVxDSu5c/UmGzJCsqKpZUtzrKPLUDfVqF21vaUpjzjcwKmOwbPqATo97bgF9PXh6ArxVg+P/tfXlYU2fa/qvdW0nivnWdaacdWez6tZ3OtJ2uCmrbmc/5Zqbfb5ZuM9OqqMlJQNt+dG9nWmVJTthcaq222M3aqpAgKotKFhIg+8ouWmUXxST3749zTgiICgoS4NzX9Vx6icDJOee5n+d9Vi9KtQYUxP8Vqocug3rh3VDHRg6YCFSxUb49bOwgPy56+/anI29llGjwSSA41KO988+NbZ3cKK9AfXO776cTXTjccsLhbmyPIeQCRob18XuMhw9Pa2juOHT8zJXl/p9OdOFwe6e35vjx6NDvGRAG8wWbSDGu7BRW+rummpdLQwBcKfD6XUWXeh7g2UnAUY3iLZ9BdR/nBUQPjABCiEA9LzLAkkDFzidvZ+rrB5kEOFe76lhbZGPrybbD7Nk8uC/gNFDX0lHEjfK60IYnTpkPHz58XX1rx9ctp9FjTDk3N/D4ST9qjrW8FXptA8LFvFCTpDSmy5S4XqbETIrGVIkC10kUIGI5iFgOgUSBaZQCs6XMNNppUiUmUYMX2eblAghgaQreH55moLPEAjzQ6MtRsOwvUP3maqgXDvAo0FMCec/c6ytcdBfUsXOqfoi7nXHHB5EEOMWsrj46q66pvewoczYPHerpazkN/NTeuZ7zAC5mfJjj2DFBQ0vnj829CIAbItLM7C/Ya2llOgcH/LsG+iJNpBjrPlOqZPLKS1NAXk0GWZ4GIlZgvEyJW1dl4OZVGSBSJYhYARKfCvJaMkh8KqZKFJgpZZZW8t7BpRWOAN7YmguN1X2p24HP6QWUfPctVL8hUMfFXJgXsPAuqOPuxO65BLmPEF/hs/dAFTunase8qEcJ6c9xoH+KEzrXr665bUvTKX/vDUKBhpYOHO/sQvVxpr//YgaIHjlyZMbh1g7DWQaIBhpaOlDX1HGioa3zkQv6XQNR/JlSJaZQzFw58loySEIGnl+7FW+s344Pvt6D5B8KsV59CNuK9PiyUI+svINYu2M/3s1RIXHddiz692YQdi49WZGGiRSNWVIlJksHN+LNyzkIYFkqXs7+HgdNTnYnYBgQgNUDjdGEvavjoXpo/AC8gEioF9wJ9aJ7kPfIZKjuI9Cs+QAVn2Ygf/H9vr3z7kB+bHTNLpYEBssT4JSsvq3zdbavv6diNrX5j3Z04XBbR31DS8t9F6KYXBCwpubE7LrmNksfQUDOCwi0+YHGtpNcafXgE8BM1sUn8akgy9PwwHuf4t9fqfHlPi0KjDYYHF4YHV4Y7R4Y7B4YbOyfdg+Mdg/KHV7o7R7k6szYWqDBv79S44mPtzDewbJUXC2W43qZEiKKJ4IhJ4D4VCyWf4WSSselngh0Hi+gCiXfb4fq1wTquDuhjuuHF/DMfch77Gbk3k1QvOx5ONV5aO08jWqTGbmRBHkL7/YVLpqL/PlR1h2PRt9BSN8kAOCK44CwvzrDRfirW9r/xAbnzpjtX9/c7msLAA0t7T86HI6r2O8b8A7B6sbGnze0njh2pOPU2XYV+pu7Ajjc2pnOfc+AjgF9vSgCigngzZAqMVtKM27+8jQs/mQLsnYXY2+ZBVqbm1Fs1or0R8qszPdorW7sN1rxWf4hvJzxLW5ITAdZkoJpLNnwRDCEBLA8DU98tBlFl34o6Hm8ADc05WbsfWMlVA8RNiNwNqs/F+pF9yL3ToK9f3gU1u1f42hDI1pOBtB6CrB8/y123UagWnQvcmOjfEVMkZFK9cQ9QkK6jwOclXUePx5Ve7yNs6DnVR7ubN/Q1PbI4eb25saQQGCoe17f3I4j7acCta2tfwtV6gERQP3Re491dp06y+9gA4E+HG7pKAnpTbhwAhBRNGbJlJgoYc/uy1Lx32u3In1nEYoq7DDYPSizeaA1u1Bqdg5orbTG7GRST2YnymxuZiy1xYUv9mmxNHs7yAo5yLIUzJDSmMofC4aGAFak4Yk3s1BSbru0ewH65QVU4+Du3VAviIRq3h1sPOBMl181Pxq77yDQfPgm6m12tHQBLScDaO70oam5HWXyj7D7vwhUC++Gen5kIDc20n/gmbnYMy9a3q1gJDgZt+F4W1pdc/uHhBCShPNPKeKU01Vz5LajbacqzzLfH/VNbf4mxkPQm48enUlI/6cgBWMNR9ufP9J+0s/2AvRJAMc6u/DTiZMNtvr6gQ9b5V6OiRSN6VIlZlE0yLIUkBVy/PearViXW4J9RhuMDm8waDQYL03ozzHaPThkduEz9SEs+mQLyLJUkJVyzJIph11pRpNMZfcCxCSkY3uJIfwIwOqGxmTHvncSoXpoXK/iIE755yBvLoFp21Y0tbSh9TTQfOI0mjq60HIKOHasCQekLyP311dAtfBuppJwfmQgWGIcN+d/CSEE7MqsuiMdd9Ufb6+vb+54JVS5+6OcBQVloqOdXXuYVeF9bBJmU3XHOn2obel4fSA/P9h9+FPrR2wVYKCuqQ11TWfGAI50nMKxE13HHEeb7yZkgKPWRKziT6VoJpK/Qo4/J3+JrN0lKKl0wGD3Bl3FoUgZaVgy0FldMDq82Ge04cOv8zFtdSbIslTMlA5+WexYlUkUDYFEASJRID33AAyXcjVYf0nAXoWD6nyoY2+Det4c1gtglf/JW5H/5GQ483PResqHVh/Q3HkaLSd9aO48jdbTwNFjx1Hw/ELkPToFqoV3BUuJc2Mj/fsWRKNgQVT1D0/9Mop7/+vb2lN+OnEKdU2tiwjpf96eU9C2U77NzBKRdl8f1jlYsHOk/URNXWvr7WcjAbCrvUOVH8D4htYTe5itRB2+hpZ2MAtD28H9vb65PXCk/SR+6uxqqWlqe4QQZh9gvxuTZnKKvywVj3+4GfIfClFYYWcsPhskuhQvSWicQG/zIKdQjz8lfwGyLBVTJApMkyp5ErhIEVE0ZrDP+52v8i/tbsD+EoDFBa3Vhf1rP4LqXgL1onuYtODCu5B3L4Ft53doCwAtXQE0nzyNllN+tJzyo/nkabT5gAavF6qFjyPviRuhWnBnkAAYEojyFS+KQf4z931OCCF1da23NzSfqDrW2XWq5idmRNcACIAZ8dXSSbf6gPrmsxIA6praAs1dAdQ1t38QSh6EBOcD9Pk7La6jv6hvPuE93NoJT+Mxv+dIEzxHjvcQ79GmQH1LB46c6GqrbW5/vK/rzMnJuezsZLA8DbEfbUbKjv0oKLPCYPcGo8PD8XIwv9MJo8OLwgo7Vm/ZzaQdV8oxU6ZERBgo0kiW69lagMRNP4ZHNWBvMTmgddbg4L79yP+fh6F64haon70fuXcRlH+agZZTp9FyOoDmLh9aTvvR0sVI8ykfWv2Ap3g/8n57A/Lmz4EqLqYHAajmRwbUcdHIX3iXbzshi44Bf+8E0Nh6qq2hue2/L4QAqpvaXj/SfvJsmYCQc/ppNLZ2Oqurq2dx3x/6u7Ta+ms15fafaSrtvz9YaftAb3V/V+6ps5TZq07rbR7orO4+RW9zw+DwosJV49dbvcUak/1djcm66ECZ5eadO3cKel8zSwTdZLB2+14UGKwos3m6Xf0weClKzU4YbG6UWlyQ/7AfP1uVCbI8DTfycYGLktlsHUa8Yht0bDVgWIrNg/1rPoD6IYK8R2ahaMn/4mhDA1oDYJTfF+iW0wG0dPnR6ges332B3PsJVAvugiouuicBxEZBFRsT2DPvNux59sGympraA8dO+XGk41Rb/U9Nz4Uqdn8JoKGp9a+HWzq6Grr79fvyAIKueu3xjtWhP6fAZLpV43C/qLO4vtJZ3HVldq/f4KiCqaoBlZ66fmfYSk0OGJ3VMDqrYbBX+fU2d6PW5tqht1WvPFjhfrKwvLznIBXOKzA6GIuvDRPFDxWN2QmdxYUyuwdf7tfhgfc+BVmagtlSPlV4oTKdLQZ65qPNyNWbL+16sP4K2yh0qLAE+f/zCHLvJHDty0cbupW/1RdAq58RhgQYAijfSGP3XCYFeKbyR7GkMDew5zcEFdu34XBHFxrbTvjrfmp57UIIoLqp6Zn65hMd50jT9Vj5fbSjS2Orr5+iKtLeaHC4PzY4PLZydy3MVQ0wOKpYUnYHNFaXT2tx+gdy33RWt1/LTlwyOKpg8tbDUtMIncXTYXBW7deanW8fqqyc2+ODhIvFPzsJMERgtHvxw8FyxH6yBSQ+FTMppheBJ4GByWQpjWtWyjH7zWxs3a+HMdziABwBWFzQ2b3Yl/YJ9v/hVzjWdBytfoYAOMVvDQCtgQBa/X60nA6gDYBW8QlTA/DMfX0TQGwUVIvuRu4DJFC47K+Bmuoaf7MfqDnaNKCGmmAq8KeW+xpaOn462nGq71RgSF3A4dYTqDve3lHprt2utXosJm89Kty10FpcflYCWosrcJHPIxAifq3F6ddZ3TBVNcDorEaZ3d2os7o36W2ux3c6HAIy7A+730TghMHmQYHBilcyvgV5LRlT2ReaJ4H+y0SKZlK9K+RYt7t4+NuCz0UCjmocKiyBc/8etLJnf8bSBxjFD4ARvx8tfqC5sxMl77yOXVEE6mfO4gEESYApJDJ8m+M7CaC+tTP7ggig5shtjW0nGnoN7OhDOuA+fAzlrJte7qrhLPaArPyFkkKpxeXTWd1+g7Mald56GOzek3qbK2/EEEA3Cbixr9wGatOPIPGpmCZRYCqfIRiQ3CBTgryajLVf58PgCMNAYK9nXnWkCa1dzDm/5bQfLUEPgDsC+NEK4Gh9LQrj/4rdD13VXQNwNllwJ/IeuxEFLy7yNTc34cjJ0zs3bNhwNavc502fcf+nqrl54uH2TvdZi4Ga21Hf1A57bSO0FhdKTQ5oWet8qe876+0HtBaXX2d1o9JThxFFANyHKLO5cdDswttf5IIsTYFQosBMPjjYb+GWhL6Q/i0KKxzh0RR0judtcFThp45TaD3tR3OXvzsGwAYBm0/70Qag3lKBvc//FrmP34LeKcCzeQE7f0H89twdONJ20tJrwm+/CKDM4xEdbu/0HD/lP6Ndt765HbVNrTBX1YdFcJ39/X4tSz46q3vkEQD3QfRWhgTey1ExTUXL03CDVImpbICwtwy30oWTTGErAu9891OodabwKwjqJaUmB5z1R1kvgE3/ne7OADR3+dAGoEpTgvynpiNvfvSZKcA+CeAe5N5HAoUrX0Hd4SNobD31LCH9m+TTPbAD19U2dxzo4QE0taOhuR21x1th8tZxVn+4JaCzuv3l7lqUu2thcNf4NGbX4RFJAN0k4ILW6kZ23gE88v6nIEtTQVakYYZUielSJWZImSEkw61w4SaTKRoRFI0JK+T4ar8u/AqC+nzWbhxubkfr6QCaT/mC+f+W00wRUDsA5141cu8iUC285ywpwN4ZgRjkzY/G7vuJv6HCgCPA66HK3R8CcABX1bZ0fH/spA8NLe1+NuWH2uOtqHDXhovy+/U2DwzOahgdVS6t2ZFlMHuf01itt49YAuBeDK3FBYPdg13aSry/TY3/YlOFZEkyyJIUZvaARIHJfJwgKCKKxjQp09795peqYX+O/ZFSkwOVrhocP9GF1i4/mtkKwJZTfjR3nkYHAGveLuwSEaifvf/8yh8bBVVsJFTP3o8fZxB/xaYsHG5pPVDVjImEnL8gKNgPAFxd39y+K3RgR11TG0ze8FF+g7MaGqu7U1vheP+Q0XhLjw8SBhd4cSTAEgFXQpyrrUTOnlKs/a4A732lxibVAby+fjvIkmS+uShEuNkAf07+EqVsvcVAOjuHiwQcNY1oOelj6v9PnkZLpw/NnT60nvKjfOsm7PoZgfqZ/+onAURBtWAudv92lr/wxVjUOR2t9cC9rIL3jwAKCq6ub27feazTh4aWDn/t8TbG7Q8Pjyqgt3mgNbvbNVbHC6HXzlYFjh/xBBAkAjbIYrB5YGRnDuhtbhSZnfhX5rcgS5L5QGGITJEyqcD73szCNyWG8CwIOstzdtUfRXOnDy0n/Wg6cRrNJwM43tQK3Zp3sPteAtWie/pPALFRUC+4O7D7FhJwFe5Dw4muT/pzDAjdEVDT1L7u+Ekfao+3Bio9YWP5obG4fJbqRmgt7v9wpHaGZzPcFzlURKAxO1Fu92BHiQFkVSZEYgUmhYHiXQqZSDFFUuf6PyKKxg1SGiQ+DR9sU6M8zNOBvZ+vo7YRxzu60MISwNGGRhyQvYLchyf26ALslyy6BztjiN+Y/DbqfzpmqgNuOB8JhHxtXH3TiY+Pdfpgqa4PsGm2Yb9PWtb66+yeFk25bT4hZ9kjGAYXOmQvisHuxWbVQZAlyZguHRvZABFFY7xYjgixHFPOUyQ1U8akA3+/divTDzJCvACthTkOmNy1aGxqR9tpoLGqGvv/32PIe/JWqBbMHRgBLLwLuY9MQv4f5/kbGo/i6An/Ss66n8sLIGxTTV1z+wfeI81cjj08CMDs9Ju89ajw1Bm25+m5BqQzCW3YL3SIlF9nceGQ2YX4jT+ALEvBzDGSDbhGrEB00jpcvjoTZKUc06T0WTsog+3BYgW+KNDAOLwLQy+IBPRWN+pbOlHncEH9yEwmsNefFGCvbIBqfiR2P3il33uwCEdP+rXcXP+zxQK4f9cCV5S7qnIq3LUII+uPUovLZ64+DIOr6vvehDUmCEBvdaOk0o7Yjz5jegfGAAFwjT6/S92GbNVBPPDOBuazn6ONeqaMWRj6xue7UGpxjYhgYI8XnSX70vRU5D14HfpVAHSWoqDd0QTa9LWBZj9Q19pOnY0AQv9NY3O+p7d5uesJFwII6KzugN7mQanZfu7UZhhc7KALVy34dbEBcxMzQFbKMfU8Z+LRINPZmX/3vpGNkgo7vijQgCxPwzUSxVlnLE5jg4H3vLUB+Xpz+AwK7Y+YHNA5qnFg507kPXoZ1PN+AVXcAN1/Tp65F7vvJCh6Qxw40tyGhvYTXovr6C96KjzGcccCbX39tTqbM63M7oXe5gkn5ecIAAa792Spw/MIIedIa4bBxQ4JARjsHvz7u70gK+SYQY2N8/9kisZ1EgWuXCnHxnxmAeiHX6lBlqZg1lliIBMpGpMlNMiKNKT9WMgOfA13AnCyg0OqUaotQ8Grf4b6tzOgHmjwr1dvQO5jN6LgL0/BXW70NweAw81tnxMwbnPoVJ1dB8puLrN5vjFVNQQVbvjvSbforG6/wVEFrdlVZTAYZhNyjvLm4b7YQVd+9k+9zQMq+zuQV5NxwxhJ/4koGtyIt8QtuTDYPSiqsOMF5TcgS5JxvazvYihuStBLim3d9zFcjwHsVGmtswalOiP2vrGC2SXAjhG/YAKIi4Yq7i6ofk1g3bcn0HjSj8a2jkBDU8tfeuqL4ymNxVlR6a3nFD+slJ+VQIW7Flqr+0uj0XjdmDoCcNb/h9IK/Pq9T0GWp46pcuDZMkaZX0zLQamZGa22W1OJ+9/eECyT7k0CU6Q0rl4px1WJGfhyvw7l4RYM5JTe4oLW5oXW7sXBvftQsPIFqB7suVr8ggkgNgqqZ/8Lu28gMG7/FodPnPYfO9GFhrYTVY3t7TE7tNopGqvjQ4Otqq3SUweN1e0LR+XXsZ2G1tojKLN64s8Wxxi1BFBqdqLCWYVNqoPMHEFqbLj/nExjl3/8+p0N2HGoAmVWN8rsHqxXHwSRKjFJojgjPSigaFzPjgp7Y/OuS784lFPw3mJysAtEvdDaq6C1eVGqN6JoYzby//xbqH5FoF54T4/dARdFAM/ci91RBNrMNDQ0taK+pSPQ2NYJR90Rnc7qKq1w16HMUYWBTuq5xBLQWd0os7lP6Y2ux1kCOHs6MwwueNBfJp3Ng49yVCD/WovZY8T952QiRWO6RAFC0cjMLQkW+OhsHrzzZV4wHtB7Metk1gu48fUsbC8xwDCUOwN6K7rVDa3NA62VFXsVtI5q6Fy10Nq90JSV41DRARRtzMKef/wPVA8SqB6/4Qzlv2gCWHg3ch8aj+I34+Fwe2GuaYTe6g6YvPUwVTWO9yfgAAAgAElEQVQER24N+zt+7nvrN1U1wGivPrBfq515TvefEEKY2WNh/qH6KVxPwP5yG36X8iVIfCqmjyH3n5MbWGue+nU+yp1VwXz5IbMTSzO/BVmaght73RduPwRZmgLZ5p3M1FnLIHsBnMLbPNDavdA6qqF1VEFjqERpqQ6lGj1KNXocKizGwbxclOz4AUWfbcLehH9BvfCXUD1EoHr4OsblX3Ansy9gMAlgwVzkPX4z8l9YgEMHNdDYvNCYHNCYnf4wt/pBKbW4fNbaIzC4qrPPq/yEEGKuOgyd1Y1Si8s33Bc/GARgsHuQV1qB2xIzQMRjI/3XW2bKlCBLkrEs8zsUm9g8OZsaLaqw4x/sSLXeE5anSGlcLlZAyMYCjPZBjAWYndBa3IxFN5pw4IfvUahYi73vvo0CaikKlv4FBfF/R8Gyv2HP3xci/9loqH5NkPdLAtVvIqB++g5maeh5NgdfFAHExUD19B1QPzcXB4sOMOTEEEBwNmUPGWyCvHgJaC0u6KxuHLI4l5z3/E8IIRqLa1uZ3QuTtwHa7sGEw/1BBq78Fhe0Fif0NjfW7tgfXD/e29UdCzKVrQd44MPNyNOZYQgZ915m8yBPZ8bvPv4c5LVk3NSLBGaxQcQX5NtQYhqkDcKc1be4UPLt1yhY8QLUsaxFv5NA9SsC1cMTofpNBFS/EUD12A1QPT2HsfSL7mWsfVwMsyDkHMo/KAQwfw7yHrsVmqIDMLprUWZ1wWDzMJOzLUyhVF+fry9yGAY98Je7aqC1ehr26ey/JKQf041ycnIu01kdz+ttbkOlpx56m4f1BsIoCtwfAmArwnRWNzM0NJ5ZKzbcyjgcMpmiMUGiABEr8GOJoUeJL0cCuzWV+O81W0BeY9KkXKA0mEqMT8N729TQ2dzBnZAXrPwWZux8YWYao/SPTmPWfi24m1HwhXczlp2TASj8oBNAbCRUv7kJP+7Mxw6DDT8cKsePmkoUV9q7ScDq6rGYQ2/zoMzuYRfnulFm7b5npUFSuDQEUOGug9bs2pHD7j7s96LQUpNpht7iTtFb3afYHOeI8ga48t/8Mgti3/t0zJ7/hRTbDUgxS14zdhYxlWohChwkAa0Jf0nNYXct0MFdC5OkNEQSBYhUiQ2qg8GJQRds1WxeFH+2gQnezYtkFP6Mzb+DIxdFALHMFKH8p25CfOIa3PH+Z/jtO+vx8Lsb8cf/bMYLa7bg72u24B/Kr/Fq9nZIPtuJd77KB727GN8U6bFXZ0JBmQWF5TYcsriYjT3OKhhZYuDufagMog4EtBYXyl210FmcL/db+QGM49iCEEIOlluf0Vm9GpO3gVlSYB4ZwQ+N2YlyhxcZuSUgUiWmSBRj0v0Xsso/kSWAdbuLoe9j5BdHAoXldizN+g5kWSpms9kBAcWmE1ek4bbVmfhinxZGdo3YgF5aNoVXelCDPS89B9UjE9ldf2cG78KDABjZM+9mvPxSAsgKBcjyVHZ3ZgozYWppCjN6blkqSDz7tZVy3CJR4E6ZEg+8vwnPpuZg1eZdyPx+L7aoD+LrEgPUZVaU2T0wsst2uSBrD1K4GB0wOwMGRzXK7J7DhYcsvUqY+wHmPzOMcbCiYnqp2SXXWVynmKKH8M8UaMxOlNm9eHvzzqBbO9yKOFwyRUrjGokC42Xp2HnQeNbCHq5norjSwZDAkhRcz5JABMXGA5an4sF3NuLzvVqU2T0DOg5oTA7oXLUo+WobVA8RxuUfIsUfPAKIxp55t2DJyyv8REb7b5QoA9OlzL2YzYlUidlSGjOlNKZRNIQSBcaJFcwOS3bRLlmSDPKPNSDL0zD9/9bh959sReL67/H+V/nYvE+L4nIbDrEpUKODIQWt2RU8Mgz0/S+1uHy2uqPQO7xZoca93wTAYlzoRFRNpf33ZVa3NaTsMSy9AS79p9JbsOBjZnPQWKr+6y3T2HFfz6z9Avnn6fMvDSGBZdnbQZYyJDCJojGBYguE4lNx26oMrMstgYYpMunXi6oxOaBz16Hw003Iu5lA/ex9YU4A7BHg6Zvwz/j3QV7fiNmydAgoBQSUwh9Byf0RYjrA3Wdu4vQklnSnSengMNqZMiVmyZSYSdG4aqWc8SBeXQsSn4afJaRj4Tsb8Lf0b7Fm+178UFIGdZkFGqsb5Q4vykJW9fXT6wqw3nqX1uxkJhuHePUDRqjrUHCg7Gad2bnV6KwGO1ww7NKFpaz7/12RHoSiMVkydqb/9CXXs4s/Pti6G2X92ADMHQeKKx1Yuu575jhAMZ7ABIqxflesSAMRK7D6893Ya+y5RbrPVGHw3xzYn/wfqOZyq77DmADmRwZUsVHYO+/WwKpXk74hsuyvZosV1cKETAhXZUOUmAWhLB0CCe0TUHJ/f56FiGKKrGbKlLhRpsT1FI0rVsqZ40N8KnOkoGg89ckWvLVlNzJ3FWOX1sQu62Uk9D73dUwotbh85qrD0Fpdhdut1ogLtf69SSAYG9BqtVdoLY6VervnSFgGCM1OaKxurPm2AGRJMqZJlWOq/DdUJklpiMQKkIR0fLantN91/cFdCyYnVn2+q4cnEEExqcXJEmaS8HOfbEFW7gEUVdhhsHthtDNBxlJzH3lykx37P/kAqrtGDgEULogO7Hvq8ocJMP7u+P/ECGSZ8yMo5RqRLLNSlJDRIVq9HsLELJYIFH5BiFfQX0KYIVViJju2fiLFNHCR15JBVioQ+/6nWJ7xDT75fj/ydSYcqHT0uM/d95ix/lqLC1qb11daYf3DoCh/LyLo3mFutN+vs3hK2LJIjgiGVfm5F7e4wo5nkr8EWZ6GaWM0/Seg2GagZSn449qtKK50QDeArT9cTOCQ2YnXMr8DeXUtrg/ZxDyRojFbSjOBsRVy/H7tF8jaVYxdmkrobB5UOKuCOXOd1Q2d2YkyTx2KNn3GHAGeCfMjwPzIgDouCurY6Hb1vOgHe+uC8F8fTIyIT1sklCqyhRKFV7R6PeMVUEoMlAhCCWEiS7CzZUpmbyPnGayU4+43svAX5TfI3lmEHw6VQ2tjjgl6Noh4yOz02et+gt7u+ZywE38GlQC4H8j9UK1WO1NrdmQaHFUBo7N62CsIuZd2xwEjfrGKqf6bMkYJYCLFBKXI8jR88PUeprd/gPezlCXUwnI7Xs34Ntg7EPp7ZgStFhMN/9X7m/B/G3cgeftebN2vQ2G5jXFZKx3QuusCJV9s8avvJoG+avfDigDmRQYKFkRDPT/ao4qLYgppHk26nPRRTHPlkjXRAnF6vFCWoRVIMwPCVdkQSpUQiGmfQDJwIujxDFkymErRIGJ5kBBi3tqA1Rt2IGN3CfYabdBb3f5KTx3KHF6vweSI7G2wBx2hxQWlFufLOqtz2I8E3Cjwt3LUIMvTxlz3X6hwqbs7k9ZBrWPOkRcSUeZItajCgZfk21gS6BlUnURxQS/Wff3nGpD4VNy0OhML39mAP/xnc+B5+bbAH5TfYdVHSuz+3f3Y89RtAdUQ5f8HgwByYyP9+xfGQB0Xvf+bp34+jRBC0D1HbxwBxpGkpPEk1MImyidftyLtz0JKqRZQCr9o1ToIKRoCMR24EI+gNxlMZYOLM6Q0xq9IYwKJKxWY995Gf8LWPHxXZDyp63b9h075OfQ4Ethcv9bZvdpKTx13FrmkRwIuIFJqceEf7It6/RhO/81mm4De3LIbmots5illiXWXthKPvr+JSWf1MUuAc1+vlzGpMbIyzU/iU/xkuRxEnM5IfHLFN889Wb9v/s+hnh8dCF8CiPKVLJqLvXFR60Je+b7d6aSk8T08g8VrrrlOqnheIFXmCaQZPmFiFuMRSGjfxRIBd5+nsPd5spgOkGVpASJOB6Hkb53zOocC3GYRQpgKQp3V9Vm5q4abjXbJSICz/t8eMCLm/9aBrEjDlDGa/psspTFBLIdodSa+KzYMylAPDTtQ5NP8QxgvS4dghTwwScJYNoEkRMR0QCCR+wWUwj91VTZmrc7G9dKMppuk9M5IKv1PJGnrLFXs3A/2L4hG/vzIgHp+5JCRwMUQQF5slK94UQzy5835P0IIyVlMzp9K600EEsl1ohXy5wSUPE8gpU+LuKOBRO6/mKMBJxFiOiCSKH2zXt+A2RJ6EyGLB1byO5gIzRLo7d63dVZ316WMC3AvKL1jH8hrKWO2+EdAsS3AS1KwfN12HOrlIV2sh6W3ewKvb/7RR8QZmLUqG6JQkpUqIZSlQ/T6BghXZUMgzTALqHSlQJJ8P/lb0tXcu7Lr8dtj9sZFHy+Ii4Z6XhgSwPzIQH5cFPJiozp2Pz2HcamTyEBc6nFkcXfu/eakpKsnSOR/FEiVeYKEjFOi1zcwREAp/ALqwshAIKF9AokyIHrzUwhlym8EL66ZREj/1pkPGXqMSK60/VFr8TSYqho4EhiyuIDG7ILe6kJRpQP/yvwO5LVkzBijBDBFSuMysRw3JqQjp1A/qMM8NGZXwOiowv4KZ1PcR59nkOXysukJGRDK0iGUZUAoTYdIqqwXytJzhNKMV65+Ka3H8snFi3MuY8/M4wpiozccWDQXebFRvnAjgNzYOf7ChTHIj4uy5Cz4xWxCepz/B6IQPYiAvJJxbYQ0bZGAUm4RUOldolXZECZmMQotVjBpxHOQgUBCBwSU3C8Q0z5hQib7vekZouVJIkII6StAeckRmiU4UGZ5VGfzmM3Vh7njwJCQgMbshNHuwc6D5bhmdSauEMsxeYxG/2ex/f9LsrajdPDvdUBrdaPCVePX2dyvERI98dZE+YMCifKP14kVf4pYkfqbq16T/2LWKxnXhrwQ4zilJ6TbkuY+9cuH82MjW/bGRSMvNtIfTgSQFxvpL1wYA3Vs1GeDpBbjeihnUtKVE+KTH46QyNMF0gyngMpgLDlHBpTC35cIKRqiVdkQvb4RQll61QSJYgl3X8NC+TmExgUOGa23aG2eb5jFhK4h25iit3mQ9kMhyLIUTKXGZvR/EkVDIFZgxupMfFtcFuzcG8z7XGpx+Sw1jdDZ3PvO+RJ0W/sz3w/2pc2bN+eTwoUx4UUArPufHxt1Mjf2l08SMmD3/1zo6REQQibKUm6MWKl4WZCQuUUgS7cIpekQrV4P4arsHiJavY7xtKQZuokU/Z+rpMm3B39IOCl/KDgSyMmpuUZjcqTpbB6U2b2cNzBo1l9nZerR/5ySAxKfekaaaqzI9VLm7P/mlt1DOczTX+6uhc7i9moMjruY58sqe3cQ7NzbdNmvfz0/5vqCuOiKooUxyIudM+gkcDHuvzou8tC3z94pIoSQJDJoBMBhXF9KGyFOu+PalfIFEyh6aQSlXBMhpTeKxPQmIUVnRFBp0ghp2qKJspQbu29kt2cVtghtRNBY3Ev0Vm9buatm0IKDXO36NyUGRK0aO5t/egs3+ee+tzdgx8HyoRzkGSize6GzuI+XmpzzCLmwnHPwKLAw+tk9sZGd+bFRGOyMwIUWAO1dEI38hTEvhV7nEIE5Hi1e3HeGISnpSpKUcyVJSrq817+PD1ur3xdCX5DSCtMf9DZvnbnq8KCQANP7X4WPvlKDLE3F9WNQ+ZkhnjTIslS8v03dZ8//YBKA3uaBzuxuL6mwPdf7+Q4A4zhPIHde1Pv7F8RAFRfpH0wSuJDcf/GiuVDPj87fPH+SgJALDP5dGBivYHHOZX0qNxdQHEmKHwoA47gUhd7qutfgrC43eRuguYgMARf931dux9/TckCWpGDmGIz+Mzv90vDkB5+hoMwypDv9NGZnwOisht7mqtFbLA8SQkhS0oVVnXHKlTqfXJUXG72leFEMkxUYJBIYmPJH+vPjorBnQVRb3vyopwgZcut/PoxjZnKMADd/AAgGB/dorLeXWp1qS00jdFb3BVUOcq2/X+7TgsjSx2Trb+jcPvkPhTD0o+X3YqTU4vKZqhpQZvdW5hqNTInsRRSecAU2Pz4aOSM/NrK4ZFEMVLFRPtWlJID5kYG82Eh/8aIYqOPmvEfIJbX8Yw9cXCDXaJxWFlo5OICRYxoLu77a4sabW3NBlqZgxhhr/Q12/C1Jxl/TclBYYR+cyb3nuOdai8tvrmqAxuySEzI4VWecpVU9EXljfmz0vmB9wEV6Av1V/tzYSH/Rohio50Vvy3k0cgIhPAEMOThPICkp6XKt2flGmb36tNFZzY0c69fZv8zmwV6DBQ+8u3FMBv+mSGlcJ1aAUEp8XqDpMfF3iMRvdFbD6Kxt3G9yzAl9jhcLzhPIX/CL2fmxkcUHFs1lYgIXUSnYH7dfFRuFkkUxyIuN/Hr7Q1MiCBmSqD+PvtCjmcji+ave5j5S0c+5g8zcPw+y8w5g3Eo5hBQ95tz/Wez2HsnGH1FqGYLtPb3ut9bi8puqGqC3uN4KeYyDZik5T2D7k7fPUsdFbytcGIM9cVFQXaA3cG6rH+UriIvGnrhoqOKiNnMpv2E+9489hFYOFlssD2pNThO7a/3865atLrya/g3IsrGX+5/KLv6856312KWpHNr9fezZ31Z3FDqrZ5d1sEZN9fU+sAqYOp9clTd/zlv5cZFtRQtjoGbP6QMhglClV8+PDHDuvjouCsWLYqCKi/wpd17kihw2Dccr/zAhtHKwWOv4udbm2lHhqeNGJZ8RF+BKf7cVlSHqzWxcsXLsDf6YzVr/tO/3DUnFXw8xO/2V3noYHFXaEpPzVvaZDZmyhCqiOi76iT0LovYWLIjGvgXRrGJH+/Ji5/hV89huwr5kHqPsebFRvjzW1c+Pi0bRohjkx0Z2FsRFb9sx79bgpJ8kXvmHH9zqYm19/bVai/s/epsH3R2FPZdZ6G0evMtuuL1hjFn/2VJm1Ndf0rahpNIB/QBGfV3ouV9jdVXvrbTdxz6nIVcWhNQJqJ64R5gfO+ef+bGRhoK4aBQujEHRwhgUxEUjPzYK+fMjA6GyJy4KBXEMYRQtjMG+BdEoWBANdVzU4by46I0/Ph05j3C9CSG/h0cYIPTlOmR2vKi3VdVZa45wnoCf6/vPL7PgsY82gyxPw9QxRACTpTQixAoQmRJf7tPCOITWv9Ti8pW7aqG3u49ozPYnej+fS/I+hFjm7U/ePiv3qV/+cU9s1A9Fi6KtexfEHN0TG316b1w0QqUgNrKzYEHUT4ULol17F0SV5sZGfZY/L/JvuQuj70idT67ifh5v9cMUoT3NRRWue/U2r6rcVQuDg/EGyuyewMa8A+zKr7HV+MMF/t7emhus9x8qy8/0bThPlprc/0sIIUmXWPk5gJBxoUSAJDL+x0cjZ+x8KvKx3U//8i+qp6OX5D0Z+VpeLCOqp+f8IfepyMd2PPmz25IevfnqpF5RfTbjwFv9cEZoXGDnToeg1Ox+x+iqPm6taYTG4sb/k28DWZaKGWPE+gsoZgAnWZ6Gh9/fhHz9kFb8+cvsXpTZvf4DlbaXQp7HsCrNxbjrIGQca/F5xR9JCHU5C432+8ud3h+37S87+fOEdJAxFPybzM34p2hsVB+E0TFkFX9+rcUFo7MaWpsrIeQ5hI3icESQlETG4yySlETGhxBG2Fw7jwsAuodKEABXPvnvz/NJYjYmSmjfcCvmpZJZbKvvivU7uod8DvKaaY3ZGdDZPIFKbz1KK21v9bj/PHgMJ4INJ8+viSbLUt2TEjPBTUkZzSKgmB1zZHkaHnl3I1Q681C5/gGtxRUwVx2GzuJK777vI7T7jMcoA/sizpSlvz7tjY3o7z62kS4iisZ0igZZIYdyZ1FwNfdgK7+OXSyhs3jW79BqryVk+IJ+PHj0BKv811GKGSJpeqUwMWvMWP/r2Waff2Z+hxKTc0AruQdg+f3MQkn3l1pW+S91uo8Hj7ODJQDRytQXhLIMRjkGYa56uAu33ef2N7Kx/YBxKMp9Axqb22euaoDW4txTajLNIIRXfh7hBC4A9eKaSQJKqRkr1l9I0ZglZfr8k4em3Degtbj8Jm89NGZXXr7BwIy/5pWfR1iBnaIqpJT/EsrSGes/COuVwlmCff5LU/By+jcoHuRyX43ZCZ3F5Td5Gcuv1WpnEkJITg7Ov/2GB49LBi4C/eKaSYKE9BLRqnUQSEZ/8I9x/eW4+631QzLgU2Nx+ZhFrs49edbqWYTwys8jHMESQIRY/pIwIRMCiTIgkChHtfWfSNGYQTEz/tJ27IdhkF1/bqSX1uq2aLWOnxPS3YTFg0f4gDv7/z116lg5+wsodrPPshT8g4v6D5rr70SpxeWr9NRBZ/OYNQZv90x/HjzCDqz1F0rpf3ZH/kev9e+u9U/Fr9/egJ2lFYPp+gc0VrfPVNUAndlp1xjNUYTwys8jXMFaf2ECPVEgVR4SJWaN+sKfSRSNyRRT8JOxqxiGwav1D2gtLj8zYMWjLTXZ7ySEV34e4Qwu7y+jVwoTsyCQjm7XX0ixQz6WpGDF+u+hHbxa/2CRj87sUumDAT9e+XmEK4KBv4w7hFLaJUwY3TX/AorGDJkSJD4V97+zcTDn+zF5fmaEd56ej/bzGAEIdp2JxPJU0ep1EIzyjr9JUhqTJQqQlXJsyDswWG2+Aa3FFbDUNEJrce8+WOGeTggzgn34Hi0PHucDa/0FkuT7BVTGMaEsY1RbfyHFbvRdmgJq04/QWF2D4fr79TZPoMJTB63du7mCVX7e8vMIc7Bpv6ScK4Uy5Tei1etHdeBPQNGYKVOCLEvFEx9+BrV+UNp8/VqLC5XeeuitHlqr1V5BCH/m5zESwHX8rUj7s0CiDAilylFd8juZoiGSKDCOUmJT/qGLLvgptbh8Bkc1DI5qaCttbyUBlxPC1/bzGAkIafcVypQG4RhI+10vZaz/myHDPS9sq4+Tnd5bA43VfUJvdf2z+7bywzx4jARwkX+p/H3hquxRbfmFFOv6L03BHz7Zgn1GG8oufKFnQGtx+S3VjdDbXDUHDK7fcbeUH+PFY2Qg2O2X+pRIqmgTytJHNQFMkdK4UqzA9NWZ+HK/7mJc/5DzvrtUZ3Q8QAgzOo1Xfh4jA905/ykCCV0qWpU9qtN+IorGbIoGWZaKD7/ZgzK7B1qLc8CuP7MbwYsKTx20FtfnvXr5eeXnMULAlfxK6A9Fq9aN6nN/aI//3xRfobDCDv2AXX9ncIiH3u49rjd7ZDkm05WE8JF+HiMNXM5/edp8IUWfEEpHt+vPjfeKeSMbOw6WD3SlV0Bjcfn0di/j8tvcZQcr3E9yt5KP9PMYWWCVf4r0o1lCKr1stEf9RRQ73mtZKpK37w1W+/XD9Q+wZ/0As53X26mxuD45WFExnRBG8fnzPo8Rhu4XVkjRWYzrP3qr/UIn+76Y/g2KKuzn7fHXmJ2c4qPCU4cKdx20Vtf+g2ZXLHfveJefx8hEyJQfAZU+6gt+prOu/31vrccuTWXAaPcENGytflBYhdeYnf5Si8tX5qhCpbce5a4a6Gwec6nVE28ymSYxNxDj+Pw+j5EJNuU3Uab4tYBKPypMGN21/hMpGtMkCpBlaYGUHYV+g90TYK17iLhhcFShwl0La+0RWGuOQGNxtuos7hJNpe01bmAnIfxZn8dIBmu1rl2eMVMgSz8oWrUOAvHoTvlNkygDZElqQPrZLpS5amDy1KPSUw+TtwGmqgaYqxthcFT5dVbnEb3NYyx3Vn+rt1d9qLU4ntpQUHB1961L4s/6PEYwuJd38ZprBJTiC1Fi9qi2/EKKxhSpwk/ENB76YDN2aUzHjPaqfRqz48NDZrtUW2l7SVfpeVprd8WVGl2PH9Ca7z5kNN6Sk5NzZc/bhnHBnYg8eIxQjAvN9wsTsyAQ04HRutlHIKEDAjHtm5yYCSKhO19I3vahu6npps07HYL+3Cw2ss9bfB6jBFzQT5L2okCa4RvN+X4BpfALxHRAtHoDhDKlZ/IK+XO9bweAcQDGJyUlXZ6UlHQ5gMtycnIu45Wex+gDG/SLkKYtEkrTm4Wy9FGb7xeIaZ9Qlg7RqmwIZbRqYjwdQwghBBiX1K3cvILzGCNgLf81y+UPCii6gXX9R13QT0Ap/AJK4RetyoZQojghouRvEYnkupB7wCs9jzEG1vJfs1z+oFCi8DJbfUaX8jNbimifUJYB0er1EEpprZBKfSp4D/hcPY8xCVb5r3pVcWuEVFEpWr1+1Fl+gZgOCMR0QMi4+y1CSvHxBIlyGnMDMI5Xfh5jEyHKL5IqioWjrL2XzV4wVj8xCyJKWTRBkvZY8PPzis9jzIJV/qslabcIpPQBZqS3cvQoPyX3CyklmN4FujGCUkgnyj4UEkKYOgde+XmMWYQov0iqKBStGh2FPgKJMiCg5H6BRBlgFD+9S0ApvhAtTb2r92fnwWMsYlzISK+fi6Q06/aPfMsvoOR+gZgOCBOzIErMgpCi906UpC4MKnxS0nje6vMYuwhxe69ZlvIrAUVbhQkje4W3QEIHutN66yBKzIZAprRMWJH26hSpNCL42XnF5zEq0d9KtMWLg27vxJXKBSKKrhvJlp9z9YUUDWFCJoSJWRBJlYYIWbrk2iUfzer+3DmXET6vz2NU41zWDQi6/GRx0pVCKS0TUfQJ0Qgt8mEUX+EXSNlCHlk6hLL0qgiKfq+H4gPj+k2OPHiMXPxNRAjpdu+5P7m/s7gyno4RUuk5wsQsCGUZI075BWLG1RdKFBAmZjFWX5ahFUnkyyf/c83s4O1YvPgyXvF5jB0sSVHdTqU9dNav/z11qkisWBFBKepEq9cHLehwK3S/FZ9S+IN5/FXZEMoyIBIriidQ8tcmSJKm9fis/Dmfx5hDQhamJWQcEUiV74ooxZ1Xv/bxTUIq4+fXyDJ/JaLkb4mkmZVCWQaECZkQiGnfSGjpZYJ6cr9AIvcLEzKZPL4081gEpfxRSNGLI8QZU4Kfvzuyz1t9HmMP0yllsMpNKElvj6AUZqGU9ghkGaeECZkQJWaNCKvPlur6BGI6wHTorYPo9Q0QSJROgYSmJ8QnP9wjf784h3f1efAIWkwxHRBKlRDKMn6sHngAAANdSURBVBihlOy5We4PV6sfTOGJaZ9QylTsCROzIJJltguk9O4ImfLlCUtT5/T4wL1iGzx4jGmcoVBs00vYKj0bzAteX2IWY+kpZadQQhsjqLQ1gpWpD5BXMq4Nfsju+gVe8XnwCMVwK/T5rbwyEHTvKYVfKEtnovhsJD9CSpsFsow1Iin9LPl76tQeH46v3OPB49wYbgU/q2sv7q7QC/bfJ2RCKKXbhBK6YsJKRUrEivRnJr36yQ09P1FIOpMHDx7nRtgoO5Nh8AkkSiYWkZAJ0ep1jOLLlLUCWfZO4Ur6w+soxTxRUpKox4fgipV4pefBY2AYNpeekvsFYgWTVpRlMFF7Nk8vkCi7hBK6QihVZkYkyF8Siul7yPz5V51x8byLz4PHxWFolZxx4UNFSNGsdV8P0ZubmPoCaXqjcFVWmYiitwsldOIEsfzhKdKPZpHFST1m5veqUOStPQ8eF4vBU3S5n83DM1adSykmZkGUmA1hYjZEq9dBmJABIUV7BFJlnkiWLhdSyn9NiE9+eOIrHwpDl4gGsTjnMj6Cz4PHEKHfyh604nI/c1ZnFV2WDq7aTvTGRoje2MhY9YTMTkFi9k+ihMwqoZguEEqVmROkaa8KxfInJ0iUURHipClnuaRxvJXnweMS4byKzw3D5GbirWICc6I3PmWi8pSyVUiluwWyjFKBLGNnRAK9USBNS7pOnP4noXTNvWR5xkySpJhAkpIuP+OXJyWND1p4XuF58Lj0OLfVl/uFUiXjwkuVHoGU3hkhpjcJKcXHEVSa5Dqx/K+ClemxQnHKPYTpqDu3AnPRej5Nx4NHeOAclt8nTMiEgFJ2RUiU6VetUNxKkjYLSFKvwFxvcIG6UOGtOw8e4Yk+XX5K4ReuyoaQor2ClfT/nvldrCXnrDmv5Dx4jEz05fILV62DQErvnCBbE8n8L/CBOR48RiNCKvJ8woRMJqIvS0+dIl3HDMTkR2Dz4DF6wVXlCROzIZSlNwtXKv4R/CJfZceDx+iGQKIMiFavg5CSl4skax8NfoGP0vPgMfohlKZDJEv/7OrXPr6JEMJafV75efAYExBK6MSgq8+f93nwGKPgz/s8eIxB8AswePDgwYMHDx48ePDgwYMHDx48ePDgwYMHDx48ePAYZfj/WTCY21F/aRcAAAAASUVORK5CYII=",
+}
+
+// defaultService is the single service offered when the configuration does
+// not list any services.  It uses the broker ID as its ID and offers every
+// plan.
+func defaultService(brokerID string, databasePlanMap map[string]PlanFactory) ServiceConfig {
+	planIDs := make([]string, 0, len(databasePlanMap))
+	for planID := range databasePlanMap {
+		planIDs = append(planIDs, planID)
+	}
+	sort.Strings(planIDs)
+	return ServiceConfig{
+		Name:        defaultServiceName,
+		ID:          brokerID,
+		Description: defaultServiceDescription,
+		Metadata:    defaultServiceMetadata,
+		PlanIDs:     planIDs,
+	}
+}
+
+// loadServices checks the services listed in the configuration and returns
+// them along with a map from each plan ID to the ID of the service that
+// offers it.  Every plan may only be offered by one service.
+func loadServices(conf *ServerConfig, databasePlanMap map[string]PlanFactory, logger SdLogger) ([]ServiceConfig, map[string]string, error) {
+	services := conf.Services
+	if len(services) == 0 {
+		services = []ServiceConfig{defaultService(conf.BrokerID, databasePlanMap)}
+	}
+
+	serviceIDs := make(map[string]bool)
+	planServices := make(map[string]string)
+	for _, service := range services {
+		if service.ID == "" || service.Name == "" {
+			return nil, nil, fmt.Errorf("Every service must have an id and a name")
+		}
+		if serviceIDs[service.ID] {
+			return nil, nil, fmt.Errorf("The service %s is listed more than once", service.ID)
+		}
+		serviceIDs[service.ID] = true
+		if len(service.PlanIDs) == 0 && len(conf.Services) > 0 {
+			return nil, nil, fmt.Errorf("The service %s does not offer any plans", service.ID)
+		}
+		for _, planID := range service.PlanIDs {
+			if _, ok := databasePlanMap[planID]; !ok {
+				return nil, nil, fmt.Errorf("The service %s offers the unknown plan %s", service.ID, planID)
+			}
+			if other, ok := planServices[planID]; ok {
+				return nil, nil, fmt.Errorf("The plan %s is offered by both %s and %s", planID, other, service.ID)
+			}
+			planServices[planID] = service.ID
+		}
+	}
+	for planID := range databasePlanMap {
+		if _, ok := planServices[planID]; !ok {
+			logger.Logf(WARN, "The plan %s is not offered by any service", planID)
+		}
+	}
+	return services, planServices, nil
+}
+
+// catalogService builds the catalog entry for a service.
+func (c *ControllerImpl) catalogService(r *http.Request, service ServiceConfig) CatalogService {
+	catalogService := CatalogService{
+		Name:                service.Name,
+		ID:                  service.ID,
+		Description:         service.Description,
+		Tags:                service.Tags,
+		Requires:            service.Requires,
+		BindingsRetrievable: GetAPIVersion(r).AtLeast(fetchBindingVersion),
+		Metadata:            service.Metadata,
+	}
+
+	catalogService.Plans = make([]ServicePlan, len(service.PlanIDs))
+	for i, planID := range service.PlanIDs {
+		v := c.databasePlanMap[planID]
+		catalogService.Plans[i] = ServicePlan{
+			Name:        v.PlanName(),
+			ID:          v.PlanID(),
+			Description: v.PlanDescription(),
+			Metadata:    v.Metadata(),
+			Free:        v.Free(),
+			Bindable:    v.Bindable(),
+		}
+		if GetAPIVersion(r).AtLeast(schemasVersion) {
+			catalogService.Plans[i].Schemas = v.Schemas()
+		}
+		if u, ok := v.(UpdatablePlanFactory); ok && u.PlanUpdateable() {
+			catalogService.Plans[i].PlanUpdateable = true
+			catalogService.PlanUpdateable = true
+		}
+		if v.Bindable() {
+			catalogService.Bindable = true
+		}
+	}
+	return catalogService
+}
+
+// checkServicePlan makes sure that the service is in the catalog and that
+// it offers the plan.
+func (c *ControllerImpl) checkServicePlan(serviceID string, planID string) error {
+	serviceFound := false
+	for _, service := range c.services {
+		if service.ID == serviceID {
+			serviceFound = true
+			break
+		}
+	}
+	if !serviceFound {
+		return NewBadRequestError("%s is not a known service", serviceID)
+	}
+	planServiceID, ok := c.planServices[planID]
+	if !ok {
+		return NewBadRequestError("%s is not a known plan", planID)
+	}
+	if planServiceID != serviceID {
+		return NewBadRequestError("The plan %s is not offered by the service %s", planID, serviceID)
+	}
+	return nil
+}
+
+// checkMaintenanceInfo rejects a request that names a maintenance_info.
+// The catalog does not publish one for any plan so a platform that sends
+// one expects a different version of the plan than the broker offers.
+func checkMaintenanceInfo(mi *MaintenanceInfo) error {
+	if mi == nil {
+		return nil
+	}
+	return NewMaintenanceInfoConflictError("The maintenance_info version %s is not offered by this broker", mi.Version)
+}
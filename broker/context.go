@@ -0,0 +1,174 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OriginatingIdentityHeader is the HTTP header in which platforms identify
+// the end user that triggered a request.
+const OriginatingIdentityHeader = "X-Broker-API-Originating-Identity"
+
+// The platforms that are known to send a context object.
+const (
+	PlatformCloudFoundry = "cloudfoundry"
+	PlatformKubernetes   = "kubernetes"
+)
+
+// PlatformContext is the context object sent by the platform.  Its contents
+// are defined by each platform so it is kept as a generic JSON object.
+type PlatformContext map[string]interface{}
+
+// OriginatingIdentity is the end user on whose behalf the platform made a
+// request.  The contents of Value are defined by the platform.
+type OriginatingIdentity struct {
+	Platform string                 `json:"platform"`
+	Value    map[string]interface{} `json:"value"`
+}
+
+// RequestContext holds the information that the platform sent about where
+// a request came from.  It is handed to the plans so that they can name
+// resources after the tenant.  BrokerPlatform is the label of the broker
+// credential that the request was authenticated with.
+type RequestContext struct {
+	Context             PlatformContext
+	OriginatingIdentity *OriginatingIdentity
+	BrokerPlatform      string
+}
+
+// Platform returns the name of the platform that sent the context.
+func (pc PlatformContext) Platform() string {
+	return pc.getString("platform")
+}
+
+// Tenant returns the name of the tenant that owns the resource, which is
+// the namespace on Kubernetes and the space on Cloud Foundry.  An empty
+// string is returned when it is not known.
+func (pc PlatformContext) Tenant() string {
+	switch pc.Platform() {
+	case PlatformKubernetes:
+		return pc.getString("namespace")
+	case PlatformCloudFoundry:
+		if name := pc.getString("space_name"); name != "" {
+			return name
+		}
+		return pc.getString("space_guid")
+	}
+	return ""
+}
+
+func (pc PlatformContext) getString(key string) string {
+	s, _ := pc[key].(string)
+	return s
+}
+
+func (oi *OriginatingIdentity) String() string {
+	if oi == nil {
+		return "an unknown user"
+	}
+	for _, key := range []string{"user_id", "username", "uid"} {
+		if s, ok := oi.Value[key].(string); ok && s != "" {
+			return fmt.Sprintf("%s user %s", oi.Platform, s)
+		}
+	}
+	return fmt.Sprintf("an unidentified %s user", oi.Platform)
+}
+
+// String describes who made the request for the audit log.
+func (rc *RequestContext) String() string {
+	if rc.BrokerPlatform == "" {
+		return rc.OriginatingIdentity.String()
+	}
+	return fmt.Sprintf("%s through the platform %s", rc.OriginatingIdentity, rc.BrokerPlatform)
+}
+
+// NamePrefix returns base followed by the tenant name, reduced to the
+// characters that are allowed in Stardog database and user names.  base is
+// returned unchanged when the tenant is not known.
+func (rc *RequestContext) NamePrefix(base string) string {
+	if rc == nil {
+		return base
+	}
+	tenant := rc.Context.Tenant()
+	if tenant == "" {
+		return base
+	}
+	const maxTenantLen = 24
+	b := make([]byte, 0, maxTenantLen)
+	for i := 0; i < len(tenant) && len(b) < maxTenantLen; i++ {
+		c := tenant[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return fmt.Sprintf("%s%s_", base, string(b))
+}
+
+// ParseOriginatingIdentity decodes the X-Broker-API-Originating-Identity
+// header.  Nil is returned when the header was not sent.
+func ParseOriginatingIdentity(r *http.Request) (*OriginatingIdentity, error) {
+	header := r.Header.Get(OriginatingIdentityHeader)
+	if header == "" {
+		return nil, nil
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return nil, NewBadRequestError("The %s header must be a platform followed by a value", OriginatingIdentityHeader)
+	}
+	data, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, NewBadRequestError("The %s value is not base64 encoded: %s", OriginatingIdentityHeader, err)
+	}
+	oi := OriginatingIdentity{Platform: fields[0]}
+	err = json.Unmarshal(data, &oi.Value)
+	if err != nil {
+		return nil, NewBadRequestError("The %s value is not a JSON object: %s", OriginatingIdentityHeader, err)
+	}
+	return &oi, nil
+}
+
+// newRequestContext builds the RequestContext for a request.  The context
+// object is only honored for API versions that define it, otherwise one is
+// made from the Cloud Foundry organization and space GUIDs if they are
+// known.
+func newRequestContext(r *http.Request, context PlatformContext, organizationGUID string, spaceGUID string) (*RequestContext, error) {
+	rc := &RequestContext{BrokerPlatform: GetBrokerPlatform(r)}
+	version := GetAPIVersion(r)
+	if version.AtLeast(contextObjectVersion) && len(context) > 0 {
+		rc.Context = context
+	} else if organizationGUID != "" || spaceGUID != "" {
+		rc.Context = PlatformContext{
+			"platform":          PlatformCloudFoundry,
+			"organization_guid": organizationGUID,
+			"space_guid":        spaceGUID,
+		}
+	}
+	if version.AtLeast(originatingIdentityVersion) {
+		oi, err := ParseOriginatingIdentity(r)
+		if err != nil {
+			return nil, err
+		}
+		rc.OriginatingIdentity = oi
+	}
+	return rc, nil
+}
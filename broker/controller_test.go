@@ -0,0 +1,937 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testStore keeps everything in maps and, like the memory store, hands
+// back the instances that it holds rather than copies.
+type testStore struct {
+	instances     map[string]*ServiceInstance
+	bindings      map[string]*BindInstance
+	operations    map[string]*AsyncOperation
+	orphans       map[string]*Orphan
+	getErr        error
+	getBindingErr error
+	addErr        error
+	deleteErr     error
+	lock          sync.Mutex
+}
+
+func newTestStore() *testStore {
+	return &testStore{
+		instances:  make(map[string]*ServiceInstance),
+		bindings:   make(map[string]*BindInstance),
+		operations: make(map[string]*AsyncOperation),
+		orphans:    make(map[string]*Orphan),
+	}
+}
+
+func (s *testStore) AddInstance(id string, si *ServiceInstance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.addErr != nil {
+		return s.addErr
+	}
+	if s.instances[id] != nil {
+		return NewConflictError("The instance %s already exists", id)
+	}
+	s.instances[id] = si
+	return nil
+}
+
+func (s *testStore) GetInstance(id string) (*ServiceInstance, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	si := s.instances[id]
+	if si == nil {
+		return nil, NewNotFoundError("The instance %s does not exist", id)
+	}
+	return si, nil
+}
+
+func (s *testStore) GetAllInstances() (map[string]*ServiceInstance, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	instances := make(map[string]*ServiceInstance, len(s.instances))
+	for k, v := range s.instances {
+		instances[k] = v
+	}
+	return instances, nil
+}
+
+func (s *testStore) UpdateInstance(id string, si *ServiceInstance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.instances[id] == nil {
+		return NewNotFoundError("The instance %s does not exist", id)
+	}
+	s.instances[id] = si
+	return nil
+}
+
+func (s *testStore) DeleteInstance(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	if s.instances[id] == nil {
+		return NewNotFoundError("The instance %s does not exist", id)
+	}
+	delete(s.instances, id)
+	return nil
+}
+
+func (s *testStore) AddBinding(instanceID string, bindingID string, bi *BindInstance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.addErr != nil {
+		return s.addErr
+	}
+	if s.bindings[instanceID+"/"+bindingID] != nil {
+		return NewConflictError("The binding %s already exists", bindingID)
+	}
+	b := *bi
+	s.bindings[instanceID+"/"+bindingID] = &b
+	return nil
+}
+
+func (s *testStore) GetBinding(instanceID string, bindingID string) (*BindInstance, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.getBindingErr != nil {
+		return nil, s.getBindingErr
+	}
+	bi := s.bindings[instanceID+"/"+bindingID]
+	if bi == nil {
+		return nil, NewNotFoundError("The binding %s does not exist", bindingID)
+	}
+	return bi, nil
+}
+
+func (s *testStore) GetAllBindings(instanceID string) (map[string]*BindInstance, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	bindings := make(map[string]*BindInstance)
+	for k, v := range s.bindings {
+		if len(k) > len(instanceID) && k[:len(instanceID)+1] == instanceID+"/" {
+			bindings[k[len(instanceID)+1:]] = v
+		}
+	}
+	return bindings, nil
+}
+
+func (s *testStore) UpdateBinding(instanceID string, bindingID string, bi *BindInstance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b := *bi
+	s.bindings[instanceID+"/"+bindingID] = &b
+	return nil
+}
+
+func (s *testStore) DeleteBinding(instanceID string, bindingID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.bindings[instanceID+"/"+bindingID] == nil {
+		return NewNotFoundError("The binding %s does not exist", bindingID)
+	}
+	delete(s.bindings, instanceID+"/"+bindingID)
+	return nil
+}
+
+func (s *testStore) setOperation(key string, op *AsyncOperation) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	o := *op
+	s.operations[key] = &o
+	return nil
+}
+
+func (s *testStore) getOperation(key string) (*AsyncOperation, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	o := s.operations[key]
+	if o == nil {
+		return nil, NewNotFoundError("No operation exists for %s", key)
+	}
+	op := *o
+	return &op, nil
+}
+
+func (s *testStore) SetInstanceOperation(instanceID string, op *AsyncOperation) error {
+	return s.setOperation(instanceID, op)
+}
+
+func (s *testStore) GetInstanceOperation(instanceID string) (*AsyncOperation, error) {
+	return s.getOperation(instanceID)
+}
+
+func (s *testStore) SetBindingOperation(instanceID string, bindingID string, op *AsyncOperation) error {
+	return s.setOperation(instanceID+"/"+bindingID, op)
+}
+
+func (s *testStore) GetBindingOperation(instanceID string, bindingID string) (*AsyncOperation, error) {
+	return s.getOperation(instanceID + "/" + bindingID)
+}
+
+func (s *testStore) AddOrphan(orphan *Orphan) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	o := *orphan
+	s.orphans[orphan.OrphanID] = &o
+	return nil
+}
+
+func (s *testStore) GetAllOrphans() ([]*Orphan, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	orphans := make([]*Orphan, 0, len(s.orphans))
+	for _, o := range s.orphans {
+		orphan := *o
+		orphans = append(orphans, &orphan)
+	}
+	return orphans, nil
+}
+
+func (s *testStore) DeleteOrphan(orphanID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.orphans[orphanID] == nil {
+		return NewNotFoundError("The orphan %s does not exist", orphanID)
+	}
+	delete(s.orphans, orphanID)
+	return nil
+}
+
+// testPlanFactory makes plans that do not talk to Stardog.  Instance
+// parameters are persisted as they are given, the secrets parameter names
+// the ones that must not be sent back and unbindErr makes every unbind
+// fail.  Creates and updates are counted and take createDelay and
+// updateDelay.
+type testPlanFactory struct {
+	id          string
+	secrets     []string
+	unbindErr   error
+	createDelay time.Duration
+	updateDelay time.Duration
+	creates     int
+	updates     int
+	lock        sync.Mutex
+}
+
+func (f *testPlanFactory) PlanName() string        { return f.id + "name" }
+func (f *testPlanFactory) PlanDescription() string { return "A test plan" }
+func (f *testPlanFactory) PlanID() string          { return f.id }
+func (f *testPlanFactory) Metadata() interface{}   { return nil }
+func (f *testPlanFactory) Free() bool              { return true }
+func (f *testPlanFactory) Bindable() bool          { return true }
+func (f *testPlanFactory) Schemas() *PlanSchemas   { return nil }
+func (f *testPlanFactory) PlanUpdateable() bool    { return true }
+func (f *testPlanFactory) SecretParameters() []string {
+	return f.secrets
+}
+
+func (f *testPlanFactory) InflatePlan(params interface{}, clientFactory StardogClientFactory, logger SdLogger) (Plan, error) {
+	var p map[string]interface{}
+	err := ReSerializeInterface(params, &p)
+	if err != nil {
+		return nil, err
+	}
+	return &testPlan{factory: f, params: p}, nil
+}
+
+type testPlan struct {
+	factory *testPlanFactory
+	params  map[string]interface{}
+}
+
+func (p *testPlan) CreateServiceInstance(ctx context.Context, rc *RequestContext) (int, interface{}, error) {
+	p.factory.lock.Lock()
+	p.factory.creates++
+	p.factory.lock.Unlock()
+	time.Sleep(p.factory.createDelay)
+	return http.StatusCreated, p.params, nil
+}
+
+func (p *testPlan) RemoveInstance(ctx context.Context) (int, interface{}, error) {
+	return http.StatusOK, nil, nil
+}
+
+func (p *testPlan) Bind(ctx context.Context, rc *RequestContext, params interface{}) (int, interface{}, error) {
+	return http.StatusOK, map[string]interface{}{"username": "user", "password": "secret"}, nil
+}
+
+func (p *testPlan) UnBind(ctx context.Context, binding interface{}) (int, error) {
+	if p.factory.unbindErr != nil {
+		return http.StatusInternalServerError, p.factory.unbindErr
+	}
+	return http.StatusOK, nil
+}
+
+func (p *testPlan) PlanID() string {
+	return p.factory.id
+}
+
+func (p *testPlan) EqualInstance(params interface{}) bool {
+	return true
+}
+
+func (p *testPlan) EqualBinding(bi *BindInstance, br *BindRequest) bool {
+	return true
+}
+
+// UpdateServiceInstance fails when the parameters ask it to so that
+// failed updates can be tested.
+func (p *testPlan) UpdateServiceInstance(ctx context.Context, params interface{}) (int, interface{}, error) {
+	var update map[string]interface{}
+	err := ReSerializeInterface(params, &update)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	p.factory.lock.Lock()
+	p.factory.updates++
+	p.factory.lock.Unlock()
+	time.Sleep(p.factory.updateDelay)
+	if update["fail"] == true {
+		return http.StatusInternalServerError, nil, NewBrokerError(http.StatusInternalServerError, "", "The update failed")
+	}
+	return http.StatusOK, MergeOptions(p.params, update), nil
+}
+
+func (p *testPlan) CanMoveTo(planFactory PlanFactory) bool {
+	return true
+}
+
+type testBroker struct {
+	server *httptest.Server
+	store  *testStore
+	plans  map[string]*testPlanFactory
+}
+
+// newTestBroker serves the broker API with the test plans and store.  The
+// broker credentials are user and pw.
+func newTestBroker(t *testing.T, conf *ServerConfig, plans ...*testPlanFactory) *testBroker {
+	if conf == nil {
+		conf = &ServerConfig{}
+	}
+	conf.BrokerUsername = "user"
+	conf.BrokerPassword = "pw"
+	if conf.BrokerID == "" {
+		conf.BrokerID = "brokerid"
+	}
+	planMap := make(map[string]PlanFactory)
+	tb := &testBroker{store: newTestStore(), plans: make(map[string]*testPlanFactory)}
+	for _, p := range plans {
+		planMap[p.id] = p
+		tb.plans[p.id] = p
+	}
+	s, err := CreateServer(planMap, conf, nil, getLogger(t), tb.store, nil)
+	if err != nil {
+		t.Fatalf("Failed to create the server %s", err)
+	}
+	tb.server = httptest.NewServer(s.handler())
+	return tb
+}
+
+func (tb *testBroker) close() {
+	tb.server.Close()
+}
+
+// do sends a request to the broker and decodes the response into result
+// if it is not nil.
+func (tb *testBroker) do(t *testing.T, method string, path string, version string, body interface{}, result interface{}) int {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to marshal the request %s", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, tb.server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("Failed to make the request %s", err)
+	}
+	req.SetBasicAuth("user", "pw")
+	if version != "" {
+		req.Header.Set(APIVersionHeader, version)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("The request failed %s", err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the response %s", err)
+	}
+	if result != nil && len(data) > 0 {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			t.Fatalf("The response %s is not valid JSON: %s", string(data), err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestMultipleServices(t *testing.T) {
+	conf := &ServerConfig{Services: []ServiceConfig{
+		{ID: "svc1", Name: "stardog-shared", PlanIDs: []string{"plan1"}},
+		{ID: "svc2", Name: "stardog-dedicated", PlanIDs: []string{"plan2", "plan3"}},
+	}}
+	tb := newTestBroker(t, conf, &testPlanFactory{id: "plan1"}, &testPlanFactory{id: "plan2"}, &testPlanFactory{id: "plan3"})
+	defer tb.close()
+
+	var catalog CatalogResponse
+	tb.do(t, "GET", "/v2/catalog", "2.14", nil, &catalog)
+	if len(catalog.Services) != 2 {
+		t.Fatalf("Both services should be in the catalog %v", catalog)
+	}
+	for _, s := range catalog.Services {
+		plans := make([]string, len(s.Plans))
+		for i, p := range s.Plans {
+			plans[i] = p.ID
+		}
+		if (s.ID == "svc1" && strings.Join(plans, ",") != "plan1") || (s.ID == "svc2" && strings.Join(plans, ",") != "plan2,plan3") {
+			t.Fatalf("The service %s lists the plans %v", s.ID, plans)
+		}
+	}
+
+	creates := []struct {
+		id        string
+		serviceID string
+		planID    string
+		code      int
+	}{
+		{"inst1", "svc1", "plan1", http.StatusCreated},
+		{"inst2", "svc2", "plan2", http.StatusCreated},
+		{"inst3", "svc1", "plan2", http.StatusBadRequest},
+		{"inst4", "other", "plan1", http.StatusBadRequest},
+		{"inst5", "svc2", "unknown", http.StatusBadRequest},
+	}
+	for _, c := range creates {
+		create := map[string]interface{}{"service_id": c.serviceID, "plan_id": c.planID}
+		code := tb.do(t, "PUT", "/v2/service_instances/"+c.id, "2.14", create, nil)
+		if code != c.code {
+			t.Fatalf("Creating %s with %s and %s got %d instead of %d", c.id, c.serviceID, c.planID, code, c.code)
+		}
+	}
+	si, _ := tb.store.GetInstance("inst2")
+	if si.ServiceID != "svc2" || si.PlanID != "plan2" {
+		t.Fatalf("The instance was stored with the wrong service %s and plan %s", si.ServiceID, si.PlanID)
+	}
+
+	// Plans can only change within a service
+	code := tb.do(t, "PATCH", "/v2/service_instances/inst2", "2.14", map[string]interface{}{"plan_id": "plan3"}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Moving to a plan of the same service should work, got %d", code)
+	}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst2", "2.14", map[string]interface{}{"plan_id": "plan1"}, nil)
+	if code != http.StatusBadRequest {
+		t.Fatalf("Moving to a plan of another service should fail, got %d", code)
+	}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", map[string]interface{}{"service_id": "svc2"}, nil)
+	if code != http.StatusBadRequest {
+		t.Fatalf("An update naming the wrong service should fail, got %d", code)
+	}
+	bind := map[string]interface{}{"service_id": "svc2", "plan_id": "plan1"}
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b1", "2.14", bind, nil)
+	if code != http.StatusBadRequest {
+		t.Fatalf("A bind naming the wrong service should fail, got %d", code)
+	}
+}
+
+func TestLoadServicesErrors(t *testing.T) {
+	plans := map[string]PlanFactory{"plan1": &testPlanFactory{id: "plan1"}, "plan2": &testPlanFactory{id: "plan2"}}
+	bad := [][]ServiceConfig{
+		{{ID: "svc1", PlanIDs: []string{"plan1"}}},
+		{{ID: "svc1", Name: "a", PlanIDs: []string{"plan1"}}, {ID: "svc1", Name: "b", PlanIDs: []string{"plan2"}}},
+		{{ID: "svc1", Name: "a", PlanIDs: []string{"plan1"}}, {ID: "svc2", Name: "b", PlanIDs: []string{"plan1"}}},
+		{{ID: "svc1", Name: "a", PlanIDs: []string{"plan3"}}},
+		{{ID: "svc1", Name: "a"}},
+	}
+	for _, services := range bad {
+		_, _, err := loadServices(&ServerConfig{Services: services}, plans, getLogger(t))
+		if err == nil {
+			t.Fatalf("The services %v should be rejected", services)
+		}
+	}
+	services, planServices, err := loadServices(&ServerConfig{BrokerID: "brokerid"}, plans, getLogger(t))
+	if err != nil || len(services) != 1 || planServices["plan1"] != "brokerid" || planServices["plan2"] != "brokerid" {
+		t.Fatalf("Without services every plan should be offered by the default service: %v", err)
+	}
+}
+
+func TestGetOrphansRedactsSecrets(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1", secrets: []string{"username", "password"}})
+	defer tb.close()
+	tb.store.AddOrphan(&Orphan{
+		OrphanID:   "o1",
+		Kind:       OrphanInstance,
+		PlanID:     "plan1",
+		PlanParams: map[string]interface{}{"db_name": "db", "username": "admin", "password": "adminpw"},
+	})
+	tb.store.AddOrphan(&Orphan{
+		OrphanID:   "o2",
+		Kind:       OrphanBinding,
+		PlanID:     "plan1",
+		PlanParams: map[string]interface{}{"db_name": "db", "username": "bound", "password": "boundpw"},
+	})
+
+	var orphans OrphansResponse
+	code := tb.do(t, "GET", "/admin/orphans", "", nil, &orphans)
+	if code != http.StatusOK || len(orphans.Orphans) != 2 {
+		t.Fatalf("Failed to list the orphans %d %v", code, orphans)
+	}
+	for _, o := range orphans.Orphans {
+		params := o.PlanParams.(map[string]interface{})
+		if _, ok := params["password"]; ok {
+			t.Fatalf("The orphan %s has a password %v", o.OrphanID, params)
+		}
+		if params["db_name"] != "db" {
+			t.Fatalf("The orphan %s lost the database name %v", o.OrphanID, params)
+		}
+	}
+	for _, o := range orphans.Orphans {
+		params := o.PlanParams.(map[string]interface{})
+		if o.Kind == OrphanBinding && params["username"] != "bound" {
+			t.Fatalf("The binding orphan should keep the username so that it can be cleaned up %v", params)
+		}
+		if o.Kind == OrphanInstance && params["username"] != nil {
+			t.Fatalf("The instance orphan should not have the admin username %v", params)
+		}
+	}
+	stored, _ := tb.store.GetAllOrphans()
+	for _, o := range stored {
+		if o.PlanParams.(map[string]interface{})["password"] == nil {
+			t.Fatalf("The stored orphan should not have been changed")
+		}
+	}
+}
+
+func TestCompensation(t *testing.T) {
+	defer func(delay time.Duration) { compensationDelay = delay }(compensationDelay)
+	compensationDelay = time.Millisecond
+	plan := &testPlanFactory{id: "plan1"}
+	tb := newTestBroker(t, nil, plan)
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+
+	tb.store.addErr = fmt.Errorf("The store is not reachable")
+	code = tb.do(t, "PUT", "/v2/service_instances/inst2", "2.14", create, nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("An instance that cannot be saved should fail, got %d", code)
+	}
+	orphans, _ := tb.store.GetAllOrphans()
+	if len(orphans) != 0 {
+		t.Fatalf("The unsaved instance was removed so it is not an orphan %v", orphans)
+	}
+
+	plan.unbindErr = fmt.Errorf("Stardog is not reachable")
+	bind := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b1", "2.14", bind, nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("A binding that cannot be saved should fail, got %d", code)
+	}
+	var listed OrphansResponse
+	code = tb.do(t, "GET", "/admin/orphans", "", nil, &listed)
+	if code != http.StatusOK || len(listed.Orphans) != 1 {
+		t.Fatalf("The binding that could not be undone should be an orphan %d %v", code, listed)
+	}
+	o := listed.Orphans[0]
+	if o.Kind != OrphanBinding || o.InstanceGUID != "inst1" || o.BindingGUID != "b1" || o.PlanID != "plan1" {
+		t.Fatalf("The orphan does not describe the binding %v", o)
+	}
+
+	code = tb.do(t, "DELETE", "/admin/orphans/"+o.OrphanID, "", nil, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Failed to delete the orphan %d", code)
+	}
+	code = tb.do(t, "DELETE", "/admin/orphans/"+o.OrphanID, "", nil, nil)
+	if code != http.StatusNotFound {
+		t.Fatalf("The orphan should be gone, got %d", code)
+	}
+}
+
+func TestFailedUpdateLeavesInstance(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"}, &testPlanFactory{id: "plan2"})
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1", "parameters": map[string]interface{}{"db_name": "db"}}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+
+	update := map[string]interface{}{"plan_id": "plan2", "parameters": map[string]interface{}{"fail": true}}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", update, nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("The update should have failed %d", code)
+	}
+	si, _ := tb.store.GetInstance("inst1")
+	if si.PlanID != "plan1" {
+		t.Fatalf("The failed update changed the stored instance to %s", si.PlanID)
+	}
+
+	update = map[string]interface{}{"plan_id": "plan2", "parameters": map[string]interface{}{"search": true}}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", update, nil)
+	if code != http.StatusOK {
+		t.Fatalf("The update should have succeeded %d", code)
+	}
+	si, _ = tb.store.GetInstance("inst1")
+	params := si.InstanceParams.(map[string]interface{})
+	if si.PlanID != "plan2" || params["search"] != true || params["db_name"] != "db" {
+		t.Fatalf("The update was not saved %s %v", si.PlanID, params)
+	}
+}
+
+func TestMaintenanceInfoConflict(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	create := map[string]interface{}{
+		"service_id":       "brokerid",
+		"plan_id":          "plan1",
+		"maintenance_info": map[string]interface{}{"version": "2.0.0"},
+	}
+	var e ErrorMessageResponse
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, &e)
+	if code != http.StatusUnprocessableEntity || e.Error != ErrorMaintenanceInfoConflict {
+		t.Fatalf("A create with a maintenance_info should conflict, got %d %v", code, e)
+	}
+
+	delete(create, "maintenance_info")
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	update := map[string]interface{}{"maintenance_info": map[string]interface{}{"version": "2.0.0"}}
+	e = ErrorMessageResponse{}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", update, &e)
+	if code != http.StatusUnprocessableEntity || e.Error != ErrorMaintenanceInfoConflict {
+		t.Fatalf("An update with a maintenance_info should conflict, got %d %v", code, e)
+	}
+}
+
+func TestStardogErrorSanitized(t *testing.T) {
+	err := &StardogError{Operation: "POST https://stardog.internal:5820/admin/databases", Status: http.StatusConflict, Code: "0D0DU2", Message: "Database already exists"}
+	w := httptest.NewRecorder()
+	SendBrokerError(getLogger(t), w, err, http.StatusInternalServerError)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("The Stardog status should be passed on, got %d", w.Code)
+	}
+	var e ErrorMessageResponse
+	json.Unmarshal(w.Body.Bytes(), &e)
+	if strings.Contains(e.Description, "stardog.internal") {
+		t.Fatalf("The Stardog URL was sent to the platform: %s", e.Description)
+	}
+	if e.Description != "Stardog returned 409: Database already exists (error code 0D0DU2)" {
+		t.Fatalf("The description lost the Stardog message: %s", e.Description)
+	}
+	if !strings.Contains(err.Error(), "stardog.internal") {
+		t.Fatalf("The logged error should still name the request")
+	}
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	plan := &testPlanFactory{id: "plan1", createDelay: 100 * time.Millisecond}
+	tb := newTestBroker(t, nil, plan)
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1", "parameters": map[string]interface{}{"db_name": "db"}}
+
+	for _, async := range []bool{false, true} {
+		path := "/v2/service_instances/sync"
+		if async {
+			path = "/v2/service_instances/async?accepts_incomplete=true"
+		}
+		codes := make(chan int, 5)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(codes); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- tb.do(t, "PUT", path, "2.14", create, nil)
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		for code := range codes {
+			if code != http.StatusOK && code != http.StatusCreated && code != http.StatusAccepted {
+				t.Fatalf("A repeated create should be accepted, got %d", code)
+			}
+		}
+	}
+	// Wait for the asynchronous create to finish
+	for i := 0; ; i++ {
+		instances, _ := tb.store.GetAllInstances()
+		if len(instances) == 2 {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("Both instances should be stored")
+		}
+		time.Sleep(plan.createDelay / 10)
+	}
+	plan.lock.Lock()
+	creates := plan.creates
+	plan.lock.Unlock()
+	if creates != 2 {
+		t.Fatalf("Each instance should be provisioned once, not %d times", creates)
+	}
+}
+
+func TestCreateStoreFailure(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	tb.store.getErr = fmt.Errorf("The store is not reachable")
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("A store failure should not be taken as a missing instance, got %d", code)
+	}
+	if tb.plans["plan1"].creates != 0 {
+		t.Fatalf("Nothing should be provisioned when the store cannot be read")
+	}
+}
+
+func TestStaleInstanceOperation(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	update := map[string]interface{}{"parameters": map[string]interface{}{"search": true}}
+
+	op := &AsyncOperation{OperationID: "op1", InstanceGUID: "inst1", Action: OperationUpdate, State: OperationInProgress}
+	op.Deadline = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tb.store.SetInstanceOperation("inst1", op)
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", update, nil)
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("An operation before its deadline should block the update, got %d", code)
+	}
+
+	// The broker that was running the operation stopped an hour ago
+	op.Deadline = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	tb.store.SetInstanceOperation("inst1", op)
+	var last LastOperation
+	code = tb.do(t, "GET", "/v2/service_instances/inst1/last_operation?operation=op1", "2.14", nil, &last)
+	if code != http.StatusOK || last.State != OperationFailed || last.Description == "" {
+		t.Fatalf("An operation past its deadline should be reported as failed %d %v", code, last)
+	}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", update, nil)
+	if code != http.StatusOK {
+		t.Fatalf("The instance should be updatable once the operation expired, got %d", code)
+	}
+
+	// Operations recorded before deadlines were kept have none
+	op.Deadline = ""
+	tb.store.SetInstanceOperation("inst1", op)
+	code = tb.do(t, "DELETE", "/v2/service_instances/inst1", "2.14", nil, nil)
+	if code != http.StatusOK {
+		t.Fatalf("An operation without a deadline should not block the instance for ever, got %d", code)
+	}
+}
+
+func TestAsyncOperationDeadline(t *testing.T) {
+	tb := newTestBroker(t, &ServerConfig{OperationTimeoutSeconds: 60}, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1?accepts_incomplete=true", "2.14", create, nil)
+	if code != http.StatusAccepted {
+		t.Fatalf("Failed to start creating the instance %d", code)
+	}
+	op, err := tb.store.GetInstanceOperation("inst1")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	deadline, err := time.Parse(time.RFC3339, op.Deadline)
+	if err != nil {
+		t.Fatalf("The operation should have a deadline: %s", err)
+	}
+	if deadline.Before(time.Now().Add(30*time.Second)) || deadline.After(time.Now().Add(90*time.Second)) {
+		t.Fatalf("The deadline %s does not follow operation_timeout_seconds", op.Deadline)
+	}
+}
+
+func TestDeprovisionStoreFailure(t *testing.T) {
+	defer func(delay time.Duration) { compensationDelay = delay }(compensationDelay)
+	compensationDelay = time.Millisecond
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1", "parameters": map[string]interface{}{"db_name": "db"}}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+
+	tb.store.deleteErr = fmt.Errorf("The store is not reachable")
+	code = tb.do(t, "DELETE", "/v2/service_instances/inst1", "2.14", nil, nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("An instance that cannot be deleted from the store should fail, got %d", code)
+	}
+	orphans, _ := tb.store.GetAllOrphans()
+	if len(orphans) != 1 || orphans[0].Kind != OrphanInstance || orphans[0].InstanceGUID != "inst1" {
+		t.Fatalf("The removed instance should be recorded as an orphan %v", orphans)
+	}
+}
+
+func TestStaleBindingOperation(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	bind := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+
+	op := &AsyncOperation{OperationID: "op1", InstanceGUID: "inst1", BindingGUID: "b1", Action: OperationBind, State: OperationInProgress}
+	op.Deadline = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tb.store.SetBindingOperation("inst1", "b1", op)
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b1", "2.14", bind, nil)
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("An operation before its deadline should block the binding, got %d", code)
+	}
+
+	op.Deadline = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	tb.store.SetBindingOperation("inst1", "b1", op)
+	var last LastOperation
+	code = tb.do(t, "GET", "/v2/service_instances/inst1/service_bindings/b1/last_operation?operation=op1", "2.14", nil, &last)
+	if code != http.StatusOK || last.State != OperationFailed || last.Description == "" {
+		t.Fatalf("An operation past its deadline should be reported as failed %d %v", code, last)
+	}
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b1", "2.14", bind, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("The binding should be possible once the operation expired, got %d", code)
+	}
+}
+
+func TestBindStoreFailure(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	tb.store.getBindingErr = fmt.Errorf("The store is not reachable")
+	bind := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b1", "2.14", bind, nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("A store failure should not be taken as a missing binding, got %d", code)
+	}
+}
+
+func TestConcurrentUpdate(t *testing.T) {
+	plan := &testPlanFactory{id: "plan1", updateDelay: 100 * time.Millisecond}
+	tb := newTestBroker(t, nil, plan)
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	update := map[string]interface{}{"parameters": map[string]interface{}{"search": true}}
+
+	operations := make(chan string, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(operations); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var resp CreateGetServiceInstanceResponse
+			code := tb.do(t, "PATCH", "/v2/service_instances/inst1?accepts_incomplete=true", "2.14", update, &resp)
+			if code != http.StatusAccepted {
+				t.Errorf("A repeated update should be accepted, got %d", code)
+			}
+			operations <- resp.Operation
+		}()
+	}
+	wg.Wait()
+	close(operations)
+	first := <-operations
+	for op := range operations {
+		if op != first {
+			t.Fatalf("The repeated updates should share one operation, got %s and %s", first, op)
+		}
+	}
+	for i := 0; ; i++ {
+		var last LastOperation
+		tb.do(t, "GET", "/v2/service_instances/inst1/last_operation?operation="+first, "2.14", nil, &last)
+		if last.State == OperationSucceeded {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("The update should finish, the last state was %s", last.State)
+		}
+		time.Sleep(plan.updateDelay / 10)
+	}
+	plan.lock.Lock()
+	updates := plan.updates
+	plan.lock.Unlock()
+	if updates != 1 {
+		t.Fatalf("The instance should be updated once, not %d times", updates)
+	}
+}
+
+func TestRepeatedAsyncOperation(t *testing.T) {
+	plan := &testPlanFactory{id: "plan1", updateDelay: 200 * time.Millisecond}
+	tb := newTestBroker(t, nil, plan)
+	defer tb.close()
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.14", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	update := map[string]interface{}{"parameters": map[string]interface{}{"search": true}}
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1?accepts_incomplete=true", "2.14", update, nil)
+	if code != http.StatusAccepted {
+		t.Fatalf("Failed to start updating the instance %d", code)
+	}
+
+	var errResp ErrorMessageResponse
+	code = tb.do(t, "PATCH", "/v2/service_instances/inst1", "2.14", update, &errResp)
+	if code != http.StatusUnprocessableEntity || errResp.Error != ErrorAsyncRequired {
+		t.Fatalf("A synchronous repeat of the update should be told to go asynchronous %d %v", code, errResp)
+	}
+	errResp = ErrorMessageResponse{}
+	code = tb.do(t, "DELETE", "/v2/service_instances/inst1?accepts_incomplete=true", "2.14", nil, &errResp)
+	if code != http.StatusUnprocessableEntity || errResp.Error != ErrorConcurrency {
+		t.Fatalf("Another operation should be a concurrency error %d %v", code, errResp)
+	}
+}
@@ -0,0 +1,195 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// The error codes defined by the Open Service Broker API.  They are sent
+// in the error field of an error response so that platforms can act on
+// them.
+const (
+	ErrorAsyncRequired           = "AsyncRequired"
+	ErrorConcurrency             = "ConcurrencyError"
+	ErrorRequiresApp             = "RequiresApp"
+	ErrorMaintenanceInfoConflict = "MaintenanceInfoConflict"
+	ErrorBadRequest              = "BadRequest"
+)
+
+// BrokerError is an error that knows how it should be reported to the
+// client.  Status is the HTTP status and Code is the Open Service Broker
+// API error code, which is empty when the API does not define one.  Plans,
+// Stores and StardogClients can return a BrokerError to control the
+// response that the platform gets.
+type BrokerError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *BrokerError) Error() string {
+	return e.Description
+}
+
+// NewBrokerError makes a BrokerError with a formatted description.
+func NewBrokerError(status int, code string, format string, a ...interface{}) *BrokerError {
+	return &BrokerError{
+		Status:      status,
+		Code:        code,
+		Description: fmt.Sprintf(format, a...),
+	}
+}
+
+// NewBadRequestError is used when the request from the client is malformed
+// or its parameters are not valid.
+func NewBadRequestError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusBadRequest, ErrorBadRequest, format, a...)
+}
+
+// NewNotFoundError is used when an instance, binding or other object does
+// not exist.
+func NewNotFoundError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusNotFound, "", format, a...)
+}
+
+// NewConflictError is used when an object already exists with different
+// values.
+func NewConflictError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusConflict, "", format, a...)
+}
+
+// NewConcurrencyError is used when another operation is already in
+// progress on the instance or binding.
+func NewConcurrencyError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusUnprocessableEntity, ErrorConcurrency, format, a...)
+}
+
+// NewAsyncRequiredError is used when a request can only be handled
+// asynchronously but the client did not send accepts_incomplete=true.
+func NewAsyncRequiredError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusUnprocessableEntity, ErrorAsyncRequired, format, a...)
+}
+
+// NewRequiresAppError is used when a bind request must include an
+// application GUID.  None of the plans needs one yet.
+func NewRequiresAppError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusUnprocessableEntity, ErrorRequiresApp, format, a...)
+}
+
+// NewMaintenanceInfoConflictError is used when the maintenance_info sent
+// by the client does not match the one in the catalog.
+func NewMaintenanceInfoConflictError(format string, a ...interface{}) *BrokerError {
+	return NewBrokerError(http.StatusUnprocessableEntity, ErrorMaintenanceInfoConflict, format, a...)
+}
+
+// StardogError is an error response from a Stardog server.  Status is the
+// HTTP status of the response, Code and Message are the error code and
+// message that Stardog sent, if any, and Operation is the request that
+// failed.
+type StardogError struct {
+	Operation string
+	Status    int
+	Code      string
+	Message   string
+}
+
+func (e *StardogError) Error() string {
+	msg := fmt.Sprintf("Stardog returned %d to %s", e.Status, e.Operation)
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s (error code %s)", msg, e.Code)
+	}
+	return msg
+}
+
+// Description is the part of the error that is sent to platforms.  It
+// leaves out the Stardog request, which names the internal URL of the
+// server.
+func (e *StardogError) Description() string {
+	msg := fmt.Sprintf("Stardog returned %d", e.Status)
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s (error code %s)", msg, e.Code)
+	}
+	return msg
+}
+
+// ErrorDescription returns the description of err that is sent to
+// platforms.
+func ErrorDescription(err error) string {
+	if se, ok := err.(*StardogError); ok {
+		return se.Description()
+	}
+	return err.Error()
+}
+
+// StardogStatus returns the HTTP status of the Stardog response that err
+// reports, or 0 if err is not a StardogError.
+func StardogStatus(err error) int {
+	if se, ok := err.(*StardogError); ok {
+		return se.Status
+	}
+	return 0
+}
+
+// IsStardogAuthFailure returns true if Stardog rejected the credentials
+// that a request was made with or did not allow the request.
+func IsStardogAuthFailure(err error) bool {
+	status := StardogStatus(err)
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// ErrorStatus returns the HTTP status that err should be reported with.
+// Stardog rejecting a request as malformed or conflicting is passed on to
+// the client.  Other errors get defaultStatus.
+func ErrorStatus(err error, defaultStatus int) int {
+	switch e := err.(type) {
+	case *BrokerError:
+		if e.Status != 0 {
+			return e.Status
+		}
+	case *StardogError:
+		if e.Status == http.StatusBadRequest || e.Status == http.StatusConflict {
+			return e.Status
+		}
+	}
+	return defaultStatus
+}
+
+// IsNotFound returns true if err reports that an object does not exist.
+func IsNotFound(err error) bool {
+	return ErrorStatus(err, 0) == http.StatusNotFound
+}
+
+// SendBrokerError sends err to the client.  The status and error code of
+// a BrokerError are used, other errors are sent with defaultStatus.
+func SendBrokerError(logger SdLogger, w http.ResponseWriter, err error, defaultStatus int) {
+	status := ErrorStatus(err, defaultStatus)
+	e := ErrorMessageResponse{Description: ErrorDescription(err)}
+	if be, ok := err.(*BrokerError); ok {
+		e.Error = be.Code
+	} else if StardogStatus(err) == http.StatusBadRequest {
+		e.Error = ErrorBadRequest
+	}
+	logger.Logf(ERROR, "Sending the error message %d %s %s", status, e.Error, err)
+	WriteResponse(w, status, &e)
+}
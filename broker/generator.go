@@ -0,0 +1,160 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	lowercaseChars = "abcdefghijklmnopqrstuvwxyz"
+	uppercaseChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars     = "0123456789"
+	symbolChars    = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	// DefaultPasswordLength is the length of generated passwords when the
+	// plan does not set a password policy.
+	DefaultPasswordLength = 24
+	// MinPasswordLength is the shortest password a policy may ask for.
+	MinPasswordLength = 12
+)
+
+var characterClasses = map[string]string{
+	"lowercase": lowercaseChars,
+	"uppercase": uppercaseChars,
+	"digits":    digitChars,
+	"symbols":   symbolChars,
+}
+
+var defaultCharacterClasses = []string{"lowercase", "uppercase", "digits"}
+
+// PasswordGenerator creates the passwords of bound users according to a
+// PasswordPolicy.  Every generated password contains at least one
+// character of each class in the policy.
+type PasswordGenerator struct {
+	length   int
+	classes  [][]rune
+	alphabet []rune
+}
+
+// NewPasswordGenerator checks a password policy and creates a generator
+// for it.  A nil policy generates DefaultPasswordLength characters from
+// lower and upper case letters and digits.
+func NewPasswordGenerator(policy *PasswordPolicy) (*PasswordGenerator, error) {
+	if policy == nil {
+		policy = &PasswordPolicy{}
+	}
+	g := &PasswordGenerator{length: policy.Length}
+	if g.length == 0 {
+		g.length = DefaultPasswordLength
+	}
+	if g.length < MinPasswordLength {
+		return nil, fmt.Errorf("The password length must be at least %d", MinPasswordLength)
+	}
+	classNames := policy.CharacterClasses
+	if len(classNames) == 0 {
+		classNames = defaultCharacterClasses
+	}
+	seen := make(map[rune]bool)
+	for _, name := range classNames {
+		chars, ok := characterClasses[name]
+		if !ok {
+			return nil, fmt.Errorf("The character class %s is not supported", name)
+		}
+		var class []rune
+		for _, c := range chars {
+			if strings.ContainsRune(policy.ExcludeCharacters, c) || seen[c] {
+				continue
+			}
+			seen[c] = true
+			class = append(class, c)
+		}
+		if len(class) == 0 {
+			return nil, fmt.Errorf("Every character of the class %s is excluded", name)
+		}
+		g.classes = append(g.classes, class)
+		g.alphabet = append(g.alphabet, class...)
+	}
+	if g.length < len(g.classes) {
+		return nil, fmt.Errorf("The password length %d is too short to hold every character class", g.length)
+	}
+	return g, nil
+}
+
+// Generate returns a new password.
+func (g *PasswordGenerator) Generate() (string, error) {
+	pw := make([]rune, 0, g.length)
+	for _, class := range g.classes {
+		c, err := randomRunes(class, 1)
+		if err != nil {
+			return "", err
+		}
+		pw = append(pw, c...)
+	}
+	rest, err := randomRunes(g.alphabet, g.length-len(pw))
+	if err != nil {
+		return "", err
+	}
+	pw = append(pw, rest...)
+
+	// Shuffle so that the required characters are not always first
+	for i := len(pw) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		pw[i], pw[j] = pw[j], pw[i]
+	}
+	return string(pw), nil
+}
+
+// randomInt returns a uniformly distributed number in [0, n) from the
+// system's secure random source.
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to read random data: %s", err)
+	}
+	return int(i.Int64()), nil
+}
+
+func randomRunes(alphabet []rune, n int) ([]rune, error) {
+	b := make([]rune, n)
+	for i := range b {
+		j, err := randomInt(len(alphabet))
+		if err != nil {
+			return nil, err
+		}
+		b[i] = alphabet[j]
+	}
+	return b, nil
+}
+
+// GetRandomName is a convience function for creating random strings.  It
+// appends n random letters to base.  The letters come from the system's
+// secure random source so names cannot be predicted.
+func GetRandomName(base string, n int) string {
+	b, err := randomRunes([]rune(lowercaseChars+uppercaseChars), n)
+	if err != nil {
+		// The system has no usable source of randomness, so nothing
+		// secret can be generated safely
+		panic(err)
+	}
+	return fmt.Sprintf("%s%s", base, string(b))
+}
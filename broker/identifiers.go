@@ -0,0 +1,101 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// Identifiers end up in SPARQL, Turtle, SQL and URL paths so they are
+// restricted to characters that have no meaning in any of them.
+var (
+	// GUIDs are usually UUIDs but platforms may use other IDs.  They are
+	// limited to the 64 characters that the SQL store keeps.
+	guidPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+	// Stardog database names, which may also be used as role names
+	databaseNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,63}$`)
+	// Stardog user names
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.@-]{0,63}$`)
+	// SQL database names, which must not need quoting
+	sqlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+)
+
+// ValidateGUID checks that an instance, binding or orphan ID only uses
+// letters, digits, dashes and underscores.
+func ValidateGUID(kind string, id string) error {
+	if !guidPattern.MatchString(id) {
+		return NewBadRequestError("The %s %q is not valid.  It must be 1 to 64 letters, digits, dashes and underscores", kind, id)
+	}
+	return nil
+}
+
+// ValidateDatabaseName checks that a name can be used as the name of a
+// Stardog database.
+func ValidateDatabaseName(name string) error {
+	if !databaseNamePattern.MatchString(name) {
+		return NewBadRequestError("The database name %q is not valid.  It must start with a letter followed by up to 63 letters, digits, dashes and underscores", name)
+	}
+	return nil
+}
+
+// ValidateUsername checks that a name can be used as the name of a Stardog
+// user.
+func ValidateUsername(name string) error {
+	if !usernamePattern.MatchString(name) {
+		return NewBadRequestError("The username %q is not valid.  It must be 1 to 64 letters, digits, dashes, underscores, dots and @ signs", name)
+	}
+	return nil
+}
+
+// ValidateSQLName checks that a name can be used as a SQL identifier
+// without quoting.
+func ValidateSQLName(name string) error {
+	if !sqlNamePattern.MatchString(name) {
+		return fmt.Errorf("The SQL name %q is not valid.  It must start with a letter or underscore followed by up to 63 letters, digits and underscores", name)
+	}
+	return nil
+}
+
+// EscapeSPARQLString escapes a string so that it can be put between double
+// quotes in a SPARQL query or a Turtle document.
+func EscapeSPARQLString(s string) string {
+	var b bytes.Buffer
+	for _, c := range s {
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
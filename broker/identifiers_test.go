@@ -0,0 +1,90 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateGUID(t *testing.T) {
+	valid := []string{
+		"3c1b3c8e-0d71-4e0b-a8a5-1d3c1b9f0c7e",
+		"a",
+		"instance_1",
+		strings.Repeat("a", 64),
+	}
+	for _, id := range valid {
+		err := ValidateGUID("instance ID", id)
+		if err != nil {
+			t.Fatalf("%s should be valid: %s", id, err)
+		}
+	}
+	invalid := []string{
+		"",
+		strings.Repeat("a", 65),
+		"-leading",
+		"_leading",
+		"has space",
+		"a/b",
+		"a'b",
+		"a.b",
+		"a%2Fb",
+	}
+	for _, id := range invalid {
+		err := ValidateGUID("instance ID", id)
+		if err == nil {
+			t.Fatalf("%q should not be valid", id)
+		}
+		if ErrorStatus(err, 0) != http.StatusBadRequest {
+			t.Fatalf("%q should be a bad request: %s", id, err)
+		}
+	}
+}
+
+func TestValidateSQLName(t *testing.T) {
+	for _, name := range []string{"service_broker", "_x", "A1", strings.Repeat("a", 64)} {
+		err := ValidateSQLName(name)
+		if err != nil {
+			t.Fatalf("%s should be valid: %s", name, err)
+		}
+	}
+	for _, name := range []string{"", "1abc", "a-b", "a;drop table x", "a`b", strings.Repeat("a", 65)} {
+		if ValidateSQLName(name) == nil {
+			t.Fatalf("%q should not be valid", name)
+		}
+	}
+}
+
+func TestEscapeSPARQLString(t *testing.T) {
+	cases := map[string]string{
+		"plain":         "plain",
+		`a"b`:           `a\"b`,
+		`a'b`:           `a\'b`,
+		`a\b`:           `a\\b`,
+		"a\nb\rc\td":    `a\nb\rc\td`,
+		"a\bb\fc":       `a\bb\fc`,
+		`"} ; DROP ALL`: `\"} ; DROP ALL`,
+		"unicode é":     "unicode é",
+	}
+	for in, expected := range cases {
+		out := EscapeSPARQLString(in)
+		if out != expected {
+			t.Fatalf("%q was escaped to %q instead of %q", in, out, expected)
+		}
+	}
+}
@@ -15,7 +15,10 @@
 
 package broker
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 // StardogClientFactory creates netwrok API connection objects to a
 // Stardog service.  This mainly serves and a place to insert mock objects
@@ -24,19 +27,40 @@ type StardogClientFactory interface {
 	GetStardogAdminClient(string, DatabaseCredentials) StardogClient
 }
 
+// TLSClientFactory is implemented by StardogClientFactory objects that can
+// connect to Stardog servers with custom TLS settings.  The factory that it
+// returns creates clients that use the settings.
+type TLSClientFactory interface {
+	WithTLSConfig(*StardogTLSConfig) (StardogClientFactory, error)
+}
+
+// RetryClientFactory is implemented by StardogClientFactory objects whose
+// clients can retry requests.  The factory that it returns creates clients
+// that retry as the Retrier says.
+type RetryClientFactory interface {
+	WithRetrier(*Retrier) StardogClientFactory
+}
+
 // StardogClient is the object used to interact with the Stardog service.
 // At some point it may make sense to break this out into its own package.
+// Requests to Stardog are abandoned when the context is cancelled.
 type StardogClient interface {
-	CreateDatabase(string) error
-	DeleteDatabase(string) error
-	UserExists(string) (bool, error)
-	NewUser(string, string) error
-	DeleteUser(string) error
-	GrantUserAccessToDb(string, string) error
-	RevokeUserAccess(string, string) error
-	GetDatabaseSize(dbName string) (int, error)
-	AddData(dbName string, format string, data string) error
-	Query(dbName string, data string) ([]byte, error)
+	CreateDatabase(ctx context.Context, dbName string, options map[string]interface{}) error
+	DeleteDatabase(ctx context.Context, dbName string) error
+	SetDatabaseOptions(ctx context.Context, dbName string, options map[string]interface{}) error
+	UserExists(ctx context.Context, username string) (bool, error)
+	NewUser(ctx context.Context, username string, password string) error
+	DeleteUser(ctx context.Context, username string) error
+	GrantUserPermissions(ctx context.Context, dbName string, username string, actions []string) error
+	RevokeUserPermissions(ctx context.Context, dbName string, username string, actions []string) error
+	CreateRole(ctx context.Context, role string) error
+	DeleteRole(ctx context.Context, role string) error
+	GrantRolePermissions(ctx context.Context, dbName string, role string, actions []string) error
+	AssignRole(ctx context.Context, username string, role string) error
+	GetDatabaseSize(ctx context.Context, dbName string) (int, error)
+	AddData(ctx context.Context, dbName string, format string, data string) error
+	Query(ctx context.Context, dbName string, data string) ([]byte, error)
+	Update(ctx context.Context, dbName string, query string) error
 }
 
 // Controller object handles the HTTP network API calls.
@@ -45,20 +69,50 @@ type Controller interface {
 	CreateServiceInstance(http.ResponseWriter, *http.Request)
 	GetServiceInstance(http.ResponseWriter, *http.Request)
 	RemoveServiceInstance(http.ResponseWriter, *http.Request)
+	UpdateServiceInstance(http.ResponseWriter, *http.Request)
 	Bind(http.ResponseWriter, *http.Request)
+	GetBinding(http.ResponseWriter, *http.Request)
 	UnBind(http.ResponseWriter, *http.Request)
+	InstanceLastOperation(http.ResponseWriter, *http.Request)
+	BindingLastOperation(http.ResponseWriter, *http.Request)
+	GetOrphans(http.ResponseWriter, *http.Request)
+	DeleteOrphan(http.ResponseWriter, *http.Request)
 }
 
 // Store is the interface to persisting information related to service instances
 // and bounded applications.  There is an in memory store for testing and
 // Stardog store for the shared plan.  More storage drivers maybe needed
-// as more plans are created.
+// as more plans are created.  The state of asynchronous operations is also
+// kept in the Store so that it can be reported by last_operation, as are
+// the resources that were orphaned when a plan could not be cleaned up.
+// GetAllInstances and UpdateBinding are used by tools that rewrite every
+// record, such as re-encryption.
 type Store interface {
 	AddInstance(string, *ServiceInstance) error
 	GetInstance(string) (*ServiceInstance, error)
+	GetAllInstances() (map[string]*ServiceInstance, error)
+	UpdateInstance(string, *ServiceInstance) error
 	DeleteInstance(string) error
 	AddBinding(string, string, *BindInstance) error
 	GetBinding(string, string) (*BindInstance, error)
 	GetAllBindings(string) (map[string]*BindInstance, error)
+	UpdateBinding(string, string, *BindInstance) error
 	DeleteBinding(string, string) error
+	SetInstanceOperation(string, *AsyncOperation) error
+	GetInstanceOperation(string) (*AsyncOperation, error)
+	SetBindingOperation(string, string, *AsyncOperation) error
+	GetBindingOperation(string, string) (*AsyncOperation, error)
+	AddOrphan(*Orphan) error
+	GetAllOrphans() ([]*Orphan, error)
+	DeleteOrphan(string) error
+}
+
+// SecretStore keeps the credentials of bindings outside of the Store so
+// that only a reference to them is persisted.  Credentials are JSON
+// documents named with CredHub style paths.  Requests to a remote store
+// are abandoned when the context is cancelled.
+type SecretStore interface {
+	SetCredentials(context.Context, string, interface{}) error
+	GetCredentials(context.Context, string) (interface{}, error)
+	DeleteCredentials(context.Context, string) error
 }
@@ -0,0 +1,63 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"sync"
+)
+
+// instanceLocks holds a lock for each service instance that a request is
+// working on.  Creating an instance checks that it does not exist and that
+// no operation is in progress before it starts one, and the lock makes
+// those steps atomic when several requests for the same instance arrive at
+// once.  A lock is dropped when no request holds it.
+type instanceLocks struct {
+	lock  sync.Mutex
+	locks map[string]*instanceLock
+}
+
+type instanceLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newInstanceLocks() *instanceLocks {
+	return &instanceLocks{locks: make(map[string]*instanceLock)}
+}
+
+// acquire waits for the lock of the instance and returns the function that
+// releases it.
+func (l *instanceLocks) acquire(instanceGUID string) func() {
+	l.lock.Lock()
+	il, ok := l.locks[instanceGUID]
+	if !ok {
+		il = &instanceLock{}
+		l.locks[instanceGUID] = il
+	}
+	il.refs++
+	l.lock.Unlock()
+
+	il.Lock()
+	return func() {
+		il.Unlock()
+		l.lock.Lock()
+		il.refs--
+		if il.refs == 0 {
+			delete(l.locks, instanceGUID)
+		}
+		l.lock.Unlock()
+	}
+}
@@ -36,26 +36,56 @@ type CatalogMetadata struct {
 }
 
 // CatalogService is a catalog entry that describes one of the brokers
-// service offerings.  This broker only offers a single service.
+// service offerings.
 type CatalogService struct {
-	Name           string          `json:"name"`
-	ID             string          `json:"id"`
-	Description    string          `json:"description"`
-	Bindable       bool            `json:"bindable"`
-	PlanUpdateable bool            `json:"plan_updateable, omitempty"`
-	Plans          []ServicePlan   `json:"plans"`
-	Metadata       CatalogMetadata `json:"metadata, omitempty"`
+	Name                string          `json:"name"`
+	ID                  string          `json:"id"`
+	Description         string          `json:"description"`
+	Tags                []string        `json:"tags,omitempty"`
+	Requires            []string        `json:"requires,omitempty"`
+	Bindable            bool            `json:"bindable"`
+	PlanUpdateable      bool            `json:"plan_updateable,omitempty"`
+	BindingsRetrievable bool            `json:"bindings_retrievable,omitempty"`
+	Plans               []ServicePlan   `json:"plans"`
+	Metadata            CatalogMetadata `json:"metadata,omitempty"`
 }
 
 // ServicePlan is a catalog entry that describes a plan.  Currently the
 // only plan offered by this service is the shared plan.
 type ServicePlan struct {
-	Name        string      `json:"name"`
-	ID          string      `json:"id"`
-	Description string      `json:"description"`
-	Metadata    interface{} `json:"metadata,omitempty"`
-	Free        bool        `json:"free,omitempty"`
-	Bindable    bool        `json:"bindable,omitempty"`
+	Name           string       `json:"name"`
+	ID             string       `json:"id"`
+	Description    string       `json:"description"`
+	Metadata       interface{}  `json:"metadata,omitempty"`
+	Free           bool         `json:"free,omitempty"`
+	Bindable       bool         `json:"bindable,omitempty"`
+	PlanUpdateable bool         `json:"plan_updateable,omitempty"`
+	Schemas        *PlanSchemas `json:"schemas,omitempty"`
+}
+
+// PlanSchemas are the JSON Schemas that describe the parameters a plan
+// accepts.  They are published in the catalog and used to validate the
+// parameters that clients send.
+type PlanSchemas struct {
+	ServiceInstance *ServiceInstanceSchema `json:"service_instance,omitempty"`
+	ServiceBinding  *ServiceBindingSchema  `json:"service_binding,omitempty"`
+}
+
+// ServiceInstanceSchema holds the schemas for creating and updating a
+// service instance.
+type ServiceInstanceSchema struct {
+	Create *InputParametersSchema `json:"create,omitempty"`
+	Update *InputParametersSchema `json:"update,omitempty"`
+}
+
+// ServiceBindingSchema holds the schema for creating a binding.
+type ServiceBindingSchema struct {
+	Create *InputParametersSchema `json:"create,omitempty"`
+}
+
+// InputParametersSchema wraps a JSON Schema document.
+type InputParametersSchema struct {
+	Parameters map[string]interface{} `json:"parameters"`
 }
 
 // Request structures
@@ -63,47 +93,91 @@ type ServicePlan struct {
 // CreateServiceInstanceRequest is the object representation of the clients
 // request to create a new service instance.
 type CreateServiceInstanceRequest struct {
-	ServiceID        string      `json:"service_id"`
-	PlanID           string      `json:"plan_id"`
-	OrganizationGUID string      `json:"organization_guid"`
-	SpaceGUID        string      `json:"space_guid"`
-	Parameters       interface{} `json:"parameters, omitempty"`
+	ServiceID        string           `json:"service_id"`
+	PlanID           string           `json:"plan_id"`
+	OrganizationGUID string           `json:"organization_guid"`
+	SpaceGUID        string           `json:"space_guid"`
+	Parameters       interface{}      `json:"parameters,omitempty"`
+	Context          PlatformContext  `json:"context,omitempty"`
+	MaintenanceInfo  *MaintenanceInfo `json:"maintenance_info,omitempty"`
+}
+
+// UpdateServiceInstanceRequest is the object representation of the clients
+// request to modify an existing service instance.  PlanID is only set when
+// the client wants to move the instance to a different plan.
+type UpdateServiceInstanceRequest struct {
+	ServiceID       string           `json:"service_id"`
+	PlanID          string           `json:"plan_id,omitempty"`
+	Parameters      interface{}      `json:"parameters,omitempty"`
+	PreviousValues  PreviousValues   `json:"previous_values,omitempty"`
+	Context         PlatformContext  `json:"context,omitempty"`
+	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
+}
+
+// MaintenanceInfo is the version of a plan that the client expects.
+type MaintenanceInfo struct {
+	Version string `json:"version"`
+}
+
+// PreviousValues describes the service instance as the client knew it
+// before an update.
+type PreviousValues struct {
+	ServiceID string `json:"service_id,omitempty"`
+	PlanID    string `json:"plan_id,omitempty"`
 }
 
 // BindRequest is the object representation of the clients request to bind
 // and application to a service instance.
 type BindRequest struct {
-	ServiceID  string       `json:"service_id"`
-	PlanID     string       `json:"plan_id"`
-	Resource   BindResource `json:"bind_resource, omitempty"`
-	Parameters interface{}  `json:"parameters, omitempty"`
+	ServiceID  string          `json:"service_id"`
+	PlanID     string          `json:"plan_id"`
+	Resource   BindResource    `json:"bind_resource,omitempty"`
+	Parameters interface{}     `json:"parameters,omitempty"`
+	Context    PlatformContext `json:"context,omitempty"`
 }
 
 // BindResource describes that application being bound.
 type BindResource struct {
-	AppGUID string `json:"app_GUID, omitempty"`
+	AppGUID string `json:"app_GUID,omitempty"`
 }
 
 // Response structures
 
 // CreateGetServiceInstanceResponse is the response to the client
-// when a service is created or looked up.
+// when a service is created or looked up.  Operation is only set when the
+// request is being handled asynchronously.  ServiceID, PlanID and
+// Parameters are only set when an instance is looked up.
 type CreateGetServiceInstanceResponse struct {
-	DashboardURL  string         `json:"dashboard_url, omitempty"`
-	LastOperation *LastOperation `json:"last_operation, omitempty"`
+	ServiceID     string         `json:"service_id,omitempty"`
+	PlanID        string         `json:"plan_id,omitempty"`
+	DashboardURL  string         `json:"dashboard_url,omitempty"`
+	Parameters    interface{}    `json:"parameters,omitempty"`
+	Operation     string         `json:"operation,omitempty"`
+	LastOperation *LastOperation `json:"last_operation,omitempty"`
 }
 
-// LastOperation is used for async messaging.
+// LastOperation is used for async messaging.  It is the document returned
+// when the client polls the last_operation endpoint.
 type LastOperation struct {
 	State                    string `json:"state"`
-	Description              string `json:"description"`
-	AsyncPollIntervalSeconds int    `json:"async_poll_interval_seconds, omitempty"`
+	Description              string `json:"description,omitempty"`
+	AsyncPollIntervalSeconds int    `json:"async_poll_interval_seconds,omitempty"`
 }
 
 // ErrorMessageResponse wraps up error messages that are sent to
-// the client.
+// the client.  Error is one of the error codes defined by the Open Service
+// Broker API.
 type ErrorMessageResponse struct {
-	Description string `json:"description, omitempty"`
+	Error             string           `json:"error,omitempty"`
+	Description       string           `json:"description,omitempty"`
+	InvalidParameters []ParameterError `json:"invalid_parameters,omitempty"`
+}
+
+// ParameterError describes a parameter that failed validation.  Field is
+// the path to the parameter, such as parameters.db_name.
+type ParameterError struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
 }
 
 // BindResponse is the data sent back to the client after a bind.  The
@@ -112,6 +186,12 @@ type BindResponse struct {
 	Credentials interface{} `json:"credentials"`
 }
 
+// AsyncBindResponse is sent to the client when a bind or unbind request is
+// being handled asynchronously.
+type AsyncBindResponse struct {
+	Operation string `json:"operation,omitempty"`
+}
+
 // UnbindResponse is the empty reply to a successful unbind call.
 type UnbindResponse struct {
 }
@@ -120,22 +200,95 @@ type UnbindResponse struct {
 
 // ServiceInstance is the brokers representation of a service instance
 // and contains and interface to the plan in use.  It can be serialized
-// by Stores.
+// by Stores.  Context and OriginatingIdentity record where the request
+// that created it came from.
 type ServiceInstance struct {
-	Plan             Plan        `json:"-"`
-	InstanceGUID     string      `json:"instance_guid"`
-	PlanID           string      `json:"plan_id"`
-	OrganizationGUID string      `json:"organization_guid"`
-	SpaceGUID        string      `json:"space_guid"`
-	ServiceID        string      `json:"service_id"`
-	InstanceParams   interface{} `json:"plan_params"`
+	Plan                Plan                 `json:"-"`
+	InstanceGUID        string               `json:"instance_guid"`
+	PlanID              string               `json:"plan_id"`
+	OrganizationGUID    string               `json:"organization_guid"`
+	SpaceGUID           string               `json:"space_guid"`
+	ServiceID           string               `json:"service_id"`
+	InstanceParams      interface{}          `json:"plan_params"`
+	Context             PlatformContext      `json:"context,omitempty"`
+	OriginatingIdentity *OriginatingIdentity `json:"originating_identity,omitempty"`
 }
 
 // BindInstance is used to represent bounded applications.  The PlanParams
 // field is defined by the plan in use.  It can be serialized by a Store.
 type BindInstance struct {
-	BindGUID   string      `json:"binding_guid"`
-	PlanParams interface{} `json:"plan_params"`
+	BindGUID            string               `json:"binding_guid"`
+	PlanParams          interface{}          `json:"plan_params"`
+	Context             PlatformContext      `json:"context,omitempty"`
+	OriginatingIdentity *OriginatingIdentity `json:"originating_identity,omitempty"`
+}
+
+// The states that an AsyncOperation can be in.  These are the values
+// defined by the Open Service Broker API for last_operation.
+const (
+	OperationInProgress = "in progress"
+	OperationSucceeded  = "succeeded"
+	OperationFailed     = "failed"
+)
+
+// The actions that can be run asynchronously.
+const (
+	OperationProvision   = "provision"
+	OperationDeprovision = "deprovision"
+	OperationUpdate      = "update"
+	OperationBind        = "bind"
+	OperationUnbind      = "unbind"
+)
+
+// AsyncOperation records the progress of a request that is being handled
+// in the background.  It is persisted by a Store so that its state can be
+// reported by the last_operation endpoint.  BindingGUID is only set for
+// bind and unbind operations.  An operation that is still in progress
+// after its Deadline is reported as failed since the broker that ran it
+// has stopped or given up on it.
+type AsyncOperation struct {
+	OperationID  string `json:"operation_id"`
+	InstanceGUID string `json:"instance_guid"`
+	BindingGUID  string `json:"binding_guid,omitempty"`
+	Action       string `json:"action"`
+	State        string `json:"state"`
+	Description  string `json:"description"`
+	Deadline     string `json:"deadline,omitempty"`
+}
+
+// The kinds of resources that can be orphaned.
+const (
+	OrphanInstance = "instance"
+	OrphanBinding  = "binding"
+)
+
+// Orphan records a resource that a plan created but that could neither be
+// persisted nor cleaned up, for example a Stardog database whose service
+// instance could not be saved.  PlanParams are the values returned by the
+// plan and describe what was leaked.  Orphans must be removed by an
+// operator.
+type Orphan struct {
+	OrphanID     string      `json:"orphan_id"`
+	Kind         string      `json:"kind"`
+	InstanceGUID string      `json:"instance_guid"`
+	BindingGUID  string      `json:"binding_guid,omitempty"`
+	PlanID       string      `json:"plan_id"`
+	PlanParams   interface{} `json:"plan_params"`
+	Reason       string      `json:"reason"`
+	Created      string      `json:"created"`
+}
+
+// OrphansByCreated sorts orphans from the oldest to the newest.
+type OrphansByCreated []*Orphan
+
+func (o OrphansByCreated) Len() int           { return len(o) }
+func (o OrphansByCreated) Less(i, j int) bool { return o[i].Created < o[j].Created }
+func (o OrphansByCreated) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+
+// OrphansResponse is the document returned by the admin API that lists
+// the orphaned resources.
+type OrphansResponse struct {
+	Orphans []*Orphan `json:"orphans"`
 }
 
 // DatabaseCredentials is a convenience object for passing around the
@@ -150,14 +303,162 @@ type DatabaseCredentials struct {
 // ServerConfig the configuration document that is passed to the broker
 // when it is started.  It contains plan and storage information.
 type ServerConfig struct {
-	Port           string        `json:"port"`
-	Plans          []PlanConfig  `json:"plans"`
-	Storage        StorageConfig `json:"storage"`
-	BrokerUsername string        `json:"broker_username"`
-	BrokerPassword string        `json:"broker_password"`
-	BrokerID       string        `json:"broker_id"`
-	LogLevel       string        `json:"log_level"`
-	LogFile        string        `json:"log_file"`
+	Port                    string               `json:"port"`
+	Plans                   []PlanConfig         `json:"plans"`
+	Storage                 StorageConfig        `json:"storage"`
+	BrokerUsername          string               `json:"broker_username"`
+	BrokerPassword          string               `json:"broker_password"`
+	BrokerID                string               `json:"broker_id"`
+	LogLevel                string               `json:"log_level"`
+	LogFile                 string               `json:"log_file"`
+	MinAPIVersion           string               `json:"min_api_version"`
+	Services                []ServiceConfig      `json:"services"`
+	Encryption              *EncryptionConfig    `json:"encryption,omitempty"`
+	SecretStore             *SecretStoreConfig   `json:"secret_store,omitempty"`
+	Credentials             []BrokerCredential   `json:"credentials,omitempty"`
+	TLS                     *TLSConfig           `json:"tls,omitempty"`
+	RateLimit               *RateLimitConfig     `json:"rate_limit,omitempty"`
+	StardogClient           *StardogClientConfig `json:"stardog_client,omitempty"`
+	OperationTimeoutSeconds int                  `json:"operation_timeout_seconds,omitempty"`
+}
+
+// RateLimitConfig tunes the protection of the broker API.  A client that
+// fails to authenticate MaxAuthFailures times within LockoutSeconds is
+// locked out for LockoutSeconds.  When TrustForwardedFor is set clients
+// are told apart by the X-Forwarded-For header set by a router in front of
+// the broker.  RequestsPerSecond, with bursts of up to Burst requests,
+// limits the requests that provision, update, deprovision, bind and
+// unbind that each broker username makes, and is not limited when it is
+// 0.
+type RateLimitConfig struct {
+	MaxAuthFailures   int     `json:"max_auth_failures,omitempty"`
+	LockoutSeconds    int     `json:"lockout_seconds,omitempty"`
+	TrustForwardedFor bool    `json:"trust_forwarded_for,omitempty"`
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+	Burst             int     `json:"burst,omitempty"`
+}
+
+// StardogClientConfig tunes the connections that the broker makes to
+// Stardog servers.  ConnectTimeoutSeconds limits how long connecting may
+// take and RequestTimeoutSeconds limits each request, including reading
+// the response.  MaxIdleConnsPerHost is the number of connections to each
+// server that are kept open to be reused.
+type StardogClientConfig struct {
+	ConnectTimeoutSeconds int `json:"connect_timeout_seconds,omitempty"`
+	RequestTimeoutSeconds int `json:"request_timeout_seconds,omitempty"`
+	MaxIdleConnsPerHost   int `json:"max_idle_conns_per_host,omitempty"`
+}
+
+// RetryPolicy controls how a plan retries requests to Stardog that failed
+// with a network error or a 5xx response.  The delay before a retry starts
+// at InitialIntervalMillis and doubles up to MaxIntervalMillis.  No retry
+// is started once MaxElapsedSeconds have passed.  A MaxAttempts of 1 turns
+// retries off.
+type RetryPolicy struct {
+	MaxAttempts           int `json:"max_attempts,omitempty"`
+	InitialIntervalMillis int `json:"initial_interval_ms,omitempty"`
+	MaxIntervalMillis     int `json:"max_interval_ms,omitempty"`
+	MaxElapsedSeconds     int `json:"max_elapsed_seconds,omitempty"`
+}
+
+// DatabaseOptionsPolicy lists the Stardog database options that clients
+// may set on the databases of a plan and the values that new databases
+// get when the client does not set them.  Any option can be set when
+// Allowed is empty.
+type DatabaseOptionsPolicy struct {
+	Allowed  []string               `json:"allowed,omitempty"`
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+}
+
+// PasswordPolicy describes the passwords that a plan generates for bound
+// users.  CharacterClasses holds any of lowercase, uppercase, digits and
+// symbols, and defaults to the first three.  Characters listed in
+// ExcludeCharacters, such as ones that are easily confused, are never
+// used.
+type PasswordPolicy struct {
+	Length            int      `json:"length,omitempty"`
+	CharacterClasses  []string `json:"character_classes,omitempty"`
+	ExcludeCharacters string   `json:"exclude_characters,omitempty"`
+}
+
+// StardogTLSConfig holds the TLS settings used to connect to a Stardog
+// server.  CAFile is a PEM bundle of the CAs that sign the server
+// certificate, which otherwise must be signed by a system CA.  CertFile and
+// KeyFile are the client certificate presented to servers that require
+// one.  InsecureSkipVerify turns off server verification and is only meant
+// for development.
+type StardogTLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// TLSConfig turns on TLS for the broker server.  The certificate, key and
+// client CAs are loaded again when the files change.  MinVersion is one of
+// 1.0, 1.1, 1.2 or 1.3 and defaults to 1.2.  When ClientCAFile is set a
+// client certificate that platforms present must be signed by one of its
+// CAs, and one must be presented when RequireClientCert is set.  A client
+// certificate does not replace the broker credentials.
+type TLSConfig struct {
+	CertFile          string `json:"cert_file"`
+	KeyFile           string `json:"key_file"`
+	MinVersion        string `json:"min_version"`
+	ClientCAFile      string `json:"client_ca_file"`
+	RequireClientCert bool   `json:"require_client_cert"`
+}
+
+// BrokerCredential is a username and bcrypt password hash that platforms
+// can use to authenticate to the broker.  Expires is an optional RFC 3339
+// time after which the credential is no longer accepted.  Platform is an
+// optional label that is logged with the requests made with it.
+type BrokerCredential struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+	Expires      string `json:"expires,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+}
+
+// SecretStoreConfig describes where binding credentials are kept.  When
+// ReferenceInResponse is set the bind response only refers to the
+// credentials so that the platform must resolve them.
+type SecretStoreConfig struct {
+	Type                string      `json:"type"`
+	Parameters          interface{} `json:"parameters"`
+	ReferenceInResponse bool        `json:"reference_in_response"`
+}
+
+// EncryptionConfig holds the keys used to encrypt the plan parameters kept
+// by the Store.  New records are encrypted with the key named by
+// PrimaryKeyID, or the first key when it is not set.  The other keys are
+// only used to read records written before a key rotation.
+type EncryptionConfig struct {
+	PrimaryKeyID string          `json:"primary_key_id"`
+	Keys         []EncryptionKey `json:"keys"`
+}
+
+// EncryptionKey is one AES-256 key.  The base64 encoded key is taken from
+// Key, the environment variable named by KeyEnv or the file named by
+// KeyFile, in that order.
+type EncryptionKey struct {
+	ID      string `json:"id"`
+	Key     string `json:"key,omitempty"`
+	KeyEnv  string `json:"key_env,omitempty"`
+	KeyFile string `json:"key_file,omitempty"`
+}
+
+// ServiceConfig describes one of the services offered in the catalog.  The
+// plans it offers are listed by the IDs given to them in the plans section
+// of the ServerConfig.
+type ServiceConfig struct {
+	Name        string          `json:"name"`
+	ID          string          `json:"id"`
+	Description string          `json:"description"`
+	Tags        []string        `json:"tags"`
+	Requires    []string        `json:"requires"`
+	Metadata    CatalogMetadata `json:"metadata"`
+	PlanIDs     []string        `json:"plan_ids"`
 }
 
 // PlanConfig contains the configuration information for a given plan.  The
@@ -0,0 +1,149 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// The name of a database is chosen by the broker so it cannot be set as
+// an option.
+const databaseNameOption = "database.name"
+
+var optionNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,127}$`)
+
+// DatabaseOptions checks the Stardog database options that clients send
+// against the policy of a plan.
+type DatabaseOptions struct {
+	allowed  map[string]bool
+	defaults map[string]interface{}
+}
+
+// NewDatabaseOptions makes a DatabaseOptions that follows policy.  A nil
+// policy allows any option and has no defaults.
+func NewDatabaseOptions(policy *DatabaseOptionsPolicy) (*DatabaseOptions, error) {
+	o := &DatabaseOptions{}
+	if policy == nil {
+		return o, nil
+	}
+	if len(policy.Allowed) > 0 {
+		o.allowed = make(map[string]bool, len(policy.Allowed))
+		for _, name := range policy.Allowed {
+			err := validateOptionName(name)
+			if err != nil {
+				return nil, err
+			}
+			o.allowed[name] = true
+		}
+	}
+	err := o.Validate(policy.Defaults)
+	if err != nil {
+		return nil, fmt.Errorf("The default database options are not valid: %s", err)
+	}
+	o.defaults = policy.Defaults
+	return o, nil
+}
+
+func validateOptionName(name string) error {
+	if !optionNamePattern.MatchString(name) {
+		return NewBadRequestError("The database option %q is not valid.  It must start with a letter followed by up to 127 letters, digits, dashes, underscores and dots", name)
+	}
+	if name == databaseNameOption {
+		return NewBadRequestError("The database option %s cannot be set", name)
+	}
+	return nil
+}
+
+// Validate checks that every option may be set and has a value that
+// Stardog understands, which is a string, number, boolean or a list of
+// strings.
+func (o *DatabaseOptions) Validate(options map[string]interface{}) error {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	// Sorted so that the same options always give the same error
+	sort.Strings(names)
+	for _, name := range names {
+		err := validateOptionName(name)
+		if err != nil {
+			return err
+		}
+		if o.allowed != nil && !o.allowed[name] {
+			return NewBadRequestError("The database option %s is not allowed by the plan", name)
+		}
+		if !isOptionValue(options[name]) {
+			return NewBadRequestError("The value of the database option %s must be a string, number, boolean or list of strings", name)
+		}
+	}
+	return nil
+}
+
+// isOptionValue checks the type of an option value.  Values decoded from
+// JSON are float64, but defaults read from YAML can be integers.
+func isOptionValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string, bool, float64, int, int64:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			_, ok := item.(string)
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// CreateOptions validates the options that a client sent when creating an
+// instance and returns them along with the defaults of the plan that they
+// do not override.
+func (o *DatabaseOptions) CreateOptions(options map[string]interface{}) (map[string]interface{}, error) {
+	err := o.Validate(options)
+	if err != nil {
+		return nil, err
+	}
+	return MergeOptions(o.defaults, options), nil
+}
+
+// AddToSchemas lists the options that may be set in the database_options
+// parameter of the instance create and update schemas of a plan so that
+// clients can see them in the catalog.
+func (o *DatabaseOptions) AddToSchemas(schemas *PlanSchemas) {
+	if o.allowed == nil {
+		return
+	}
+	for _, s := range []*InputParametersSchema{schemas.InstanceCreateSchema(), schemas.InstanceUpdateSchema()} {
+		if s == nil {
+			continue
+		}
+		properties, _ := s.Parameters["properties"].(map[string]interface{})
+		schema, ok := properties["database_options"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		allowed := make(map[string]interface{}, len(o.allowed))
+		for name := range o.allowed {
+			allowed[name] = map[string]interface{}{}
+		}
+		schema["properties"] = allowed
+		schema["additionalProperties"] = false
+	}
+}
@@ -0,0 +1,45 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"testing"
+)
+
+func TestIntegerDefaultOptions(t *testing.T) {
+	// YAML decodes whole numbers to int rather than float64
+	o, err := NewDatabaseOptions(&DatabaseOptionsPolicy{
+		Allowed:  []string{"query.timeout", "index.literals.canonical"},
+		Defaults: map[string]interface{}{"query.timeout": 60, "index.literals.canonical": int64(1)},
+	})
+	if err != nil {
+		t.Fatalf("Integer defaults should be accepted: %s", err)
+	}
+	options, err := o.CreateOptions(map[string]interface{}{"query.timeout": float64(30)})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if options["query.timeout"] != float64(30) || options["index.literals.canonical"] != int64(1) {
+		t.Fatalf("The client should override the default %v", options)
+	}
+
+	_, err = NewDatabaseOptions(&DatabaseOptionsPolicy{
+		Defaults: map[string]interface{}{"query.timeout": []int{60}},
+	})
+	if err == nil {
+		t.Fatalf("A list of numbers should be rejected")
+	}
+}
@@ -0,0 +1,187 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// compensationAttempts is the number of times that undoing the work of a
+// plan is tried before the resource is recorded as an orphan.  The delay
+// between attempts doubles each time.
+const compensationAttempts = 3
+
+var compensationDelay = time.Second
+
+// compensate runs undo until it succeeds or compensationAttempts is
+// reached.  The last error is returned.  The undo functions do not use the
+// context of the request because cleaning up must go ahead even when the
+// request was cancelled.
+func (c *ControllerImpl) compensate(description string, undo func() error) error {
+	var err error
+	delay := compensationDelay
+	for i := 1; i <= compensationAttempts; i++ {
+		err = undo()
+		if err == nil {
+			c.logger.Logf(INFO, "Succeeded to %s", description)
+			return nil
+		}
+		c.logger.Logf(WARN, "Attempt %d to %s failed: %s", i, description, err)
+		if i < compensationAttempts {
+			time.Sleep(delay)
+			delay = delay * 2
+		}
+	}
+	return err
+}
+
+// recordOrphan saves a resource that could not be cleaned up so that
+// operators can find it.  If that fails too the details are logged so
+// that they are not lost.
+func (c *ControllerImpl) recordOrphan(orphan *Orphan) {
+	orphan.OrphanID = GetRandomName("orphan", 16)
+	orphan.Created = time.Now().UTC().Format(time.RFC3339)
+	err := c.store.AddOrphan(orphan)
+	if err != nil {
+		data, _ := json.Marshal(orphan)
+		c.logger.Logf(ERROR, "Failed to record the orphaned %s: %s.  Resources leaked: %s", orphan.Kind, err, string(data))
+		return
+	}
+	c.logger.Logf(ERROR, "Recorded the orphaned %s %s for instance %s", orphan.Kind, orphan.OrphanID, orphan.InstanceGUID)
+}
+
+// cleanUpInstance removes a service instance that the plan created but
+// that could not be persisted.
+func (c *ControllerImpl) cleanUpInstance(si *ServiceInstance, storeErr error) {
+	err := c.compensate(fmt.Sprintf("remove the unsaved instance %s", si.InstanceGUID), func() error {
+		_, _, err := si.Plan.RemoveInstance(context.Background())
+		return err
+	})
+	if err != nil {
+		c.recordOrphan(&Orphan{
+			Kind:         OrphanInstance,
+			InstanceGUID: si.InstanceGUID,
+			PlanID:       si.PlanID,
+			PlanParams:   si.InstanceParams,
+			Reason:       fmt.Sprintf("The instance could not be saved (%s) or removed (%s)", storeErr, err),
+		})
+	}
+}
+
+// cleanUpBinding undoes a bind that could not be persisted or whose record
+// was already deleted.
+func (c *ControllerImpl) cleanUpBinding(si *ServiceInstance, bindingGUID string, planParams interface{}, cause error) {
+	err := c.compensate(fmt.Sprintf("unbind %s from %s", bindingGUID, si.InstanceGUID), func() error {
+		_, err := si.Plan.UnBind(context.Background(), planParams)
+		return err
+	})
+	if err != nil {
+		c.recordOrphan(&Orphan{
+			Kind:         OrphanBinding,
+			InstanceGUID: si.InstanceGUID,
+			BindingGUID:  bindingGUID,
+			PlanID:       si.PlanID,
+			PlanParams:   planParams,
+			Reason:       fmt.Sprintf("%s and the binding could not be removed (%s)", cause, err),
+		})
+	}
+}
+
+// GetOrphans lists the resources that were leaked and must be cleaned up
+// by an operator.
+func (c *ControllerImpl) GetOrphans(w http.ResponseWriter, r *http.Request) {
+	c.logger.Logf(INFO, "Get Orphans called")
+	r, err := c.authenticate(w, r)
+	if err != nil {
+		c.logger.Logf(INFO, "Authorization failed %s", err)
+		return
+	}
+
+	orphans, err := c.store.GetAllOrphans()
+	if err != nil {
+		SendBrokerError(c.logger, w, err, http.StatusInternalServerError)
+		return
+	}
+	redacted := make([]*Orphan, 0, len(orphans))
+	for _, o := range orphans {
+		redacted = append(redacted, c.redactOrphan(o))
+	}
+	WriteResponse(w, http.StatusOK, &OrphansResponse{Orphans: redacted})
+}
+
+// bindingSecretParameters are the parameters of a binding that hold the
+// credentials of its user.
+var bindingSecretParameters = []string{"password"}
+
+// redactOrphan returns a copy of an orphan without the secrets in its
+// parameters.  The rest of them, such as the database and username, are
+// kept since the operator needs them to find the leaked resource.
+func (c *ControllerImpl) redactOrphan(o *Orphan) *Orphan {
+	redacted := *o
+	secrets := bindingSecretParameters
+	if o.Kind == OrphanInstance {
+		secrets = c.secretParameters(o.PlanID)
+	}
+	params, err := removeParams(o.PlanParams, secrets)
+	if err != nil {
+		c.logger.Logf(WARN, "The parameters of the orphan %s could not be read: %s", o.OrphanID, err)
+		params = nil
+	}
+	redacted.PlanParams = params
+	return &redacted
+}
+
+// removeParams returns a copy of the parameters without the ones listed in
+// names.
+func removeParams(planParams interface{}, names []string) (map[string]interface{}, error) {
+	var params map[string]interface{}
+	err := ReSerializeInterface(planParams, &params)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		delete(params, name)
+	}
+	return params, nil
+}
+
+// DeleteOrphan removes an orphan from the list once an operator has
+// cleaned up the resource.
+func (c *ControllerImpl) DeleteOrphan(w http.ResponseWriter, r *http.Request) {
+	c.logger.Logf(INFO, "Delete Orphan called")
+	r, err := c.authenticate(w, r)
+	if err != nil {
+		c.logger.Logf(INFO, "Authorization failed %s", err)
+		return
+	}
+
+	orphanID, err := GetRouteVariable(r, "orphan_id")
+	if err != nil {
+		SendBrokerError(c.logger, w, err, http.StatusBadRequest)
+		return
+	}
+	err = c.store.DeleteOrphan(orphanID)
+	if err != nil {
+		c.sendLookupError(w, err, http.StatusNotFound, fmt.Sprintf("The orphan %s was not found", orphanID))
+		return
+	}
+	c.logger.Logf(INFO, "Removed the orphan %s", orphanID)
+	WriteResponse(w, http.StatusOK, &struct{}{})
+}
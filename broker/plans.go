@@ -15,9 +15,12 @@
 
 package broker
 
+import "context"
+
 // PlanFactory holds the information needed to create a plan instance. When
 // the instance is new MakePlan is used.  To inflate an existing instance
-// InflatePlan is used.
+// InflatePlan is used.  Schemas returns the JSON Schemas for the plan's
+// parameters or nil if the plan does not publish any.
 type PlanFactory interface {
 	PlanName() string
 	PlanDescription() string
@@ -25,17 +28,51 @@ type PlanFactory interface {
 	Metadata() interface{}
 	Free() bool
 	Bindable() bool
+	Schemas() *PlanSchemas
 	InflatePlan(interface{}, StardogClientFactory, SdLogger) (Plan, error)
 }
 
 // Plan represents a Plan that is associated with the service instance and
-// application bindings.
+// application bindings.  The RequestContext passed to CreateServiceInstance
+// and Bind describes the platform tenant and user that made the request.
+// The context.Context is cancelled when the work is no longer wanted, such
+// as when the platform gives up on a synchronous request.
 type Plan interface {
-	CreateServiceInstance() (int, interface{}, error)
-	RemoveInstance() (int, interface{}, error)
-	Bind(interface{}) (int, interface{}, error)
-	UnBind(interface{}) (int, error)
+	CreateServiceInstance(context.Context, *RequestContext) (int, interface{}, error)
+	RemoveInstance(context.Context) (int, interface{}, error)
+	Bind(context.Context, *RequestContext, interface{}) (int, interface{}, error)
+	UnBind(context.Context, interface{}) (int, error)
 	PlanID() string
 	EqualInstance(interface{}) bool
 	EqualBinding(*BindInstance, *BindRequest) bool
 }
+
+// UpdatablePlan is an optional interface for plans that allow an existing
+// service instance to be modified.  UpdateServiceInstance applies the
+// parameters from the client and returns the instance parameters that
+// should be persisted.  CanMoveTo reports if an instance of this plan can
+// be moved to a plan created by the given factory.
+type UpdatablePlan interface {
+	UpdateServiceInstance(context.Context, interface{}) (int, interface{}, error)
+	CanMoveTo(PlanFactory) bool
+}
+
+// UpdatablePlanFactory is implemented by plan factories whose plans are
+// UpdatablePlans.  It is used to advertise plan_updateable in the catalog.
+type UpdatablePlanFactory interface {
+	PlanUpdateable() bool
+}
+
+// SecretParametersPlanFactory is an optional interface for plan factories
+// whose persisted instance parameters include secrets, such as the
+// credentials of a Stardog server.  SecretParameters returns the names of
+// those parameters so that they are never sent back to the client.
+type SecretParametersPlanFactory interface {
+	SecretParameters() []string
+}
+
+// DashboardPlan is an optional interface for plans that have a web page
+// where the service instance can be managed.
+type DashboardPlan interface {
+	DashboardURL() string
+}
@@ -0,0 +1,286 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxAuthFailures is the number of failed authentications from
+	// one client after which it is locked out.
+	DefaultMaxAuthFailures = 10
+	// DefaultLockoutSeconds is how long a client is locked out for, and
+	// also how long its failed authentications are remembered.
+	DefaultLockoutSeconds = 300
+)
+
+type authFailures struct {
+	count       int
+	first       time.Time
+	lockedUntil time.Time
+}
+
+// authThrottle counts the failed authentications of each client and locks
+// out the clients that fail too often so that broker passwords cannot be
+// guessed without limit.  A client is an address and the username that it
+// sent, so that one platform failing with a stale password neither locks
+// out nor is cleared by another platform behind the same address.
+type authThrottle struct {
+	maxFailures int
+	lockout     time.Duration
+	trustProxy  bool
+	logger      SdLogger
+	clients     map[string]*authFailures
+	lastPrune   time.Time
+	lock        sync.Mutex
+	now         func() time.Time
+}
+
+func newAuthThrottle(conf *RateLimitConfig, logger SdLogger) *authThrottle {
+	t := &authThrottle{
+		maxFailures: DefaultMaxAuthFailures,
+		lockout:     DefaultLockoutSeconds * time.Second,
+		logger:      logger,
+		clients:     make(map[string]*authFailures),
+		now:         time.Now,
+	}
+	if conf != nil {
+		if conf.MaxAuthFailures != 0 {
+			t.maxFailures = conf.MaxAuthFailures
+		}
+		if conf.LockoutSeconds != 0 {
+			t.lockout = time.Duration(conf.LockoutSeconds) * time.Second
+		}
+		t.trustProxy = conf.TrustForwardedFor
+	}
+	return t
+}
+
+// clientAddress returns the address that failures are counted against.
+// Behind a router that sets X-Forwarded-For the last address in it is the
+// one that connected to the router.  Earlier addresses are ignored since
+// the client can send anything in them.
+func (t *authThrottle) clientAddress(r *http.Request) string {
+	if t.trustProxy {
+		forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		addr := strings.TrimSpace(forwarded[len(forwarded)-1])
+		if addr != "" {
+			return addr
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientKey returns the client that failures are counted against.
+func (t *authThrottle) clientKey(r *http.Request) string {
+	username, _, _ := r.BasicAuth()
+	return t.clientAddress(r) + " " + strconv.Quote(username)
+}
+
+// lockedOut returns how much longer a client is locked out for.
+func (t *authThrottle) lockedOut(client string) time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	f, ok := t.clients[client]
+	if !ok {
+		return 0
+	}
+	remaining := f.lockedUntil.Sub(t.now())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func (t *authThrottle) recordFailure(client string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	t.prune(now)
+	f, ok := t.clients[client]
+	if !ok || now.Sub(f.first) > t.lockout {
+		f = &authFailures{first: now}
+		t.clients[client] = f
+	}
+	f.count++
+	if f.count >= t.maxFailures {
+		f.lockedUntil = now.Add(t.lockout)
+		t.logger.Logf(WARN, "Locking out %s for %s after %d failed authentications", client, t.lockout, f.count)
+	}
+}
+
+func (t *authThrottle) recordSuccess(client string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.clients, client)
+}
+
+// prune forgets the clients whose failures and lockout have expired.  It
+// runs at most once per lockout period.
+func (t *authThrottle) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < t.lockout {
+		return
+	}
+	t.lastPrune = now
+	for client, f := range t.clients {
+		if now.Sub(f.first) > t.lockout && now.After(f.lockedUntil) {
+			delete(t.clients, client)
+		}
+	}
+}
+
+// statusRecorder remembers the status that a handler sent.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// authThrottleHandler rejects requests from locked out clients with 429 and
+// counts the requests that the handlers answer with 401.  The failures of a
+// client are forgotten once it authenticates with the same username.
+func authThrottleHandler(t *authThrottle, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := t.clientKey(r)
+		remaining := t.lockedOut(client)
+		if remaining > 0 {
+			t.logger.Logf(INFO, "Rejected %s %s from the locked out client %s", r.Method, r.URL.Path, client)
+			sendTooManyRequests(t.logger, w, remaining, "Too many failed authentications.  Try again later")
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		// Only a successful response shows that the client authenticated
+		// since requests can be rejected before their credentials are
+		// checked
+		if rec.status == http.StatusUnauthorized {
+			t.recordFailure(client)
+		} else if rec.status >= 200 && rec.status < 300 {
+			t.recordSuccess(client)
+		}
+	})
+}
+
+// rateLimiter limits how often requests that do work on the Stardog
+// servers are accepted.  Each broker credential has its own token bucket
+// so that one platform cannot use up the requests of the others.
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	logger  SdLogger
+	lock    sync.Mutex
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns nil when the configuration does not set a rate.
+func newRateLimiter(conf *RateLimitConfig, logger SdLogger) (*rateLimiter, error) {
+	if conf == nil || conf.RequestsPerSecond == 0 {
+		return nil, nil
+	}
+	if conf.RequestsPerSecond < 0 || conf.Burst < 0 {
+		return nil, fmt.Errorf("The request rate and burst cannot be negative")
+	}
+	burst := float64(conf.Burst)
+	if burst == 0 {
+		burst = math.Max(1, math.Ceil(conf.RequestsPerSecond))
+	}
+	return &rateLimiter{
+		rate:    conf.RequestsPerSecond,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+		logger:  logger,
+		now:     time.Now,
+	}, nil
+}
+
+// take uses up a token from the bucket of key if one is available.
+// Otherwise it returns how long it will be until the next one is.
+func (l *rateLimiter) take(key string) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// allow charges an authenticated request to the bucket of the broker
+// credential that it used and sends a 429 if the bucket is empty.  The
+// request must only be charged once its credentials have been checked so
+// that clients without them cannot use up the tokens of the platforms.  A
+// nil rateLimiter allows everything.
+func (l *rateLimiter) allow(w http.ResponseWriter, r *http.Request) bool {
+	if l == nil {
+		return true
+	}
+	username, _, _ := r.BasicAuth()
+	wait := l.take(username)
+	if wait > 0 {
+		l.logger.Logf(WARN, "Rate limited %s %s from %s", r.Method, r.URL.Path, username)
+		sendTooManyRequests(l.logger, w, wait, "Too many requests.  Try again later")
+		return false
+	}
+	return true
+}
+
+func sendTooManyRequests(logger SdLogger, w http.ResponseWriter, retryAfter time.Duration, desc string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	SendError(logger, w, http.StatusTooManyRequests, desc)
+}
@@ -0,0 +1,198 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestAuthThrottleLocksOut(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	throttle := newAuthThrottle(&RateLimitConfig{MaxAuthFailures: 3, LockoutSeconds: 60}, getLogger(t))
+	throttle.now = clock.now
+	status := http.StatusUnauthorized
+	handler := authThrottleHandler(throttle, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	send := func(remoteAddr string) int {
+		r := httptest.NewRequest("GET", "/v2/catalog", nil)
+		r.RemoteAddr = remoteAddr
+		r.SetBasicAuth("cf", "password")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if send("10.0.0.1:1234") != http.StatusUnauthorized {
+			t.Fatalf("The failure %d should have reached the handler", i)
+		}
+	}
+	status = http.StatusOK
+	if send("10.0.0.1:1234") != http.StatusTooManyRequests {
+		t.Fatalf("The client should be locked out after 3 failures")
+	}
+	if send("10.0.0.2:1234") != http.StatusOK {
+		t.Fatalf("Another client should not be locked out")
+	}
+
+	clock.t = clock.t.Add(61 * time.Second)
+	if send("10.0.0.1:1234") != http.StatusOK {
+		t.Fatalf("The lockout should have expired")
+	}
+	status = http.StatusUnauthorized
+	send("10.0.0.1:1234")
+	send("10.0.0.1:1234")
+	if throttle.lockedOut(`10.0.0.1 "cf"`) != 0 {
+		t.Fatalf("A successful request should clear the failures of the client")
+	}
+}
+
+func TestAuthThrottlePerUsername(t *testing.T) {
+	throttle := newAuthThrottle(&RateLimitConfig{MaxAuthFailures: 3, LockoutSeconds: 60}, getLogger(t))
+	handler := authThrottleHandler(throttle, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _, _ := r.BasicAuth()
+		if username != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	send := func(username string) int {
+		r := httptest.NewRequest("GET", "/v2/catalog", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.SetBasicAuth(username, "password")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	send("stale")
+	send("stale")
+	// Another platform behind the same router authenticates
+	if send("good") != http.StatusOK {
+		t.Fatalf("The other username should be accepted")
+	}
+	send("stale")
+	if send("stale") != http.StatusTooManyRequests {
+		t.Fatalf("A success with another username should not clear the failures")
+	}
+	if send("good") != http.StatusOK {
+		t.Fatalf("The lockout of one username should not lock out the others")
+	}
+}
+
+func TestAuthThrottleForwardedFor(t *testing.T) {
+	throttle := newAuthThrottle(&RateLimitConfig{TrustForwardedFor: true}, getLogger(t))
+	r := httptest.NewRequest("GET", "/v2/catalog", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
+	if throttle.clientAddress(r) != "5.6.7.8" {
+		t.Fatalf("The last forwarded address should be used but got %s", throttle.clientAddress(r))
+	}
+	throttle.trustProxy = false
+	if throttle.clientAddress(r) != "10.0.0.1" {
+		t.Fatalf("The forwarded address should be ignored but got %s", throttle.clientAddress(r))
+	}
+}
+
+func TestRateLimiterPerCredential(t *testing.T) {
+	limiter, err := newRateLimiter(&RateLimitConfig{RequestsPerSecond: 1, Burst: 2}, getLogger(t))
+	if err != nil {
+		t.Fatalf("Failed to make the limiter %s", err)
+	}
+	clock := &fakeClock{t: time.Now()}
+	limiter.now = clock.now
+	allow := func(username string) bool {
+		r := httptest.NewRequest("PUT", "/v2/service_instances/x", nil)
+		r.SetBasicAuth(username, "password")
+		return limiter.allow(httptest.NewRecorder(), r)
+	}
+
+	if !allow("platform1") || !allow("platform1") {
+		t.Fatalf("The burst should be allowed")
+	}
+	if allow("platform1") {
+		t.Fatalf("The request over the burst should be limited")
+	}
+	if !allow("platform2") {
+		t.Fatalf("Another credential should have its own bucket")
+	}
+	clock.t = clock.t.Add(time.Second)
+	if !allow("platform1") {
+		t.Fatalf("A token should have been added after a second")
+	}
+	if allow("platform1") {
+		t.Fatalf("Only one token should have been added")
+	}
+
+	var nilLimiter *rateLimiter
+	if !nilLimiter.allow(httptest.NewRecorder(), httptest.NewRequest("PUT", "/", nil)) {
+		t.Fatalf("A nil limiter should allow everything")
+	}
+	_, err = newRateLimiter(&RateLimitConfig{RequestsPerSecond: -1}, getLogger(t))
+	if err == nil {
+		t.Fatalf("A negative rate should be rejected")
+	}
+}
+
+func TestRateLimitAfterAuthentication(t *testing.T) {
+	conf := &ServerConfig{
+		BrokerUsername: "user",
+		BrokerPassword: "pw",
+		RateLimit:      &RateLimitConfig{RequestsPerSecond: 1, Burst: 1},
+	}
+	auth, err := NewAuthenticator(conf)
+	if err != nil {
+		t.Fatalf("Failed to make the authenticator %s", err)
+	}
+	limiter, err := newRateLimiter(conf.RateLimit, getLogger(t))
+	if err != nil {
+		t.Fatalf("Failed to make the limiter %s", err)
+	}
+	c := &ControllerImpl{logger: getLogger(t), auth: auth, limiter: limiter}
+	send := func(username string, password string) int {
+		r := httptest.NewRequest("DELETE", "/v2/service_instances/x", nil)
+		r.SetBasicAuth(username, password)
+		w := httptest.NewRecorder()
+		r, err := c.authenticate(w, r)
+		if err == nil && c.limiter.allow(w, r) {
+			w.WriteHeader(http.StatusOK)
+		}
+		return w.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		if send("user", "wrong") != http.StatusUnauthorized {
+			t.Fatalf("A bad password should be rejected")
+		}
+	}
+	if send("user", "pw") != http.StatusOK {
+		t.Fatalf("Failed authentications should not use up the tokens of the platform")
+	}
+	if send("user", "pw") != http.StatusTooManyRequests {
+		t.Fatalf("The second request should be limited")
+	}
+}
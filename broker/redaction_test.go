@@ -0,0 +1,96 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker_test
+
+// These tests use the real plans, which import the broker, so they are in
+// an external test package.
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stardog-union/service-broker/broker"
+	"github.com/stardog-union/service-broker/plans/perinstance"
+	"github.com/stardog-union/service-broker/store/memory"
+)
+
+func TestGetServiceInstanceRedactsPerInstanceCredentials(t *testing.T) {
+	logger, err := broker.NewSdLogger(log.New(os.Stderr, "", log.Ldate|log.Ltime), "DEBUG")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	planFactory, err := perinstance.GetPlanFactory("perinstance", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	store := memory.NewInMemoryStore(logger)
+	err = store.AddInstance("inst1", &broker.ServiceInstance{
+		InstanceGUID: "inst1",
+		PlanID:       "perinstance",
+		ServiceID:    "brokerid",
+		InstanceParams: map[string]interface{}{
+			"db_name":  "db",
+			"url":      "http://stardog.internal:5820",
+			"username": "admin",
+			"password": "adminpw",
+		},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	conf := &broker.ServerConfig{BrokerID: "brokerid", BrokerUsername: "user", BrokerPassword: "pw"}
+	controller, err := broker.CreateController(map[string]broker.PlanFactory{"perinstance": planFactory}, conf, broker.NewClientFactory(logger, nil), logger, store, nil)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/v2/service_instances/{service_instance_GUID}", controller.GetServiceInstance).Methods("GET")
+
+	r := httptest.NewRequest("GET", "/v2/service_instances/inst1", nil)
+	r.SetBasicAuth("user", "pw")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to get the instance %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Parameters map[string]interface{} `json:"parameters"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if _, ok := resp.Parameters["password"]; ok {
+		t.Fatalf("The admin password of the instance was returned %v", resp.Parameters)
+	}
+	if _, ok := resp.Parameters["username"]; ok {
+		t.Fatalf("The admin username of the instance was returned %v", resp.Parameters)
+	}
+	if resp.Parameters["db_name"] != "db" {
+		t.Fatalf("The parameters that are not secret should be returned %v", resp.Parameters)
+	}
+
+	// The stored instance keeps its credentials
+	si, err := store.GetInstance("inst1")
+	if err != nil || si.InstanceParams.(map[string]interface{})["password"] != "adminpw" {
+		t.Fatalf("Redacting the response changed the stored instance: %v", err)
+	}
+}
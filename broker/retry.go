@@ -0,0 +1,154 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetryAttempts        = 5
+	defaultRetryInitialInterval = 250 * time.Millisecond
+	defaultRetryMaxInterval     = 5 * time.Second
+	defaultRetryMaxElapsed      = 30 * time.Second
+)
+
+// Retrier repeats requests to Stardog that failed for reasons that may
+// not last, such as a connection being reset or a 5xx response.  The delay
+// before each retry grows exponentially and only a random part of it is
+// used so that brokers that failed together do not retry together.
+type Retrier struct {
+	attempts        int
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	maxElapsed      time.Duration
+}
+
+// NewRetrier makes a Retrier that follows policy.  A nil policy uses the
+// defaults.
+func NewRetrier(policy *RetryPolicy) (*Retrier, error) {
+	r := newDefaultRetrier()
+	if policy == nil {
+		return r, nil
+	}
+	if policy.MaxAttempts < 0 || policy.InitialIntervalMillis < 0 || policy.MaxIntervalMillis < 0 || policy.MaxElapsedSeconds < 0 {
+		return nil, fmt.Errorf("The retry policy settings cannot be negative")
+	}
+	if policy.MaxAttempts != 0 {
+		r.attempts = policy.MaxAttempts
+	}
+	if policy.InitialIntervalMillis != 0 {
+		r.initialInterval = time.Duration(policy.InitialIntervalMillis) * time.Millisecond
+	}
+	if policy.MaxIntervalMillis != 0 {
+		r.maxInterval = time.Duration(policy.MaxIntervalMillis) * time.Millisecond
+	}
+	if policy.MaxElapsedSeconds != 0 {
+		r.maxElapsed = time.Duration(policy.MaxElapsedSeconds) * time.Second
+	}
+	if r.initialInterval > r.maxInterval {
+		return nil, fmt.Errorf("The retry initial_interval_ms cannot be more than max_interval_ms")
+	}
+	return r, nil
+}
+
+func newDefaultRetrier() *Retrier {
+	return &Retrier{
+		attempts:        defaultRetryAttempts,
+		initialInterval: defaultRetryInitialInterval,
+		maxInterval:     defaultRetryMaxInterval,
+		maxElapsed:      defaultRetryMaxElapsed,
+	}
+}
+
+// run calls op until it succeeds, fails with an error that is not
+// transient, or the policy gives up.  The attempt number is passed to op
+// so that it can tell if an earlier attempt may already have done the
+// work.  The last error is returned.
+func (r *Retrier) run(ctx context.Context, logger SdLogger, description string, op func(attempt int) error) error {
+	start := time.Now()
+	interval := r.initialInterval
+	for attempt := 1; ; attempt++ {
+		err := op(attempt)
+		if err == nil {
+			if attempt > 1 {
+				logger.Logf(INFO, "Succeeded to %s on attempt %d", description, attempt)
+			}
+			return nil
+		}
+		if !isTransient(err) || ctx.Err() != nil {
+			if attempt > 1 {
+				logger.Logf(ERROR, "Failed to %s on attempt %d: %s", description, attempt, err)
+			}
+			return err
+		}
+		if attempt >= r.attempts {
+			logger.Logf(ERROR, "Giving up trying to %s after %d attempts: %s", description, attempt, err)
+			return err
+		}
+		delay := r.jitter(interval)
+		if time.Since(start)+delay > r.maxElapsed {
+			logger.Logf(ERROR, "Giving up trying to %s after %s: %s", description, time.Since(start), err)
+			return err
+		}
+		logger.Logf(WARN, "Attempt %d to %s failed, retrying in %s: %s", attempt, description, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		interval = interval * 2
+		if interval > r.maxInterval {
+			interval = r.maxInterval
+		}
+	}
+}
+
+// jitter picks a random delay of up to interval.
+func (r *Retrier) jitter(interval time.Duration) time.Duration {
+	ms, err := randomInt(int(interval/time.Millisecond) + 1)
+	if err != nil {
+		return interval
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// transientError is a failure to get a response from Stardog.  The request
+// may or may not have reached the server.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+// isTransient reports if a request that failed with err may succeed when
+// it is repeated.
+func isTransient(err error) bool {
+	switch e := err.(type) {
+	case *transientError:
+		return true
+	case *StardogError:
+		return e.Status >= http.StatusInternalServerError
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NewPlanSchemas builds the schemas that a plan publishes in the catalog
+// from JSON documents.  Any of the documents may be empty if the plan does
+// not take parameters for that request.
+func NewPlanSchemas(instanceCreate string, instanceUpdate string, bindingCreate string) (*PlanSchemas, error) {
+	var err error
+	schemas := &PlanSchemas{
+		ServiceInstance: &ServiceInstanceSchema{},
+		ServiceBinding:  &ServiceBindingSchema{},
+	}
+	schemas.ServiceInstance.Create, err = parseInputSchema(instanceCreate)
+	if err != nil {
+		return nil, fmt.Errorf("The instance create schema is not valid: %s", err)
+	}
+	schemas.ServiceInstance.Update, err = parseInputSchema(instanceUpdate)
+	if err != nil {
+		return nil, fmt.Errorf("The instance update schema is not valid: %s", err)
+	}
+	schemas.ServiceBinding.Create, err = parseInputSchema(bindingCreate)
+	if err != nil {
+		return nil, fmt.Errorf("The binding create schema is not valid: %s", err)
+	}
+	return schemas, nil
+}
+
+func parseInputSchema(doc string) (*InputParametersSchema, error) {
+	if doc == "" {
+		return nil, nil
+	}
+	var schema map[string]interface{}
+	err := json.Unmarshal([]byte(doc), &schema)
+	if err != nil {
+		return nil, err
+	}
+	return &InputParametersSchema{Parameters: schema}, nil
+}
+
+// InstanceCreateSchema returns the schema for the parameters used to
+// create an instance of the plan or nil if there is not one.
+func (s *PlanSchemas) InstanceCreateSchema() *InputParametersSchema {
+	if s == nil || s.ServiceInstance == nil {
+		return nil
+	}
+	return s.ServiceInstance.Create
+}
+
+// InstanceUpdateSchema returns the schema for the parameters used to
+// update an instance of the plan or nil if there is not one.
+func (s *PlanSchemas) InstanceUpdateSchema() *InputParametersSchema {
+	if s == nil || s.ServiceInstance == nil {
+		return nil
+	}
+	return s.ServiceInstance.Update
+}
+
+// BindingCreateSchema returns the schema for the parameters used to bind
+// to an instance of the plan or nil if there is not one.
+func (s *PlanSchemas) BindingCreateSchema() *InputParametersSchema {
+	if s == nil || s.ServiceBinding == nil {
+		return nil
+	}
+	return s.ServiceBinding.Create
+}
+
+// ValidateParameters checks the parameters sent by a client against a
+// schema.  It supports the subset of JSON Schema draft 4 that describes
+// plain JSON documents: type, properties, required, additionalProperties,
+// items, enum, pattern, minLength, maxLength, minimum and maximum.  An
+// error is returned for each field that does not conform.  Nothing is
+// checked when the schema is nil.
+func ValidateParameters(schema *InputParametersSchema, parameters interface{}) []ParameterError {
+	if schema == nil || schema.Parameters == nil {
+		return nil
+	}
+	if parameters == nil {
+		parameters = map[string]interface{}{}
+	}
+	// Parameters may arrive as a Go structure so they are normalized to
+	// the generic JSON form first.
+	var value interface{}
+	err := ReSerializeInterface(parameters, &value)
+	if err != nil {
+		return []ParameterError{{Field: "parameters", Description: "is not a JSON document"}}
+	}
+	var errs []ParameterError
+	validateValue(schema.Parameters, value, "parameters", &errs)
+	return errs
+}
+
+// SendParameterErrors sends a 400 to the client that lists the parameters
+// which failed validation.
+func SendParameterErrors(logger SdLogger, w http.ResponseWriter, errs []ParameterError) {
+	descs := make([]string, len(errs))
+	for i, e := range errs {
+		descs[i] = fmt.Sprintf("%s %s", e.Field, e.Description)
+	}
+	desc := fmt.Sprintf("The parameters are not valid: %s", strings.Join(descs, "; "))
+	logger.Logf(ERROR, "Sending the error message %d %s", http.StatusBadRequest, desc)
+	WriteResponse(w, http.StatusBadRequest, &ErrorMessageResponse{Error: ErrorBadRequest, Description: desc, InvalidParameters: errs})
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string, errs *[]ParameterError) {
+	addError := func(format string, a ...interface{}) {
+		*errs = append(*errs, ParameterError{Field: path, Description: fmt.Sprintf(format, a...)})
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		addError("must be of type %s", typeNames(t))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addError("must be one of %v", enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if min, ok := schema["minLength"].(float64); ok && float64(length) < min {
+			addError("must be at least %d characters long", int(min))
+		}
+		if max, ok := schema["maxLength"].(float64); ok && float64(length) > max {
+			addError("must be at most %d characters long", int(max))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil || !re.MatchString(v) {
+				addError("must match the pattern %s", pattern)
+			}
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			addError("must be at least %v", min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			addError("must be at most %v", max)
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, ok := v[name]; !ok {
+					*errs = append(*errs, ParameterError{Field: path + "." + name, Description: "is required"})
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if propSchema, ok := properties[k].(map[string]interface{}); ok {
+				validateValue(propSchema, v[k], path+"."+k, errs)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*errs = append(*errs, ParameterError{Field: path + "." + k, Description: "is not a known parameter"})
+				}
+			case map[string]interface{}:
+				validateValue(additional, v[k], path+"."+k, errs)
+			}
+		}
+	}
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch tv := t.(type) {
+	case string:
+		return matchesTypeName(tv, value)
+	case []interface{}:
+		for _, name := range tv {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case float64:
+		return name == "number" || (name == "integer" && v == math.Trunc(v))
+	case []interface{}:
+		return name == "array"
+	case map[string]interface{}:
+		return name == "object"
+	}
+	return false
+}
+
+func typeNames(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		s := make([]string, len(names))
+		for i, n := range names {
+			s[i] = fmt.Sprintf("%v", n)
+		}
+		return strings.Join(s, " or ")
+	}
+	return fmt.Sprintf("%v", t)
+}
+
+func jsonEqual(a interface{}, b interface{}) bool {
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(ab) == string(bb)
+}
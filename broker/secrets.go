@@ -0,0 +1,101 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"fmt"
+)
+
+// CredentialReferenceKey is the key under which a binding refers to
+// credentials kept in a SecretStore.  It is the key used by CredHub so
+// platforms that integrate with CredHub can resolve the credentials when
+// the application starts.
+const CredentialReferenceKey = "credhub-ref"
+
+// credentialName returns the CredHub style name under which the
+// credentials of a binding are kept.
+func (c *ControllerImpl) credentialName(si *ServiceInstance, bindingGUID string) string {
+	return fmt.Sprintf("/c/%s/%s/%s/credentials", c.BrokerID, si.ServiceID, bindingGUID)
+}
+
+// credentialReference returns the name of the credentials that the plan
+// parameters of a binding refer to or an empty string if the credentials
+// are kept in the parameters themselves.
+func credentialReference(planParams interface{}) string {
+	params, ok := planParams.(map[string]interface{})
+	if !ok || len(params) != 1 {
+		return ""
+	}
+	ref, _ := params[CredentialReferenceKey].(string)
+	return ref
+}
+
+// saveCredentials writes the credentials of a new binding to the
+// SecretStore and returns the reference that is persisted in their place.
+// The credentials are returned unchanged when there is no SecretStore.
+func (c *ControllerImpl) saveCredentials(ctx context.Context, si *ServiceInstance, bindingGUID string, credentials interface{}) (interface{}, error) {
+	if c.secretStore == nil {
+		return credentials, nil
+	}
+	name := c.credentialName(si, bindingGUID)
+	err := c.secretStore.SetCredentials(ctx, name, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to save the credentials of %s: %s", bindingGUID, err)
+	}
+	return map[string]interface{}{CredentialReferenceKey: name}, nil
+}
+
+// resolveBinding returns a copy of a stored binding with the credentials
+// read back from the SecretStore so that the plan can use them.
+func (c *ControllerImpl) resolveBinding(ctx context.Context, bi *BindInstance) (*BindInstance, error) {
+	ref := credentialReference(bi.PlanParams)
+	if ref == "" || c.secretStore == nil {
+		return bi, nil
+	}
+	credentials, err := c.secretStore.GetCredentials(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the credentials of %s: %s", bi.BindGUID, err)
+	}
+	resolved := *bi
+	resolved.PlanParams = credentials
+	return &resolved, nil
+}
+
+// deleteCredentials removes the credentials of a binding from the
+// SecretStore.  Failures are only logged because the binding is already
+// gone.  It does not take the context of the request so that the
+// credentials are removed even if the client gives up.
+func (c *ControllerImpl) deleteCredentials(si *ServiceInstance, bindingGUID string) {
+	if c.secretStore == nil {
+		return
+	}
+	name := c.credentialName(si, bindingGUID)
+	err := c.secretStore.DeleteCredentials(context.Background(), name)
+	if err != nil && !IsNotFound(err) {
+		c.logger.Logf(ERROR, "Failed to delete the credentials %s: %s", name, err)
+	}
+}
+
+// bindResponse makes the response that hands the credentials of a binding
+// to the client.  When configured to do so only the reference to them is
+// sent.
+func (c *ControllerImpl) bindResponse(storedParams interface{}, credentials interface{}) *BindResponse {
+	if c.credentialRefInResponse && credentialReference(storedParams) != "" {
+		return &BindResponse{Credentials: storedParams}
+	}
+	return &BindResponse{Credentials: credentials}
+}
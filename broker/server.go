@@ -16,6 +16,7 @@
 package broker
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -32,45 +33,59 @@ type Server struct {
 	listener    net.Listener
 	doneChannel chan error
 	logger      SdLogger
+	minVersion  APIVersion
+	tlsConfig   *tls.Config
+	throttle    *authThrottle
 }
 
-// CreateServer makes an instance of the BrokerServer.
-func CreateServer(databasePlanMap map[string]PlanFactory, conf *ServerConfig, clientFactory StardogClientFactory, logger SdLogger, store Store) (*Server, error) {
-	controller, err := CreateController(databasePlanMap, conf, clientFactory, logger, store)
+// CreateServer makes an instance of the BrokerServer.  secretStore may be
+// nil, in which case binding credentials are kept in the store.
+func CreateServer(databasePlanMap map[string]PlanFactory, conf *ServerConfig, clientFactory StardogClientFactory, logger SdLogger, store Store, secretStore SecretStore) (*Server, error) {
+	controller, err := CreateController(databasePlanMap, conf, clientFactory, logger, store, secretStore)
+	if err != nil {
+		return nil, err
+	}
+	minVersionString := conf.MinAPIVersion
+	if minVersionString == "" {
+		minVersionString = DefaultMinAPIVersion
+	}
+	minVersion, err := ParseAPIVersion(minVersionString)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newServerTLSConfig(conf.TLS, logger)
 	if err != nil {
 		return nil, err
 	}
-
 	return &Server{
 		controller: controller,
 		port:       conf.Port,
 		logger:     logger,
+		minVersion: minVersion,
+		tlsConfig:  tlsConfig,
+		throttle:   newAuthThrottle(conf.RateLimit, logger),
 	}, nil
 }
 
-// Start begins listening for HTTP connections on a port.  The listening is doneChannel
+// Start begins listening for HTTP connections on a port, or HTTPS
+// connections when TLS is configured.  The listening is doneChannel
 // in a go routine and control is handed back to the calling thread.
 func (s *Server) Start() error {
 	var err error
 
-	router := mux.NewRouter()
-
-	router.HandleFunc("/v2/catalog", s.controller.Catalog).Methods("GET")
-	router.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.GetServiceInstance).Methods("GET")
-	router.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.CreateServiceInstance).Methods("PUT")
-	router.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.RemoveServiceInstance).Methods("DELETE")
-	router.HandleFunc("/v2/service_instances/{service_instance_GUID}/service_bindings/{service_binding_GUID}", s.controller.Bind).Methods("PUT")
-	router.HandleFunc("/v2/service_instances/{service_instance_GUID}/service_bindings/{service_binding_GUID}", s.controller.UnBind).Methods("DELETE")
-
 	s.server = http.Server{
 		Addr:    ":" + s.port,
-		Handler: router,
+		Handler: s.handler(),
 	}
 
 	s.listener, err = net.Listen("tcp", s.server.Addr)
 	if err != nil {
 		return err
 	}
+	if s.tlsConfig != nil {
+		s.server.TLSConfig = s.tlsConfig
+		s.listener = tls.NewListener(s.listener, s.tlsConfig)
+	}
 	s.doneChannel = make(chan error)
 
 	go func() {
@@ -80,6 +95,30 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// handler routes the broker API to the controller.
+func (s *Server) handler() http.Handler {
+	router := mux.NewRouter()
+	apiRouter := mux.NewRouter()
+
+	apiRouter.HandleFunc("/v2/catalog", s.controller.Catalog).Methods("GET")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.GetServiceInstance).Methods("GET")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.CreateServiceInstance).Methods("PUT")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.UpdateServiceInstance).Methods("PATCH")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}", s.controller.RemoveServiceInstance).Methods("DELETE")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}/last_operation", s.controller.InstanceLastOperation).Methods("GET")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}/service_bindings/{service_binding_GUID}", s.controller.Bind).Methods("PUT")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}/service_bindings/{service_binding_GUID}", s.controller.GetBinding).Methods("GET")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}/service_bindings/{service_binding_GUID}", s.controller.UnBind).Methods("DELETE")
+	apiRouter.HandleFunc("/v2/service_instances/{service_instance_GUID}/service_bindings/{service_binding_GUID}/last_operation", s.controller.BindingLastOperation).Methods("GET")
+
+	// The admin API is not part of the Open Service Broker API so it does
+	// not require a version
+	router.HandleFunc("/admin/orphans", s.controller.GetOrphans).Methods("GET")
+	router.HandleFunc("/admin/orphans/{orphan_id}", s.controller.DeleteOrphan).Methods("DELETE")
+	router.PathPrefix("/v2/").Handler(apiVersionHandler(s.logger, s.minVersion, apiRouter))
+	return authThrottleHandler(s.throttle, router)
+}
+
 // Wait will block on a running server until the Stop method is called.
 func (s *Server) Wait() error {
 	err := <-s.doneChannel
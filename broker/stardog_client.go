@@ -17,59 +17,208 @@ package broker
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/textproto"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConnectTimeout      = 10 * time.Second
+	defaultRequestTimeout      = 60 * time.Second
+	defaultMaxIdleConnsPerHost = 10
 )
 
 type stardogClientImpl struct {
-	sdURL   string
-	dbCreds DatabaseCredentials
-	logger  SdLogger
+	sdURL      string
+	dbCreds    DatabaseCredentials
+	logger     SdLogger
+	httpClient *http.Client
+	retrier    *Retrier
 }
 
 type sdRealClientFactory struct {
-	logger SdLogger
+	logger     SdLogger
+	conf       *StardogClientConfig
+	httpClient *http.Client
+	tlsClients *tlsClientCache
+	retrier    *Retrier
+}
+
+// tlsClientCache keeps one HTTP client for each set of TLS settings so that
+// the certificates are only loaded once and connections are reused.
+type tlsClientCache struct {
+	lock    sync.Mutex
+	clients map[StardogTLSConfig]*http.Client
 }
 
 // NewClientFactory returns an object that will create StardogClient objects that
-// interact with a Stardog service
-func NewClientFactory(logger SdLogger) StardogClientFactory {
-	return &sdRealClientFactory{logger: logger}
+// interact with a Stardog service.  The clients share a pool of connections
+// that is tuned by conf, which may be nil to use the defaults.
+func NewClientFactory(logger SdLogger, conf *StardogClientConfig) StardogClientFactory {
+	return &sdRealClientFactory{
+		logger:     logger,
+		conf:       conf,
+		httpClient: NewHTTPClient(conf, nil),
+		tlsClients: &tlsClientCache{clients: make(map[StardogTLSConfig]*http.Client)},
+		retrier:    newDefaultRetrier(),
+	}
+}
+
+// NewHTTPClient creates the HTTP client used to talk to Stardog and to
+// other servers the broker calls, such as CredHub.  Without a timeout a
+// server that stops responding would hold on to the broker request that
+// is waiting for it forever.
+func NewHTTPClient(conf *StardogClientConfig, tlsConfig *tls.Config) *http.Client {
+	connectTimeout := defaultConnectTimeout
+	requestTimeout := defaultRequestTimeout
+	maxIdleConnsPerHost := defaultMaxIdleConnsPerHost
+	if conf != nil {
+		if conf.ConnectTimeoutSeconds > 0 {
+			connectTimeout = time.Duration(conf.ConnectTimeoutSeconds) * time.Second
+		}
+		if conf.RequestTimeoutSeconds > 0 {
+			requestTimeout = time.Duration(conf.RequestTimeoutSeconds) * time.Second
+		}
+		if conf.MaxIdleConnsPerHost > 0 {
+			maxIdleConnsPerHost = conf.MaxIdleConnsPerHost
+		}
+	}
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			TLSClientConfig:       tlsConfig,
+			TLSHandshakeTimeout:   connectTimeout,
+			MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+			IdleConnTimeout:       90 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		Timeout: requestTimeout,
+	}
 }
 
 // GetStardogAdminClient generates a stardog client object.  This gives a hook for mock objects
 // in testing.
 func (f *sdRealClientFactory) GetStardogAdminClient(sdURL string, dbCreds DatabaseCredentials) StardogClient {
-	client := stardogClientImpl{sdURL: sdURL, dbCreds: dbCreds, logger: f.logger}
+	client := stardogClientImpl{sdURL: sdURL, dbCreds: dbCreds, logger: f.logger, httpClient: f.httpClient, retrier: f.retrier}
 	return &client
 }
 
+// WithTLSConfig returns a factory whose clients connect with the given TLS
+// settings.
+func (f *sdRealClientFactory) WithTLSConfig(conf *StardogTLSConfig) (StardogClientFactory, error) {
+	if conf == nil {
+		return f, nil
+	}
+	f.tlsClients.lock.Lock()
+	defer f.tlsClients.lock.Unlock()
+
+	httpClient, ok := f.tlsClients.clients[*conf]
+	if !ok {
+		tlsConfig, err := NewStardogTLSConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = NewHTTPClient(f.conf, tlsConfig)
+		f.tlsClients.clients[*conf] = httpClient
+	}
+	return &sdRealClientFactory{
+		logger:     f.logger,
+		conf:       f.conf,
+		httpClient: httpClient,
+		tlsClients: f.tlsClients,
+		retrier:    f.retrier,
+	}, nil
+}
+
+// WithRetrier returns a factory whose clients retry requests as the
+// Retrier says.
+func (f *sdRealClientFactory) WithRetrier(retrier *Retrier) StardogClientFactory {
+	if retrier == nil {
+		return f
+	}
+	retryFactory := *f
+	retryFactory.retrier = retrier
+	return &retryFactory
+}
+
+// ClientFactoryWithTLS returns a factory that connects to Stardog with the
+// given TLS settings.  Factories that do not support TLS settings, such as
+// mock objects, are returned unchanged.
+func ClientFactoryWithTLS(clientFactory StardogClientFactory, conf *StardogTLSConfig) (StardogClientFactory, error) {
+	tlsFactory, ok := clientFactory.(TLSClientFactory)
+	if !ok || conf == nil {
+		return clientFactory, nil
+	}
+	return tlsFactory.WithTLSConfig(conf)
+}
+
+// ClientFactoryWithRetrier returns a factory whose clients retry requests
+// to Stardog as the Retrier says.  Factories that do not support retries,
+// such as mock objects, are returned unchanged.
+func ClientFactoryWithRetrier(clientFactory StardogClientFactory, retrier *Retrier) StardogClientFactory {
+	retryFactory, ok := clientFactory.(RetryClientFactory)
+	if !ok || retrier == nil {
+		return clientFactory
+	}
+	return retryFactory.WithRetrier(retrier)
+}
+
 // NewStardogClient creates a StardogClient network API object
 func NewStardogClient(sdURL string, dbCreds DatabaseCredentials, logger SdLogger) StardogClient {
 	s := stardogClientImpl{
-		sdURL:   sdURL,
-		dbCreds: dbCreds,
-		logger:  logger,
+		sdURL:      sdURL,
+		dbCreds:    dbCreds,
+		logger:     logger,
+		httpClient: NewHTTPClient(nil, nil),
+		retrier:    newDefaultRetrier(),
 	}
 	return &s
 }
 
-func (s *stardogClientImpl) CreateDatabase(dbName string) error {
-	data := fmt.Sprintf("{\"dbname\": \"%s\", \"options\" : {}, \"files\": []}", dbName)
+type createDatabaseRequest struct {
+	DbName  string                 `json:"dbname"`
+	Options map[string]interface{} `json:"options"`
+	Files   []string               `json:"files"`
+}
+
+// CreateDatabase creates a database with the given options.  Options that
+// are not set get the defaults of the Stardog server.
+func (s *stardogClientImpl) CreateDatabase(ctx context.Context, dbName string, options map[string]interface{}) error {
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+	root, err := json.Marshal(&createDatabaseRequest{
+		DbName:  dbName,
+		Options: options,
+		Files:   []string{},
+	})
+	if err != nil {
+		return err
+	}
+	data := string(root)
 	s.logger.Logf(DEBUG, "Creating the database with %s\n", data)
 
 	dbURL := fmt.Sprintf("%s/admin/databases", s.sdURL)
 	bodyBuf := &bytes.Buffer{}
 	bodyWriter := multipart.NewWriter(bodyBuf)
-	err := bodyWriter.WriteField("root", data)
+	err = bodyWriter.WriteField("root", data)
 	if err != nil {
 		return fmt.Errorf("didnt make write field %s", err)
 	}
@@ -80,28 +229,77 @@ func (s *stardogClientImpl) CreateDatabase(dbName string) error {
 	if err != nil {
 		return fmt.Errorf("Failed to create the req %s url %s", dbURL, err)
 	}
+	req = req.WithContext(ctx)
 	req.SetBasicAuth(s.dbCreds.Username, s.dbCreds.Password)
 
-	client := &http.Client{}
 	req.Header.Set("Content-Type", contentType)
 
-	resp, err := client.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		s.logger.Logf(DEBUG, "Failed to connect to the database %s\n", err)
 		return fmt.Errorf("Failed do the post %s", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 201 {
-		return fmt.Errorf("Failed to create the database")
+		return newStardogError(resp, "POST", dbURL)
 	}
 	return nil
 }
 
-func (s *stardogClientImpl) GetDatabaseSize(dbName string) (int, error) {
+// SetDatabaseOptions changes the configuration options of an existing
+// database.  Many options can only be set while the database is offline so
+// it is taken offline for the change and brought back online afterwards.
+// Bringing it back online does not use ctx so that a client giving up on
+// the request does not leave the database offline.
+func (s *stardogClientImpl) SetDatabaseOptions(ctx context.Context, dbName string, options map[string]interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	s.logger.Logf(DEBUG, "Setting the options on %s to %s\n", dbName, string(data))
+
+	dbURL := fmt.Sprintf("%s/admin/databases/%s/offline", s.sdURL, url.PathEscape(dbName))
+	_, err = s.doRepeatableRequest(ctx, "PUT", dbURL, nil, "application/json", "application/json", 200, 0)
+	if err != nil {
+		s.logger.Logf(WARN, "Failed to take the database %s offline %s", dbName, err)
+		if ctx.Err() != nil {
+			// Stardog may have taken the database offline before the
+			// request was abandoned
+			s.bringOnline(dbName)
+		}
+		return err
+	}
+
+	dbURL = fmt.Sprintf("%s/admin/databases/%s/options", s.sdURL, url.PathEscape(dbName))
+	_, setErr := s.doRepeatableRequest(ctx, "POST", dbURL, data, "application/json", "application/json", 200, 0)
+	if setErr != nil {
+		s.logger.Logf(WARN, "Failed to set the options on %s %s", dbName, setErr)
+	}
+
+	err = s.bringOnline(dbName)
+	if err != nil {
+		return err
+	}
+	return setErr
+}
+
+func (s *stardogClientImpl) bringOnline(dbName string) error {
+	dbURL := fmt.Sprintf("%s/admin/databases/%s/online", s.sdURL, url.PathEscape(dbName))
+	_, err := s.doRepeatableRequest(context.Background(), "PUT", dbURL, nil, "application/json", "application/json", 200, 0)
+	if err != nil {
+		s.logger.Logf(ERROR, "Failed to bring the database %s back online %s", dbName, err)
+	}
+	return err
+}
+
+func (s *stardogClientImpl) GetDatabaseSize(ctx context.Context, dbName string) (int, error) {
 	s.logger.Logf(DEBUG, "GetDatabase the database %s\n", dbName)
 
-	dbURL := fmt.Sprintf("%s/%s/size", s.sdURL, dbName)
-	bodyBuf := &bytes.Buffer{}
-	content, err := s.doRequest("GET", dbURL, bodyBuf, "text/plain", 200)
+	dbURL := fmt.Sprintf("%s/%s/size", s.sdURL, url.PathEscape(dbName))
+	content, err := s.doRepeatableRequest(ctx, "GET", dbURL, nil, "text/plain", "text/plain", 200, 0)
 	if err != nil {
 		return -1, err
 	}
@@ -109,43 +307,54 @@ func (s *stardogClientImpl) GetDatabaseSize(dbName string) (int, error) {
 	return i, err
 }
 
-func (s *stardogClientImpl) AddData(dbName string, format string, data string) error {
-	dbURL := fmt.Sprintf("%s/%s/transaction/begin", s.sdURL, dbName)
+func (s *stardogClientImpl) AddData(ctx context.Context, dbName string, format string, data string) error {
+	dbURL := fmt.Sprintf("%s/%s/transaction/begin", s.sdURL, url.PathEscape(dbName))
 	bodyBuf := &bytes.Buffer{}
-	content, err := s.doRequest("POST", dbURL, bodyBuf, "text/plain", 200)
+	content, err := s.doRequest(ctx, "POST", dbURL, bodyBuf, "text/plain", 200)
 	if err != nil {
 		return err
 	}
-	txID := string(content)
+	txID := strings.TrimSpace(string(content))
 
-	dataPayload := strings.NewReader(string(data))
-	dbURL = fmt.Sprintf("%s/%s/%s/add", s.sdURL, dbName, txID)
-	_, err = s.doRequestWithAccept("POST", dbURL, dataPayload, format, "text/plain", 200)
+	// Adding the same data to a transaction twice does not change it, but
+	// beginning or committing it again would
+	dbURL = fmt.Sprintf("%s/%s/%s/add", s.sdURL, url.PathEscape(dbName), url.PathEscape(txID))
+	_, err = s.doRepeatableRequest(ctx, "POST", dbURL, []byte(data), format, "text/plain", 200, 0)
 	if err != nil {
 		return err
 	}
 
-	dbURL = fmt.Sprintf("%s/%s/transaction/commit/%s", s.sdURL, dbName, txID)
-	_, err = s.doRequest("POST", dbURL, bodyBuf, "text/plain", 200)
+	dbURL = fmt.Sprintf("%s/%s/transaction/commit/%s", s.sdURL, url.PathEscape(dbName), url.PathEscape(txID))
+	_, err = s.doRequest(ctx, "POST", dbURL, bodyBuf, "text/plain", 200)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *stardogClientImpl) Query(dbName string, data string) ([]byte, error) {
-	bodyBuf := &bytes.Buffer{}
+func (s *stardogClientImpl) Query(ctx context.Context, dbName string, data string) ([]byte, error) {
 	q := url.QueryEscape(data)
-	dbURL := fmt.Sprintf("%s/%s/query?query=%s", s.sdURL, dbName, q)
-	content, err := s.doRequestWithAccept("GET", dbURL, bodyBuf, "application/ld+json", "application/sparql-results+json", 200)
+	dbURL := fmt.Sprintf("%s/%s/query?query=%s", s.sdURL, url.PathEscape(dbName), q)
+	content, err := s.doRepeatableRequest(ctx, "GET", dbURL, nil, "application/ld+json", "application/sparql-results+json", 200, 0)
 	if err != nil {
 		return nil, err
 	}
 	return content, nil
 }
 
-func (s *stardogClientImpl) AddDocument(dbName string, doc string) error {
-	dbURL := fmt.Sprintf("%s/%s/docs", s.sdURL, dbName)
+// Update runs a SPARQL update.  The query is sent in the body since it
+// may hold more data than fits in a URL.  Only updates that can be
+// repeated without changing their outcome should be sent since a failed
+// request is retried.
+func (s *stardogClientImpl) Update(ctx context.Context, dbName string, query string) error {
+	dbURL := fmt.Sprintf("%s/%s/update", s.sdURL, url.PathEscape(dbName))
+	body := url.Values{"query": []string{query}}.Encode()
+	_, err := s.doRepeatableRequest(ctx, "POST", dbURL, []byte(body), "application/x-www-form-urlencoded", "text/plain", 200, 0)
+	return err
+}
+
+func (s *stardogClientImpl) AddDocument(ctx context.Context, dbName string, doc string) error {
+	dbURL := fmt.Sprintf("%s/%s/docs", s.sdURL, url.PathEscape(dbName))
 
 	bodyBuf := &bytes.Buffer{}
 	bodyWriter := multipart.NewWriter(bodyBuf)
@@ -162,7 +371,7 @@ func (s *stardogClientImpl) AddDocument(dbName string, doc string) error {
 	io.Write([]byte(doc))
 	bodyWriter.Close()
 
-	_, err = s.doRequest("POST", dbURL, bodyBuf, contentType, 201)
+	_, err = s.doRequest(ctx, "POST", dbURL, bodyBuf, contentType, 201)
 	if err != nil {
 		s.logger.Logf(ERROR, "Adding the document failed %s", err)
 		return err
@@ -180,10 +389,9 @@ type userListResponse struct {
 	Users []string `json:"users"`
 }
 
-func (s *stardogClientImpl) UserExists(username string) (bool, error) {
+func (s *stardogClientImpl) UserExists(ctx context.Context, username string) (bool, error) {
 	dbURL := fmt.Sprintf("%s/admin/users", s.sdURL)
-	bodyBuf := &bytes.Buffer{}
-	content, err := s.doRequest("GET", dbURL, bodyBuf, "application/json", 200)
+	content, err := s.doRepeatableRequest(ctx, "GET", dbURL, nil, "application/json", "application/json", 200, 0)
 	if err != nil {
 		return false, err
 	}
@@ -200,7 +408,7 @@ func (s *stardogClientImpl) UserExists(username string) (bool, error) {
 	return false, nil
 }
 
-func (s *stardogClientImpl) NewUser(username string, pw string) error {
+func (s *stardogClientImpl) NewUser(ctx context.Context, username string, pw string) error {
 	request := &newUserRequest{
 		Username:  username,
 		Superuser: false,
@@ -214,10 +422,10 @@ func (s *stardogClientImpl) NewUser(username string, pw string) error {
 	if err != nil {
 		return err
 	}
-	bodyBuf := strings.NewReader(string(data))
-
+	// Plans check that the user does not exist first, so a conflict on a
+	// retry means that an earlier attempt created it
 	dbURL := fmt.Sprintf("%s/admin/users", s.sdURL)
-	c, err := s.doRequest("POST", dbURL, bodyBuf, "application/json", 201)
+	c, err := s.doRepeatableRequest(ctx, "POST", dbURL, data, "application/json", "application/json", 201, http.StatusConflict)
 	if err != nil {
 		s.logger.Logf(WARN, "Failed to create a new user %s %s", username, string(c))
 		return err
@@ -231,90 +439,195 @@ type userPermissionDb struct {
 	Resource     []string `json:"resource"`
 }
 
-func (s *stardogClientImpl) GrantUserAccessToDb(dbName string, username string) error {
-	dbURL := fmt.Sprintf("%s/admin/permissions/user/%s", s.sdURL, username)
-	up := &userPermissionDb{
-		Action:       "write",
-		ResourceType: "db",
-		Resource:     []string{dbName},
+// GrantUserPermissions grants a user each of the actions on a database.
+func (s *stardogClientImpl) GrantUserPermissions(ctx context.Context, dbName string, username string, actions []string) error {
+	return s.grantPermissions(ctx, "user", username, dbName, actions)
+}
+
+// GrantRolePermissions grants a role each of the actions on a database.
+func (s *stardogClientImpl) GrantRolePermissions(ctx context.Context, dbName string, role string, actions []string) error {
+	return s.grantPermissions(ctx, "role", role, dbName, actions)
+}
+
+// grantPermissions grants the actions on a database to either a user or a
+// role.
+func (s *stardogClientImpl) grantPermissions(ctx context.Context, kind string, name string, dbName string, actions []string) error {
+	dbURL := fmt.Sprintf("%s/admin/permissions/%s/%s", s.sdURL, kind, url.PathEscape(name))
+	for _, action := range actions {
+		up := &userPermissionDb{
+			Action:       action,
+			ResourceType: "db",
+			Resource:     []string{dbName},
+		}
+		data, err := json.Marshal(up)
+		if err != nil {
+			return err
+		}
+		_, err = s.doRepeatableRequest(ctx, "PUT", dbURL, data, "application/json", "application/json", 201, 0)
+		if err != nil {
+			s.logger.Logf(ERROR, "Failed to set %s permissions for the %s %s %s", action, kind, name, err)
+			return err
+		}
 	}
-	data, err := json.Marshal(up)
+	return nil
+}
+
+type roleRequest struct {
+	Rolename string `json:"rolename"`
+}
+
+// CreateRole creates a role that does not have any permissions.
+func (s *stardogClientImpl) CreateRole(ctx context.Context, role string) error {
+	data, err := json.Marshal(&roleRequest{Rolename: role})
 	if err != nil {
 		return err
 	}
-	bodyBuf := strings.NewReader(string(data))
-	_, err = s.doRequest("PUT", dbURL, bodyBuf, "application/json", 201)
+	dbURL := fmt.Sprintf("%s/admin/roles", s.sdURL)
+	c, err := s.doRepeatableRequest(ctx, "POST", dbURL, data, "application/json", "application/json", 201, http.StatusConflict)
 	if err != nil {
-		s.logger.Logf(ERROR, "Failed to set write permissions %s", err)
+		s.logger.Logf(WARN, "Failed to create the role %s %s", role, string(c))
 		return err
 	}
-	up.Action = "read"
-	data, err = json.Marshal(up)
+	return nil
+}
+
+// DeleteRole deletes a role even if users are still assigned to it.
+func (s *stardogClientImpl) DeleteRole(ctx context.Context, role string) error {
+	s.logger.Logf(INFO, "Deleting the role %s", role)
+	dbURL := fmt.Sprintf("%s/admin/roles/%s?force=true", s.sdURL, url.PathEscape(role))
+	c, err := s.doRepeatableRequest(ctx, "DELETE", dbURL, nil, "application/json", "application/json", 200, http.StatusNotFound)
+	if err != nil {
+		s.logger.Logf(WARN, "Error deleting role %s %s", string(c), err)
+		return err
+	}
+	return nil
+}
+
+// AssignRole adds a role to the roles of a user.
+func (s *stardogClientImpl) AssignRole(ctx context.Context, username string, role string) error {
+	data, err := json.Marshal(&roleRequest{Rolename: role})
 	if err != nil {
 		return err
 	}
-	bodyBuf = strings.NewReader(string(data))
-	_, err = s.doRequest("PUT", dbURL, bodyBuf, "application/json", 201)
+	dbURL := fmt.Sprintf("%s/admin/users/%s/roles", s.sdURL, url.PathEscape(username))
+	c, err := s.doRepeatableRequest(ctx, "POST", dbURL, data, "application/json", "application/json", 200, http.StatusConflict)
 	if err != nil {
-		s.logger.Logf(ERROR, "Failed to set read permissions %s", err)
+		s.logger.Logf(WARN, "Failed to assign the role %s to %s %s", role, username, string(c))
 		return err
 	}
 	return nil
 }
 
-func (s *stardogClientImpl) doRequest(method, urlStr string, body io.Reader, contentType string, expectedCode int) ([]byte, error) {
-	return s.doRequestWithAccept(method, urlStr, body, contentType, contentType, expectedCode)
+// doRepeatableRequest makes a request that can safely be sent more than
+// once, retrying it when it fails for a transient reason.  A response with
+// doneStatus to a retry means that an earlier attempt did the work but its
+// response was lost, so it counts as success.
+func (s *stardogClientImpl) doRepeatableRequest(ctx context.Context, method, urlStr string, body []byte, contentType string, accept string, expectedCode int, doneStatus int) ([]byte, error) {
+	var content []byte
+	err := s.retrier.run(ctx, s.logger, fmt.Sprintf("send %s to %s", method, urlStr), func(attempt int) error {
+		var err error
+		content, err = s.doRequestWithAccept(ctx, method, urlStr, bytes.NewReader(body), contentType, accept, expectedCode)
+		if err != nil && attempt > 1 && doneStatus != 0 && StardogStatus(err) == doneStatus {
+			s.logger.Logf(INFO, "%s to %s was already done by an earlier attempt", method, urlStr)
+			return nil
+		}
+		return err
+	})
+	return content, err
 }
 
-func (s *stardogClientImpl) doRequestWithAccept(method, urlStr string, body io.Reader, contentType string, accept string, expectedCode int) ([]byte, error) {
+func (s *stardogClientImpl) doRequest(ctx context.Context, method, urlStr string, body io.Reader, contentType string, expectedCode int) ([]byte, error) {
+	return s.doRequestWithAccept(ctx, method, urlStr, body, contentType, contentType, expectedCode)
+}
+
+func (s *stardogClientImpl) doRequestWithAccept(ctx context.Context, method, urlStr string, body io.Reader, contentType string, accept string, expectedCode int) ([]byte, error) {
 	req, err := http.NewRequest(method, urlStr, body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	req.SetBasicAuth(s.dbCreds.Username, s.dbCreds.Password)
-	client := &http.Client{}
 	req.Header.Set("Content-Type", contentType)
 	if accept != "" {
 		req.Header.Set("Accept", accept)
 	}
-	resp, err := client.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Failed do the post %s", err)
+		return nil, &transientError{fmt.Errorf("Failed do the post %s", err)}
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != expectedCode {
-		return nil, fmt.Errorf("Expected %d but got %d when %s to %s", expectedCode, resp.StatusCode, method, urlStr)
+		return nil, newStardogError(resp, method, urlStr)
 	}
 	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &transientError{fmt.Errorf("Failed to read the response to %s %s: %s", method, urlStr, err)}
+	}
 	s.logger.Logf(INFO, "Completed %s to %s", method, urlStr)
 	return content, nil
 }
 
-func (s *stardogClientImpl) doRequestResponse(method, urlStr string, body io.Reader, contentType string, expectedCode int) (*http.Response, error) {
+// stardogErrorResponse is the document that Stardog sends with an error.
+type stardogErrorResponse struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// maxErrorBodySize limits how much of an error response is read.
+const maxErrorBodySize = 64 * 1024
+
+// newStardogError makes a StardogError from a response with a status that
+// the request did not expect.  Stardog sends the error code in the
+// SD-Error-Code header and usually a JSON document with the message, but
+// proxies in front of it may send plain text.
+func newStardogError(resp *http.Response, method string, urlStr string) *StardogError {
+	se := &StardogError{
+		Operation: fmt.Sprintf("%s %s", method, urlStr),
+		Status:    resp.StatusCode,
+		Code:      resp.Header.Get("SD-Error-Code"),
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return se
+	}
+	var doc stardogErrorResponse
+	err = json.Unmarshal(body, &doc)
+	if err == nil {
+		se.Message = doc.Message
+		if se.Code == "" {
+			se.Code = doc.Code
+		}
+	} else {
+		se.Message = strings.TrimSpace(string(body))
+	}
+	return se
+}
+
+func (s *stardogClientImpl) doRequestResponse(ctx context.Context, method, urlStr string, body io.Reader, contentType string, expectedCode int) (*http.Response, error) {
 	req, err := http.NewRequest(method, urlStr, body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	req.SetBasicAuth(s.dbCreds.Username, s.dbCreds.Password)
-	client := &http.Client{}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", contentType)
-	resp, err := client.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Failed do the post %s", err)
+		return nil, &transientError{fmt.Errorf("Failed do the post %s", err)}
 	}
 	if resp.StatusCode != expectedCode {
+		se := newStardogError(resp, method, urlStr)
 		resp.Body.Close()
-		return nil, fmt.Errorf("Expected %d but got %d when %s to %s", expectedCode, resp.StatusCode, method, urlStr)
+		return nil, se
 	}
 	return resp, nil
 }
 
-func (s *stardogClientImpl) DeleteUser(username string) error {
+func (s *stardogClientImpl) DeleteUser(ctx context.Context, username string) error {
 	s.logger.Logf(INFO, "Deleting the user %s", username)
-	dbURL := fmt.Sprintf("%s/admin/users/%s", s.sdURL, username)
-	bodyBuf := &bytes.Buffer{}
-	c, err := s.doRequest("DELETE", dbURL, bodyBuf, "application/json", 200)
+	dbURL := fmt.Sprintf("%s/admin/users/%s", s.sdURL, url.PathEscape(username))
+	c, err := s.doRepeatableRequest(ctx, "DELETE", dbURL, nil, "application/json", "application/json", 200, http.StatusNotFound)
 	if err != nil {
 		s.logger.Logf(WARN, "Error deleting user %s %s", string(c), err)
 		return err
@@ -322,47 +635,36 @@ func (s *stardogClientImpl) DeleteUser(username string) error {
 	return nil
 }
 
-func (s *stardogClientImpl) RevokeUserAccess(dbName string, username string) error {
+// RevokeUserPermissions revokes each of the actions on a database from a
+// user.
+func (s *stardogClientImpl) RevokeUserPermissions(ctx context.Context, dbName string, username string, actions []string) error {
 	s.logger.Logf(INFO, "Revoking user %s access to %s", username, dbName)
 
-	up := &userPermissionDb{
-		Action:       "write",
-		ResourceType: "db",
-		Resource:     []string{dbName},
-	}
-	data, err := json.Marshal(up)
-	if err != nil {
-		return err
-	}
-	bodyBuf := strings.NewReader(string(data))
-
-	dbURL := fmt.Sprintf("%s/admin/permissions/user/%s/delete", s.sdURL, username)
-	c, err := s.doRequest("POST", dbURL, bodyBuf, "application/json", 200)
-	if err != nil {
-		s.logger.Logf(WARN, "Error revoking access %s %s", string(c), err)
-		return err
-	}
-	up.Action = "read"
-	data, err = json.Marshal(up)
-	if err != nil {
-		return err
-	}
-	bodyBuf = strings.NewReader(string(data))
-
-	c, err = s.doRequest("POST", dbURL, bodyBuf, "application/json", 200)
-	if err != nil {
-		s.logger.Logf(WARN, "Error revoking access %s %s", string(c), err)
-		return err
+	dbURL := fmt.Sprintf("%s/admin/permissions/user/%s/delete", s.sdURL, url.PathEscape(username))
+	for _, action := range actions {
+		up := &userPermissionDb{
+			Action:       action,
+			ResourceType: "db",
+			Resource:     []string{dbName},
+		}
+		data, err := json.Marshal(up)
+		if err != nil {
+			return err
+		}
+		c, err := s.doRepeatableRequest(ctx, "POST", dbURL, data, "application/json", "application/json", 200, 0)
+		if err != nil {
+			s.logger.Logf(WARN, "Error revoking access %s %s", string(c), err)
+			return err
+		}
 	}
 	return nil
 }
 
-func (s *stardogClientImpl) DeleteDatabase(dbName string) error {
+func (s *stardogClientImpl) DeleteDatabase(ctx context.Context, dbName string) error {
 	s.logger.Logf(INFO, "Deleting the database %s", dbName)
 
-	dbURL := fmt.Sprintf("%s/admin/databases/%s", s.sdURL, dbName)
-	bodyBuf := &bytes.Buffer{}
-	c, err := s.doRequest("DELETE", dbURL, bodyBuf, "application/json", 200)
+	dbURL := fmt.Sprintf("%s/admin/databases/%s", s.sdURL, url.PathEscape(dbName))
+	c, err := s.doRepeatableRequest(ctx, "DELETE", dbURL, nil, "application/json", "application/json", 200, http.StatusNotFound)
 	if err != nil {
 		s.logger.Logf(WARN, "Error deleting the db %s %s", string(c), err)
 		return err
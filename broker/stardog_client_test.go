@@ -0,0 +1,161 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetDatabaseOptionsOnlineAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var lock sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		paths = append(paths, r.URL.Path)
+		lock.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/options") {
+			// The client gives up while the options are being set
+			cancel()
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewStardogClient(server.URL, DatabaseCredentials{Username: "admin", Password: "admin"}, getLogger(t))
+	err := client.SetDatabaseOptions(ctx, "db", map[string]interface{}{"search.enabled": true})
+	if err == nil {
+		t.Fatalf("The cancelled request should fail")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(paths) != 3 || paths[2] != "/admin/databases/db/online" {
+		t.Fatalf("The database should have been brought back online %v", paths)
+	}
+}
+
+func TestUpdateInBody(t *testing.T) {
+	query := "DELETE { ?s ?p ?o } WHERE { ?s ?p ?o }"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/db/update" || r.URL.RawQuery != "" || r.PostFormValue("query") != query {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewStardogClient(server.URL, DatabaseCredentials{Username: "admin", Password: "admin"}, getLogger(t))
+	err := client.Update(context.Background(), "db", query)
+	if err != nil {
+		t.Fatalf("The update should be posted in the body: %s", err)
+	}
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The connection drops before the promised body is sent
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"users": [`))
+	}))
+	defer server.Close()
+
+	client := NewStardogClient(server.URL, DatabaseCredentials{Username: "admin", Password: "admin"}, getLogger(t))
+	_, err := client.UserExists(context.Background(), "admin")
+	if err == nil {
+		t.Fatalf("A truncated response should fail")
+	}
+	if !isTransient(err) {
+		t.Fatalf("A truncated response should be retried, got %s", err)
+	}
+}
+
+func TestClientFactoryWithTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"users": ["admin"]}`))
+	}))
+	defer server.Close()
+
+	// The bundle holds another CA as well as the one of the server
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	otherCAFile := filepath.Join(dir, "other.pem")
+	writeCert(t, "otherca", otherCAFile, filepath.Join(dir, "otherkey.pem"), time.Now())
+	otherCA, err := ioutil.ReadFile(otherCAFile)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	bundleFile := filepath.Join(dir, "bundle.pem")
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	writeFile(t, bundleFile, append(otherCA, serverCA...), time.Now())
+
+	retrier, err := NewRetrier(&RetryPolicy{MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	factory := ClientFactoryWithRetrier(NewClientFactory(getLogger(t), nil), retrier)
+	userExists := func(f StardogClientFactory) error {
+		client := f.GetStardogAdminClient(server.URL, DatabaseCredentials{Username: "admin", Password: "admin"})
+		_, err := client.UserExists(context.Background(), "admin")
+		return err
+	}
+
+	same, err := ClientFactoryWithTLS(factory, nil)
+	if err != nil || same != factory {
+		t.Fatalf("Without TLS settings the factory should be unchanged")
+	}
+	if userExists(factory) == nil {
+		t.Fatalf("A server with an unknown certificate should be refused")
+	}
+
+	tlsFactory, err := ClientFactoryWithTLS(factory, &StardogTLSConfig{CAFile: bundleFile})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = userExists(tlsFactory)
+	if err != nil {
+		t.Fatalf("The server should be trusted through the CA bundle: %s", err)
+	}
+	// The test server certificate is issued for example.com
+	tlsFactory, err = ClientFactoryWithTLS(factory, &StardogTLSConfig{CAFile: bundleFile, ServerName: "stardog.example.org"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if userExists(tlsFactory) == nil {
+		t.Fatalf("A server name that the certificate does not cover should be refused")
+	}
+
+	tlsFactory, err = ClientFactoryWithTLS(factory, &StardogTLSConfig{CAFile: otherCAFile})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if userExists(tlsFactory) == nil {
+		t.Fatalf("A CA that did not sign the server certificate should not be trusted")
+	}
+	_, err = ClientFactoryWithTLS(factory, &StardogTLSConfig{CAFile: filepath.Join(dir, "missing.pem")})
+	if err == nil {
+		t.Fatalf("A missing CA file should fail")
+	}
+}
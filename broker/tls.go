@@ -0,0 +1,265 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certReloader hands the server certificate to the TLS handshake and loads
+// it again when the certificate or key file changes on disk so that a
+// renewed certificate is picked up without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   SdLogger
+	lock     sync.Mutex
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+func newCertReloader(certFile string, keyFile string, logger SdLogger) (*certReloader, error) {
+	cr := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	modTime, err := cr.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	err = cr.load(modTime)
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// lastModified returns the latest modification time of the certificate and
+// key files.
+func (cr *certReloader) lastModified() (time.Time, error) {
+	return lastModified(cr.certFile, cr.keyFile)
+}
+
+// lastModified returns the latest modification time of the files.
+func lastModified(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return latest, fmt.Errorf("Failed to read the TLS file %s: %s", path, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (cr *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("Failed to load the TLS certificate %s: %s", cr.certFile, err)
+	}
+	cr.cert = &cert
+	cr.modTime = modTime
+	return nil
+}
+
+// getCertificate is used as the GetCertificate function of the TLS
+// configuration.  If the files changed but cannot be loaded, for example
+// because only one of them has been replaced so far, the previous
+// certificate is kept.
+func (cr *certReloader) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	modTime, err := cr.lastModified()
+	if err != nil {
+		cr.logger.Logf(WARN, "Using the previous TLS certificate: %s", err)
+		return cr.cert, nil
+	}
+	if modTime.Equal(cr.modTime) {
+		return cr.cert, nil
+	}
+	err = cr.load(modTime)
+	if err != nil {
+		cr.logger.Logf(WARN, "Using the previous TLS certificate: %s", err)
+		return cr.cert, nil
+	}
+	cr.logger.Logf(INFO, "Reloaded the TLS certificate %s", cr.certFile)
+	return cr.cert, nil
+}
+
+// clientCAReloader loads the CAs that sign platform client certificates
+// again when the CA file changes on disk.
+type clientCAReloader struct {
+	caFile  string
+	base    *tls.Config
+	logger  SdLogger
+	lock    sync.Mutex
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+func newClientCAReloader(caFile string, base *tls.Config, logger SdLogger) (*clientCAReloader, error) {
+	cr := &clientCAReloader{
+		caFile: caFile,
+		base:   base,
+		logger: logger,
+	}
+	modTime, err := lastModified(caFile)
+	if err != nil {
+		return nil, err
+	}
+	cr.pool, err = loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	cr.modTime = modTime
+	return cr, nil
+}
+
+// clientCAs returns the current CA pool.  As with the certificate, a CA
+// file that cannot be loaded leaves the previous CAs in place.
+func (cr *clientCAReloader) clientCAs() *x509.CertPool {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	modTime, err := lastModified(cr.caFile)
+	if err != nil {
+		cr.logger.Logf(WARN, "Using the previous client CAs: %s", err)
+		return cr.pool
+	}
+	if modTime.Equal(cr.modTime) {
+		return cr.pool
+	}
+	pool, err := loadCertPool(cr.caFile)
+	if err != nil {
+		cr.logger.Logf(WARN, "Using the previous client CAs: %s", err)
+		return cr.pool
+	}
+	cr.pool = pool
+	cr.modTime = modTime
+	cr.logger.Logf(INFO, "Reloaded the client CAs %s", cr.caFile)
+	return cr.pool
+}
+
+// getConfigForClient is used as the GetConfigForClient function of the TLS
+// configuration so that every handshake verifies client certificates
+// against the current CAs.
+func (cr *clientCAReloader) getConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	conf := cr.base.Clone()
+	conf.GetConfigForClient = nil
+	conf.ClientCAs = cr.clientCAs()
+	return conf, nil
+}
+
+// newServerTLSConfig makes the TLS configuration of the broker server.  It
+// returns nil when TLS is not configured.
+func newServerTLSConfig(conf *TLSConfig, logger SdLogger) (*tls.Config, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, fmt.Errorf("TLS requires both a cert_file and a key_file")
+	}
+	minVersion := uint16(tls.VersionTLS12)
+	if conf.MinVersion != "" {
+		var ok bool
+		minVersion, ok = tlsVersions[conf.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("The TLS version %s is not supported", conf.MinVersion)
+		}
+	}
+	cr, err := newCertReloader(conf.CertFile, conf.KeyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: cr.getCertificate,
+	}
+
+	if conf.ClientCAFile != "" {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if conf.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		cr, err := newClientCAReloader(conf.ClientCAFile, tlsConfig.Clone(), logger)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = cr.pool
+		tlsConfig.GetConfigForClient = cr.getConfigForClient
+	} else if conf.RequireClientCert {
+		return nil, fmt.Errorf("require_client_cert needs a client_ca_file")
+	}
+	return tlsConfig, nil
+}
+
+// NewStardogTLSConfig makes the TLS configuration used by clients of a
+// Stardog server.  The files are read once so they must be in place before
+// the broker starts.
+func NewStardogTLSConfig(conf *StardogTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+	if conf.CAFile != "" {
+		var err error
+		tlsConfig.RootCAs, err = loadCertPool(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		if conf.CertFile == "" || conf.KeyFile == "" {
+			return nil, fmt.Errorf("A client certificate requires both a cert_file and a key_file")
+		}
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the client certificate %s: %s", conf.CertFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the CA file %s: %s", path, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("The CA file %s does not hold any PEM certificates", path)
+	}
+	return pool, nil
+}
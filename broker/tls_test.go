@@ -0,0 +1,228 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a new self signed certificate and its key to the files
+// and sets their modification time so that a reload notices them.
+func writeCert(t *testing.T, name string, certFile string, keyFile string, modTime time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), modTime)
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), modTime)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return cert
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	err := ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return dir
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Hour)
+
+	writeCert(t, "first", certFile, keyFile, start)
+	cr, err := newCertReloader(certFile, keyFile, getLogger(t))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	cert, err := cr.getCertificate(nil)
+	if err != nil || subject(t, cert) != "first" {
+		t.Fatalf("The first certificate should be served: %v", err)
+	}
+
+	writeCert(t, "second", certFile, keyFile, start.Add(time.Minute))
+	cert, _ = cr.getCertificate(nil)
+	if subject(t, cert) != "second" {
+		t.Fatalf("The renewed certificate should be served, got %s", subject(t, cert))
+	}
+
+	// Only half of a renewal has been written
+	writeFile(t, certFile, []byte("not a certificate"), start.Add(2*time.Minute))
+	cert, _ = cr.getCertificate(nil)
+	if subject(t, cert) != "second" {
+		t.Fatalf("The previous certificate should be kept, got %s", subject(t, cert))
+	}
+
+	os.Remove(keyFile)
+	cert, _ = cr.getCertificate(nil)
+	if subject(t, cert) != "second" {
+		t.Fatalf("The previous certificate should be kept when a file is missing, got %s", subject(t, cert))
+	}
+
+	_, err = newCertReloader(certFile, keyFile, getLogger(t))
+	if err == nil {
+		t.Fatalf("A missing key file should fail at startup")
+	}
+}
+
+func subject(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCert(t, "server", certFile, keyFile, time.Now())
+	logger := getLogger(t)
+
+	tlsConfig, err := newServerTLSConfig(nil, logger)
+	if err != nil || tlsConfig != nil {
+		t.Fatalf("No TLS configuration should mean plain HTTP")
+	}
+	tlsConfig, err = newServerTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile}, logger)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Fatalf("The defaults should be TLS 1.2 without client certificates")
+	}
+	tlsConfig, err = newServerTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"}, logger)
+	if err != nil || tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("TLS 1.3 should be accepted: %v", err)
+	}
+
+	bad := []TLSConfig{
+		{CertFile: certFile},
+		{CertFile: certFile, KeyFile: keyFile, MinVersion: "2.0"},
+		{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true},
+		{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "missing.pem")},
+		{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile},
+	}
+	for i := range bad {
+		_, err = newServerTLSConfig(&bad[i], logger)
+		if err == nil {
+			t.Fatalf("The configuration %v should be rejected", bad[i])
+		}
+	}
+
+	tlsConfig, err = newServerTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile}, logger)
+	if err != nil || tlsConfig.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Fatalf("A client CA should verify the certificates that are given: %v", err)
+	}
+	tlsConfig, err = newServerTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, RequireClientCert: true}, logger)
+	if err != nil || tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("require_client_cert should require a certificate: %v", err)
+	}
+}
+
+func TestClientCAReload(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	caFile := filepath.Join(dir, "ca.pem")
+	caKeyFile := filepath.Join(dir, "cakey.pem")
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, "server", certFile, keyFile, start)
+	first := writeCert(t, "firstca", caFile, caKeyFile, start)
+
+	tlsConfig, err := newServerTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, RequireClientCert: true}, getLogger(t))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	trusts := func(cert *x509.Certificate) bool {
+		conf, err := tlsConfig.GetConfigForClient(nil)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if conf.ClientAuth != tls.RequireAndVerifyClientCert || conf.GetCertificate == nil {
+			t.Fatalf("The per client configuration lost the server settings")
+		}
+		_, err = cert.Verify(x509.VerifyOptions{
+			Roots:     conf.ClientCAs,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		return err == nil
+	}
+	if !trusts(first) {
+		t.Fatalf("The first CA should be trusted")
+	}
+
+	second := writeCert(t, "secondca", caFile, caKeyFile, start.Add(time.Minute))
+	if !trusts(second) || trusts(first) {
+		t.Fatalf("Only the CA that replaced the first one should be trusted")
+	}
+
+	writeFile(t, caFile, []byte("not a certificate"), start.Add(2*time.Minute))
+	if !trusts(second) {
+		t.Fatalf("The previous CAs should be kept when the CA file cannot be loaded")
+	}
+}
@@ -16,14 +16,10 @@
 package broker
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
-	"strings"
-	"time"
 
 	"os"
 
@@ -52,14 +48,16 @@ func WriteResponse(w http.ResponseWriter, code int, object interface{}) {
 	}
 
 	w.WriteHeader(code)
-	_, err = fmt.Fprintf(w, string(data))
+	_, err = w.Write(data)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
 
-// ReadRequestBody is a convenience function for writing an HTTP response.
+// ReadRequestBody is a convenience function for reading the JSON document
+// sent by the client.  A body that is not valid JSON results in a
+// BadRequest BrokerError.
 func ReadRequestBody(r *http.Request, object interface{}) error {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -67,82 +65,34 @@ func ReadRequestBody(r *http.Request, object interface{}) error {
 	}
 	err = json.Unmarshal(body, object)
 	if err != nil {
-		return err
+		return NewBadRequestError("The request body is not a valid JSON document: %s", err)
 	}
 	return nil
 }
 
-// SendInternalError sends a bodiless HTTP error message to the client.
-func SendInternalError(w http.ResponseWriter) {
-	w.WriteHeader(http.StatusInternalServerError)
-	return
-}
-
 // SendError sends an error message to the client with a description.
+// Malformed requests are marked with the BadRequest error code.
 func SendError(logger SdLogger, w http.ResponseWriter, code int, desc string) {
-	logger.Logf(ERROR, "Sending the error message %d %s", code, desc)
-	e := ErrorMessageResponse{Description: desc}
-	WriteResponse(w, code, &e)
+	be := &BrokerError{Status: code, Description: desc}
+	if code == http.StatusBadRequest {
+		be.Code = ErrorBadRequest
+	}
+	SendBrokerError(logger, w, be, code)
 }
 
 // GetRouteVariable pulls a variable out of the HTTP path that the client
-// sent.
+// sent.  Route variables are all IDs, so a value that is not a valid ID is
+// rejected with a BadRequest error.
 func GetRouteVariable(r *http.Request, varName string) (string, error) {
 	val, ok := mux.Vars(r)[varName]
 	if !ok {
-		return "", fmt.Errorf("A required variable did not exist")
-	}
-	return val, nil
-}
-
-// GetRandomName is a convience function for creating random strings.
-func GetRandomName(base string, n int) string {
-	letterRunes := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+		return "", NewBadRequestError("%s is required", varName)
 	}
-	return fmt.Sprintf("%s%s", base, string(b))
-}
-
-// HTTPBasicCheck checks the authentication information in a HTTP basic auth header.
-func HTTPBasicCheck(r *http.Request, w http.ResponseWriter, username string, password string) error {
-	authHeader := r.Header["Authorization"]
-	if authHeader == nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("The HTTP header did not contain the Authorization header")
-	}
-	sent := ""
-	for _, s := range authHeader {
-		fields := strings.Fields(string(s))
-		if len(fields) == 2 && strings.ToLower(fields[0]) == "basic" {
-			sent = fields[1]
-		}
-	}
-	if sent == "" {
-		w.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("HTTP basic authentication was not enabled")
-	}
-	data, err := base64.StdEncoding.DecodeString(sent)
+	err := ValidateGUID(varName, val)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return err
+		return "", err
 	}
-	sA := strings.SplitN(string(data), ":", 2)
-	if len(sA) != 2 {
-		w.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("The authentication challenge was not properly formatted")
-	}
-	if username != sA[0] {
-		w.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("The username %s does not match %s", sA[0], username)
-	}
-	if password != sA[1] {
-		w.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("The password does not match")
-	}
-	return nil
+	return val, nil
 }
 
 // ReSerializeInterface takes an already inflated JSON object (typically
@@ -164,6 +114,22 @@ func ReSerializeInterface(in interface{}, out interface{}) error {
 	return nil
 }
 
+// MergeOptions returns a new map holding the values of base overridden by
+// the values in overrides.
+func MergeOptions(base map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := make(map[string]interface{}, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
 // GetVCAPServices is used in Cloud Foundry environments to pull out bound service information
 func GetVCAPServices() (map[string][]VCAPService, error) {
 	envStr := os.Getenv("VCAP_SERVICES")
@@ -177,7 +143,3 @@ func GetVCAPServices() (map[string][]VCAPService, error) {
 	}
 	return services, nil
 }
-
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
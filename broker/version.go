@@ -0,0 +1,117 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIVersionHeader is the HTTP header in which platforms send the version
+// of the Open Service Broker API that they speak.
+const APIVersionHeader = "X-Broker-API-Version"
+
+// DefaultMinAPIVersion is the oldest version of the Open Service Broker API
+// that is accepted when the configuration does not set one.
+const DefaultMinAPIVersion = "2.10"
+
+// The versions of the Open Service Broker API in which the optional
+// features used by this broker were introduced.
+var (
+	schemasVersion             = APIVersion{Major: 2, Minor: 11}
+	contextObjectVersion       = APIVersion{Major: 2, Minor: 12}
+	originatingIdentityVersion = APIVersion{Major: 2, Minor: 13}
+	asyncBindingVersion        = APIVersion{Major: 2, Minor: 14}
+	fetchBindingVersion        = APIVersion{Major: 2, Minor: 14}
+)
+
+// APIVersion is a parsed X-Broker-API-Version header.
+type APIVersion struct {
+	Major int
+	Minor int
+}
+
+type apiVersionKey struct{}
+
+// ParseAPIVersion parses a version string of the form major.minor.
+func ParseAPIVersion(s string) (APIVersion, error) {
+	var v APIVersion
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) != 2 {
+		return v, fmt.Errorf("The API version %s is not of the form major.minor", s)
+	}
+	var err error
+	v.Major, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return v, fmt.Errorf("The API major version %s is not a number", parts[0])
+	}
+	v.Minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return v, fmt.Errorf("The API minor version %s is not a number", parts[1])
+	}
+	return v, nil
+}
+
+// AtLeast returns true if v is the same as or newer than other.
+func (v APIVersion) AtLeast(other APIVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	return v.Minor >= other.Minor
+}
+
+func (v APIVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// GetAPIVersion returns the API version that was negotiated for the
+// request.  Requests that did not go through the version check get the
+// minimum version that is accepted by default.
+func GetAPIVersion(r *http.Request) APIVersion {
+	v, ok := r.Context().Value(apiVersionKey{}).(APIVersion)
+	if !ok {
+		v, _ = ParseAPIVersion(DefaultMinAPIVersion)
+	}
+	return v
+}
+
+// apiVersionHandler rejects requests whose X-Broker-API-Version is missing
+// or is not supported with 412 and records the negotiated version on the
+// requests that it lets through.
+func apiVersionHandler(logger SdLogger, minVersion APIVersion, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(APIVersionHeader)
+		if header == "" {
+			SendError(logger, w, http.StatusPreconditionFailed, fmt.Sprintf("The %s header is required", APIVersionHeader))
+			return
+		}
+		v, err := ParseAPIVersion(header)
+		if err != nil {
+			SendError(logger, w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		if v.Major != minVersion.Major || !v.AtLeast(minVersion) {
+			SendError(logger, w, http.StatusPreconditionFailed, fmt.Sprintf("The API version %s is not supported.  Version %d.x of at least %s is required", v, minVersion.Major, minVersion))
+			return
+		}
+		logger.Logf(DEBUG, "Serving %s %s with API version %s", r.Method, r.URL.Path, v)
+		ctx := context.WithValue(r.Context(), apiVersionKey{}, v)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,124 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func getLogger(t *testing.T) SdLogger {
+	logger, err := NewSdLogger(log.New(os.Stderr, "", log.Ldate|log.Ltime), "DEBUG")
+	if err != nil {
+		t.Fatalf("Failed to make the logger %s", err)
+	}
+	return logger
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	v, err := ParseAPIVersion(" 2.14 ")
+	if err != nil || v != (APIVersion{Major: 2, Minor: 14}) {
+		t.Fatalf("2.14 was parsed as %s: %v", v, err)
+	}
+	for _, s := range []string{"", "2", "2.14.1", "two.14", "2.x"} {
+		_, err = ParseAPIVersion(s)
+		if err == nil {
+			t.Fatalf("%q should not parse", s)
+		}
+	}
+	if !v.AtLeast(APIVersion{Major: 2, Minor: 9}) || v.AtLeast(APIVersion{Major: 2, Minor: 15}) || v.AtLeast(APIVersion{Major: 3, Minor: 0}) {
+		t.Fatalf("2.14 was not ordered correctly")
+	}
+}
+
+func TestAPIVersionHandler(t *testing.T) {
+	var seen APIVersion
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetAPIVersion(r)
+	})
+	h := apiVersionHandler(getLogger(t), APIVersion{Major: 2, Minor: 10}, next)
+
+	rejected := []string{"", "junk", "2.9", "1.14", "3.0"}
+	for _, header := range rejected {
+		r := httptest.NewRequest("GET", "/v2/catalog", nil)
+		if header != "" {
+			r.Header.Set(APIVersionHeader, header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusPreconditionFailed {
+			t.Fatalf("The version %q should get 412, got %d", header, w.Code)
+		}
+	}
+
+	for _, header := range []string{"2.10", "2.14"} {
+		r := httptest.NewRequest("GET", "/v2/catalog", nil)
+		r.Header.Set(APIVersionHeader, header)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || seen.String() != header {
+			t.Fatalf("The version %s should be accepted and recorded, got %d %s", header, w.Code, seen)
+		}
+	}
+}
+
+func TestAPIVersionGating(t *testing.T) {
+	tb := newTestBroker(t, nil, &testPlanFactory{id: "plan1"})
+	defer tb.close()
+
+	var catalog CatalogResponse
+	tb.do(t, "GET", "/v2/catalog", "2.13", nil, &catalog)
+	if len(catalog.Services) != 1 || catalog.Services[0].BindingsRetrievable {
+		t.Fatalf("bindings_retrievable should not be offered to 2.13 %v", catalog)
+	}
+	catalog = CatalogResponse{}
+	tb.do(t, "GET", "/v2/catalog", "2.14", nil, &catalog)
+	if len(catalog.Services) != 1 || !catalog.Services[0].BindingsRetrievable {
+		t.Fatalf("bindings_retrievable should be offered to 2.14 %v", catalog)
+	}
+
+	create := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code := tb.do(t, "PUT", "/v2/service_instances/inst1", "2.13", create, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create the instance %d", code)
+	}
+	bind := map[string]interface{}{"service_id": "brokerid", "plan_id": "plan1"}
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b1?accepts_incomplete=true", "2.13", bind, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("A 2.13 platform should get a synchronous binding, got %d", code)
+	}
+	code = tb.do(t, "GET", "/v2/service_instances/inst1/service_bindings/b1/last_operation", "2.13", nil, nil)
+	if code != http.StatusNotFound {
+		t.Fatalf("A 2.13 platform cannot poll a binding, got %d", code)
+	}
+	code = tb.do(t, "GET", "/v2/service_instances/inst1/service_bindings/b1", "2.13", nil, nil)
+	if code != http.StatusNotFound {
+		t.Fatalf("A 2.13 platform cannot fetch a binding, got %d", code)
+	}
+
+	var async AsyncBindResponse
+	code = tb.do(t, "PUT", "/v2/service_instances/inst1/service_bindings/b2?accepts_incomplete=true", "2.14", bind, &async)
+	if code != http.StatusAccepted || async.Operation == "" {
+		t.Fatalf("A 2.14 platform should get an asynchronous binding, got %d", code)
+	}
+	code = tb.do(t, "GET", "/v2/service_instances/inst1/service_bindings/b2/last_operation", "2.14", nil, nil)
+	if code != http.StatusOK {
+		t.Fatalf("A 2.14 platform should be able to poll the binding, got %d", code)
+	}
+}
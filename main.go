@@ -25,6 +25,9 @@ import (
 	"github.com/stardog-union/service-broker/broker"
 	"github.com/stardog-union/service-broker/plans/perinstance"
 	"github.com/stardog-union/service-broker/plans/shared"
+	"github.com/stardog-union/service-broker/secrets/credhub"
+	secretsfile "github.com/stardog-union/service-broker/secrets/file"
+	"github.com/stardog-union/service-broker/store/encrypted"
 	_ "github.com/stardog-union/service-broker/store/sql"
 	storesql "github.com/stardog-union/service-broker/store/sql"
 	storestardog "github.com/stardog-union/service-broker/store/stardog"
@@ -57,10 +60,18 @@ func handlePlugins(conf *broker.ServerConfig) (map[string]broker.PlanFactory, er
 func main() {
 	var conf broker.ServerConfig
 
+	// Running with reencrypt as the first argument rewrites the stored
+	// records with the primary encryption key and exits.
+	args := os.Args[1:]
+	reencrypt := false
+	if len(args) > 0 && args[0] == "reencrypt" {
+		reencrypt = true
+		args = args[1:]
+	}
 	confPath := filepath.Join("data", "conf.json")
-	if len(os.Args) > 1 {
+	if len(args) > 0 {
 
-		confPath = strings.TrimSpace(os.Args[1])
+		confPath = strings.TrimSpace(args[0])
 	}
 	err := broker.LoadJSON(&conf, confPath)
 	if err != nil {
@@ -104,9 +115,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error parsing the configuration: %s\n", err)
 		os.Exit(2)
 	}
+	clientFactory := broker.NewClientFactory(logger, conf.StardogClient)
 	var store broker.Store
 	if conf.Storage.Type == "stardog" {
-		store, err = storestardog.NewStardogStore(conf.BrokerID, logger, conf.Storage.Parameters)
+		store, err = storestardog.NewStardogStore(conf.BrokerID, logger, clientFactory, conf.Storage.Parameters)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error setting up the data store: %s\n", err)
 			os.Exit(3)
@@ -121,9 +133,43 @@ func main() {
 		fmt.Fprintf(os.Stderr, "The datastore %s is not supported.\n", conf.Storage.Type)
 		os.Exit(5)
 	}
+	if conf.Encryption != nil {
+		keys, err := encrypted.NewKeyring(conf.Encryption)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading the encryption keys: %s\n", err)
+			os.Exit(6)
+		}
+		if reencrypt {
+			count, err := encrypted.ReEncrypt(store, keys, logger)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error re-encrypting the data store: %s\n", err)
+				os.Exit(6)
+			}
+			fmt.Fprintf(os.Stderr, "Re-encrypted %d records\n", count)
+			return
+		}
+		store = encrypted.NewEncryptedStore(store, keys, logger)
+	} else if reencrypt {
+		fmt.Fprintf(os.Stderr, "No encryption keys are configured\n")
+		os.Exit(6)
+	}
+
+	var secretStore broker.SecretStore
+	if conf.SecretStore != nil {
+		if conf.SecretStore.Type == "file" {
+			secretStore, err = secretsfile.NewFileSecretStore(logger, conf.SecretStore.Parameters)
+		} else if conf.SecretStore.Type == "credhub" {
+			secretStore, err = credhub.NewCredHubSecretStore(logger, conf.SecretStore.Parameters)
+		} else {
+			err = fmt.Errorf("The secret store %s is not supported", conf.SecretStore.Type)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting up the secret store: %s\n", err)
+			os.Exit(7)
+		}
+	}
 
-	clientFactory := broker.NewClientFactory(logger)
-	s, err := broker.CreateServer(planMap, &conf, clientFactory, logger, store)
+	s, err := broker.CreateServer(planMap, &conf, clientFactory, logger, store, secretStore)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting the server: %s\n", err)
 		os.Exit(4)
@@ -15,54 +15,169 @@
 package perinstance
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 
 	"github.com/stardog-union/service-broker/broker"
 )
 
 type perInstancePlanFactory struct {
-	planIDStr string
-	logger    broker.SdLogger
+	TLS             *broker.StardogTLSConfig      `json:"tls,omitempty"`
+	Passwords       *broker.PasswordPolicy        `json:"password_policy,omitempty"`
+	Retry           *broker.RetryPolicy           `json:"retry,omitempty"`
+	DatabaseOptions *broker.DatabaseOptionsPolicy `json:"database_options,omitempty"`
+	planIDStr       string
+	passwords       *broker.PasswordGenerator
+	retrier         *broker.Retrier
+	options         *broker.DatabaseOptions
+	logger          broker.SdLogger
+	schemas         *broker.PlanSchemas
 }
 
+const createSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"properties": {
+		"url": {
+			"description": "The URL of the Stardog server.",
+			"type": "string",
+			"pattern": "^https?://"
+		},
+		"username": {
+			"description": "The name of a Stardog user that can create databases and users.  The default is admin.",
+			"type": "string"
+		},
+		"password": {
+			"description": "The password of the Stardog user.",
+			"type": "string",
+			"minLength": 1
+		},
+		"db_name": {
+			"description": "The name of the database.  A random name is used if it is not set.",
+			"type": "string",
+			"pattern": "^[A-Za-z][A-Za-z0-9_-]*$",
+			"maxLength": 64
+		},
+		"database_options": {
+			"description": "Stardog database options to set on the database.",
+			"type": "object"
+		}
+	},
+	"required": ["url", "password"],
+	"additionalProperties": false
+}`
+
+const updateSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"properties": {
+		"url": {
+			"description": "The URL of the Stardog server.",
+			"type": "string",
+			"pattern": "^https?://"
+		},
+		"username": {
+			"description": "The name of a Stardog user that can create databases and users.",
+			"type": "string"
+		},
+		"password": {
+			"description": "The password of the Stardog user.",
+			"type": "string",
+			"minLength": 1
+		},
+		"db_name": {
+			"description": "The name of the database.  It cannot be changed.",
+			"type": "string"
+		},
+		"database_options": {
+			"description": "Stardog database options to set on the database.",
+			"type": "object"
+		}
+	},
+	"additionalProperties": false
+}`
+
+const bindSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"properties": {
+		"username": {
+			"description": "The name of the Stardog user to create.  A random name is used if it is not set.",
+			"type": "string",
+			"pattern": "^[A-Za-z0-9][A-Za-z0-9_.@-]*$",
+			"maxLength": 64
+		},
+		"password": {
+			"description": "The password of the Stardog user.  A random password is used if it is not set.",
+			"type": "string",
+			"minLength": 1
+		},
+		"access": {
+			"description": "The access the user has to the database.  The default is write.",
+			"type": "string",
+			"enum": ["read", "write", "admin"]
+		}
+	},
+	"additionalProperties": false
+}`
+
 type createServiceParameters struct {
-	DbName     string `json:"db_name"`
-	StardogURL string `json:"url"`
-	Password   string `json:"password"`
-	Username   string `json:"username"`
+	DbName          string                 `json:"db_name"`
+	StardogURL      string                 `json:"url"`
+	Password        string                 `json:"password"`
+	Username        string                 `json:"username"`
+	DatabaseOptions map[string]interface{} `json:"database_options,omitempty"`
+	// Roles is set on instances whose bound users are assigned to the
+	// instance's roles.  Older instances grant permissions to each user.
+	Roles bool `json:"roles,omitempty"`
 }
 
 type perInstanceDatabasePlan struct {
 	planID        string
+	passwords     *broker.PasswordGenerator
+	options       *broker.DatabaseOptions
 	clientFactory broker.StardogClientFactory
 	logger        broker.SdLogger
 	param         createServiceParameters
 }
 
 // NewDatabaseBindResponse is the response document that is returned from the Bind call
-type BindResponse struct {
-	DbName     string `json:"db_name"`
-	StardogURL string `json:"url"`
-	Password   string `json:"password"`
-	Username   string `json:"username"`
-}
-
-type newDatabaseBindParameters struct {
-	Password string `json:"password, omitempty"`
-	Username string `json:"username, omitempty"`
-}
+type BindResponse broker.UserBinding
 
 // GetPlanFactory returns a PlanFactory for the shared database plan
 func GetPlanFactory(planID string, params interface{}) (broker.PlanFactory, error) {
 	var dbPlan perInstancePlanFactory
 
-	// It is currently empty but leaving as a placeholder
 	err := broker.ReSerializeInterface(params, &dbPlan)
 	if err != nil {
 		return nil, err
 	}
+	if dbPlan.TLS != nil {
+		// Fail on startup rather than on the first request if the
+		// certificates cannot be loaded
+		_, err = broker.NewStardogTLSConfig(dbPlan.TLS)
+		if err != nil {
+			return nil, err
+		}
+	}
+	dbPlan.passwords, err = broker.NewPasswordGenerator(dbPlan.Passwords)
+	if err != nil {
+		return nil, err
+	}
+	dbPlan.retrier, err = broker.NewRetrier(dbPlan.Retry)
+	if err != nil {
+		return nil, err
+	}
+	dbPlan.options, err = broker.NewDatabaseOptions(dbPlan.DatabaseOptions)
+	if err != nil {
+		return nil, err
+	}
 	dbPlan.planIDStr = planID
+	dbPlan.schemas, err = broker.NewPlanSchemas(createSchema, updateSchema, bindSchema)
+	if err != nil {
+		return nil, err
+	}
+	dbPlan.options.AddToSchemas(dbPlan.schemas)
 	return &dbPlan, nil
 }
 
@@ -74,19 +189,23 @@ func (df *perInstancePlanFactory) InflatePlan(instanceParams interface{}, client
 	}
 
 	if serviceParams.StardogURL == "" {
-		return nil, fmt.Errorf("A Stardog URL is required")
+		return nil, broker.NewBadRequestError("A Stardog URL is required")
 	}
 	if serviceParams.Password == "" {
-		return nil, fmt.Errorf("An admin password is required")
+		return nil, broker.NewBadRequestError("An admin password is required")
 	}
 	if serviceParams.Username == "" {
 		serviceParams.Username = "admin"
 	}
-	if serviceParams.DbName == "" {
-		serviceParams.DbName = broker.GetRandomName("db", 16)
+	clientFactory, err = broker.ClientFactoryWithTLS(clientFactory, df.TLS)
+	if err != nil {
+		return nil, err
 	}
+	clientFactory = broker.ClientFactoryWithRetrier(clientFactory, df.retrier)
 	p := &perInstanceDatabasePlan{
 		planID:        df.PlanID(),
+		passwords:     df.passwords,
+		options:       df.options,
 		clientFactory: clientFactory,
 		logger:        logger,
 		param:         serviceParams,
@@ -118,113 +237,145 @@ func (df *perInstancePlanFactory) Bindable() bool {
 	return true
 }
 
-func (p *perInstanceDatabasePlan) CreateServiceInstance() (int, interface{}, error) {
-	client := p.clientFactory.GetStardogAdminClient(
-		p.param.StardogURL,
-		broker.DatabaseCredentials{
-			Username: p.param.Username,
-			Password: p.param.Password})
+func (df *perInstancePlanFactory) Schemas() *broker.PlanSchemas {
+	return df.schemas
+}
+
+func (df *perInstancePlanFactory) PlanUpdateable() bool {
+	return true
+}
+
+// SecretParameters lists the credentials of the Stardog server, which must
+// not be returned to clients that look up the instance.
+func (df *perInstancePlanFactory) SecretParameters() []string {
+	return []string{"username", "password"}
+}
+
+func (p *perInstanceDatabasePlan) CreateServiceInstance(ctx context.Context, requestContext *broker.RequestContext) (int, interface{}, error) {
+	if p.param.DbName == "" {
+		p.param.DbName = broker.GetRandomName(requestContext.NamePrefix("db"), 16)
+	}
+	err := broker.ValidateDatabaseName(p.param.DbName)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	p.param.DatabaseOptions, err = p.options.CreateOptions(p.param.DatabaseOptions)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
 
 	// Create an instance database for storing bindings
-	err := client.CreateDatabase(p.param.DbName)
+	code, err := p.access(p.param).CreateDatabase(ctx, p.param.DatabaseOptions)
 	if err != nil {
-		return http.StatusInternalServerError, nil, err
+		return code, nil, err
 	}
+	p.param.Roles = true
 	return http.StatusCreated, p.param, nil
 }
 
-func (p *perInstanceDatabasePlan) RemoveInstance() (int, interface{}, error) {
+func (p *perInstanceDatabasePlan) RemoveInstance(ctx context.Context) (int, interface{}, error) {
 	// Delete the db if it was created
-	client := p.clientFactory.GetStardogAdminClient(
-		p.param.StardogURL,
-		broker.DatabaseCredentials{
-			Username: p.param.Username,
-			Password: p.param.Password})
-	err := client.DeleteDatabase(p.param.DbName)
+	code, err := p.access(p.param).DeleteDatabase(ctx)
 	if err != nil {
-		return http.StatusInternalServerError, nil, err
+		return code, nil, err
 	}
 	return http.StatusOK, &broker.CreateGetServiceInstanceResponse{}, nil
 }
 
-func (p *perInstanceDatabasePlan) Bind(parameters interface{}) (int, interface{}, error) {
-	var params newDatabaseBindParameters
-
+// UpdateServiceInstance allows the Stardog server information to change,
+// for example when the admin password is rotated, and sets new options on
+// the database.  The database must be reachable with the new settings.
+func (p *perInstanceDatabasePlan) UpdateServiceInstance(ctx context.Context, parameters interface{}) (int, interface{}, error) {
+	var params createServiceParameters
 	err := broker.ReSerializeInterface(parameters, &params)
 	if err != nil {
-		return http.StatusBadRequest, nil, fmt.Errorf("The parameters were not properly formed")
+		return http.StatusBadRequest, nil, broker.NewBadRequestError("The parameters were not properly formed")
 	}
-
-	if params.Username == "" {
-		params.Username = broker.GetRandomName("stardog", 8)
+	if params.DbName != "" && params.DbName != p.param.DbName {
+		return http.StatusBadRequest, nil, broker.NewBadRequestError("The database name cannot be changed")
 	}
-	if params.Password == "" {
-		params.Password = broker.GetRandomName("", 24)
+	err = p.options.Validate(params.DatabaseOptions)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
 	}
 
-	client := p.clientFactory.GetStardogAdminClient(
-		p.param.StardogURL,
-		broker.DatabaseCredentials{
-			Username: p.param.Username,
-			Password: p.param.Password})
-	responseCred := BindResponse{
-		Username:   params.Username,
-		Password:   params.Password,
-		DbName:     p.param.DbName,
-		StardogURL: p.param.StardogURL,
+	newParam := p.param
+	if params.StardogURL != "" {
+		newParam.StardogURL = params.StardogURL
 	}
-
-	e, err := client.UserExists(responseCred.Username)
-	if err != nil {
-		p.logger.Logf(broker.WARN, "UserExists check failed: %s", err)
-		return http.StatusInternalServerError, nil, fmt.Errorf("UserExists check failed")
+	if params.Username != "" {
+		newParam.Username = params.Username
 	}
-	if e {
-		return http.StatusConflict, nil, fmt.Errorf("Failed to create the user because %s already exists", responseCred.Username)
+	if params.Password != "" {
+		newParam.Password = params.Password
 	}
-	err = client.NewUser(responseCred.Username, responseCred.Password)
+	access := p.access(newParam)
+	_, err = access.Client.GetDatabaseSize(ctx, newParam.DbName)
 	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to create the user %s", err)
-		return http.StatusInternalServerError, nil, fmt.Errorf("Failed to create the user")
+		p.logger.Logf(broker.WARN, "Failed to reach %s with the new settings: %s", newParam.DbName, err)
+		if broker.IsStardogAuthFailure(err) {
+			return http.StatusBadRequest, nil, broker.NewBadRequestError("The Stardog server %s rejected the new username and password", newParam.StardogURL)
+		}
+		return http.StatusBadRequest, nil, broker.NewBadRequestError("The database %s could not be reached with the new settings", newParam.DbName)
 	}
-	err = client.GrantUserAccessToDb(responseCred.DbName, responseCred.Username)
+	err = access.Client.SetDatabaseOptions(ctx, newParam.DbName, params.DatabaseOptions)
 	if err != nil {
-		p.logger.Logf(broker.INFO, "Failed to grant access on %s to the user %s: %s", responseCred.DbName, responseCred.Username, err)
-		return http.StatusInternalServerError, nil, fmt.Errorf("Failed to grant access on %s to the user %s", responseCred.DbName, responseCred.Username)
+		p.logger.Logf(broker.WARN, "Failed to set the options on %s: %s", newParam.DbName, err)
+		return http.StatusInternalServerError, nil, access.StardogError(err)
 	}
-	return http.StatusOK, &responseCred, nil
+	newParam.DatabaseOptions = broker.MergeOptions(p.param.DatabaseOptions, params.DatabaseOptions)
+	p.param = newParam
+	return http.StatusOK, p.param, nil
 }
 
-func (p *perInstanceDatabasePlan) UnBind(binding interface{}) (int, error) {
-	var bindResponse BindResponse
-	client := p.clientFactory.GetStardogAdminClient(p.param.StardogURL,
-		broker.DatabaseCredentials{
-			Username: p.param.Username,
-			Password: p.param.Password})
+// CanMoveTo allows instances to move between perinstance plans since the
+// server information is kept with the instance.
+func (p *perInstanceDatabasePlan) CanMoveTo(planFactory broker.PlanFactory) bool {
+	_, ok := planFactory.(*perInstancePlanFactory)
+	return ok
+}
 
-	err := broker.ReSerializeInterface(binding, &bindResponse)
+func (p *perInstanceDatabasePlan) Bind(ctx context.Context, requestContext *broker.RequestContext, parameters interface{}) (int, interface{}, error) {
+	code, binding, err := p.access(p.param).Bind(ctx, requestContext, parameters)
 	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to inflate the parameters %s", err)
-		return http.StatusInternalServerError, err
-	}
-	err = client.RevokeUserAccess(bindResponse.DbName, bindResponse.Username)
-	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to revoke user accesss %s", err)
-		return http.StatusInternalServerError, err
+		return code, nil, err
 	}
+	return code, (*BindResponse)(binding), nil
+}
 
-	err = client.DeleteUser(bindResponse.Username)
-	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to delete user %s: %s", bindResponse.Username, err)
-		return http.StatusInternalServerError, err
+func (p *perInstanceDatabasePlan) UnBind(ctx context.Context, binding interface{}) (int, error) {
+	return p.access(p.param).UnBind(ctx, binding)
+}
+
+// access connects to Stardog with the server information of the instance.
+// It comes from the platform so the platform is told when Stardog rejects
+// the credentials.
+func (p *perInstanceDatabasePlan) access(param createServiceParameters) *broker.InstanceAccess {
+	return &broker.InstanceAccess{
+		Client: p.clientFactory.GetStardogAdminClient(
+			param.StardogURL,
+			broker.DatabaseCredentials{
+				Username: param.Username,
+				Password: param.Password}),
+		DbName:     param.DbName,
+		StardogURL: param.StardogURL,
+		Roles:      param.Roles,
+		Passwords:  p.passwords,
+		Logger:     p.logger,
+		AuthFailure: func(err error) error {
+			return broker.NewBadRequestError("The Stardog server %s rejected the username and password of the instance", param.StardogURL)
+		},
 	}
-	return http.StatusOK, nil
 }
 
 func (p *perInstanceDatabasePlan) PlanID() string {
 	return p.planID
 }
 
+func (p *perInstanceDatabasePlan) DashboardURL() string {
+	return p.param.StardogURL
+}
+
 func (p *perInstanceDatabasePlan) EqualInstance(requestParamsI interface{}) bool {
 	var bindResponse BindResponse
 
@@ -237,17 +388,5 @@ func (p *perInstanceDatabasePlan) EqualInstance(requestParamsI interface{}) bool
 }
 
 func (p *perInstanceDatabasePlan) EqualBinding(bindInstance *broker.BindInstance, bindRequest *broker.BindRequest) bool {
-	var bindParams newDatabaseBindParameters
-
-	err := broker.ReSerializeInterface(bindRequest.Parameters, &bindParams)
-	if err != nil {
-		return false
-	}
-	var bindInstanceParams BindResponse
-	err = broker.ReSerializeInterface(bindInstance.PlanParams, &bindInstanceParams)
-	if err != nil {
-		return false
-	}
-
-	return bindParams.Password == bindInstanceParams.Password && bindParams.Username == bindInstanceParams.Username
+	return broker.EqualUserBinding(bindInstance, bindRequest)
 }
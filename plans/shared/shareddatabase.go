@@ -16,22 +16,89 @@
 package shared
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"net/http"
 
 	"github.com/stardog-union/service-broker/broker"
 )
 
 type dataBasePlanFactory struct {
-	StardogURL string `json:"stardog_url"`
-	AdminName  string `json:"admin_username"`
-	AdminPw    string `json:"admin_password"`
-	planIDStr  string
+	StardogURL      string                        `json:"stardog_url"`
+	AdminName       string                        `json:"admin_username"`
+	AdminPw         string                        `json:"admin_password"`
+	TLS             *broker.StardogTLSConfig      `json:"tls,omitempty"`
+	Passwords       *broker.PasswordPolicy        `json:"password_policy,omitempty"`
+	Retry           *broker.RetryPolicy           `json:"retry,omitempty"`
+	DatabaseOptions *broker.DatabaseOptionsPolicy `json:"database_options,omitempty"`
+	planIDStr       string
+	schemas         *broker.PlanSchemas
+	passwords       *broker.PasswordGenerator
+	retrier         *broker.Retrier
+	options         *broker.DatabaseOptions
 }
 
+const createSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"properties": {
+		"db_name": {
+			"description": "The name of the database.  A random name is used if it is not set.",
+			"type": "string",
+			"pattern": "^[A-Za-z][A-Za-z0-9_-]*$",
+			"maxLength": 64
+		},
+		"database_options": {
+			"description": "Stardog database options to set on the database.",
+			"type": "object"
+		}
+	},
+	"additionalProperties": false
+}`
+
+const updateSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"properties": {
+		"db_name": {
+			"description": "The name of the database.  It cannot be changed.",
+			"type": "string"
+		},
+		"database_options": {
+			"description": "Stardog database options to set on the database.",
+			"type": "object"
+		}
+	},
+	"additionalProperties": false
+}`
+
+const bindSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"properties": {
+		"username": {
+			"description": "The name of the Stardog user to create.  A random name is used if it is not set.",
+			"type": "string",
+			"pattern": "^[A-Za-z0-9][A-Za-z0-9_.@-]*$",
+			"maxLength": 64
+		},
+		"password": {
+			"description": "The password of the Stardog user.  A random password is used if it is not set.",
+			"type": "string",
+			"minLength": 1
+		},
+		"access": {
+			"description": "The access the user has to the database.  The default is write.",
+			"type": "string",
+			"enum": ["read", "write", "admin"]
+		}
+	},
+	"additionalProperties": false
+}`
+
 type serviceParameters struct {
-	DbName string `json:"db_name"`
+	DbName          string                 `json:"db_name"`
+	DatabaseOptions map[string]interface{} `json:"database_options,omitempty"`
+	Roles           bool                   `json:"roles,omitempty"`
 }
 
 type newDatabasePlan struct {
@@ -40,26 +107,22 @@ type newDatabasePlan struct {
 	adminPw       string
 	params        newDatabasePlanParameters
 	planID        string
+	passwords     *broker.PasswordGenerator
+	options       *broker.DatabaseOptions
 	clientFactory broker.StardogClientFactory
 	logger        broker.SdLogger
 }
 
 type newDatabasePlanParameters struct {
-	DbName string `json:"db_name"`
+	DbName          string                 `json:"db_name"`
+	DatabaseOptions map[string]interface{} `json:"database_options,omitempty"`
+	// Roles is set on instances whose bound users are assigned to the
+	// instance's roles.  Older instances grant permissions to each user.
+	Roles bool `json:"roles,omitempty"`
 }
 
 // NewDatabaseBindResponse is the response document that is returned from the Bind call
-type NewDatabaseBindResponse struct {
-	DbName     string `json:"db_name"`
-	StardogURL string `json:"url"`
-	Password   string `json:"password"`
-	Username   string `json:"username"`
-}
-
-type newDatabaseBindParameters struct {
-	Password string `json:"password, omitempty"`
-	Username string `json:"username, omitempty"`
-}
+type NewDatabaseBindResponse broker.UserBinding
 
 // GetPlanFactory returns a PlanFactory for the shared database plan
 func GetPlanFactory(planID string, params interface{}) (broker.PlanFactory, error) {
@@ -69,7 +132,32 @@ func GetPlanFactory(planID string, params interface{}) (broker.PlanFactory, erro
 	if err != nil {
 		return nil, err
 	}
+	if dbPlan.TLS != nil {
+		// Fail on startup rather than on the first request if the
+		// certificates cannot be loaded
+		_, err = broker.NewStardogTLSConfig(dbPlan.TLS)
+		if err != nil {
+			return nil, err
+		}
+	}
+	dbPlan.passwords, err = broker.NewPasswordGenerator(dbPlan.Passwords)
+	if err != nil {
+		return nil, err
+	}
+	dbPlan.retrier, err = broker.NewRetrier(dbPlan.Retry)
+	if err != nil {
+		return nil, err
+	}
+	dbPlan.options, err = broker.NewDatabaseOptions(dbPlan.DatabaseOptions)
+	if err != nil {
+		return nil, err
+	}
 	dbPlan.planIDStr = planID
+	dbPlan.schemas, err = broker.NewPlanSchemas(createSchema, updateSchema, bindSchema)
+	if err != nil {
+		return nil, err
+	}
+	dbPlan.options.AddToSchemas(dbPlan.schemas)
 	return &dbPlan, nil
 }
 
@@ -79,15 +167,26 @@ func (df *dataBasePlanFactory) InflatePlan(instanceParams interface{}, clientFac
 	if err != nil {
 		return nil, err
 	}
+	clientFactory, err = broker.ClientFactoryWithTLS(clientFactory, df.TLS)
+	if err != nil {
+		return nil, err
+	}
+	clientFactory = broker.ClientFactoryWithRetrier(clientFactory, df.retrier)
 
 	p := &newDatabasePlan{
 		url:           df.StardogURL,
 		adminName:     df.AdminName,
 		adminPw:       df.AdminPw,
 		planID:        df.PlanID(),
+		passwords:     df.passwords,
+		options:       df.options,
 		clientFactory: clientFactory,
 		logger:        logger,
-		params:        newDatabasePlanParameters{DbName: serviceParams.DbName},
+		params: newDatabasePlanParameters{
+			DbName:          serviceParams.DbName,
+			DatabaseOptions: serviceParams.DatabaseOptions,
+			Roles:           serviceParams.Roles,
+		},
 	}
 	return p, nil
 }
@@ -117,129 +216,128 @@ func (df *dataBasePlanFactory) Bindable() bool {
 	return true
 }
 
-func (p *newDatabasePlan) CreateServiceInstance() (int, interface{}, error) {
+func (df *dataBasePlanFactory) Schemas() *broker.PlanSchemas {
+	return df.schemas
+}
+
+func (df *dataBasePlanFactory) PlanUpdateable() bool {
+	return true
+}
+
+func (p *newDatabasePlan) CreateServiceInstance(ctx context.Context, requestContext *broker.RequestContext) (int, interface{}, error) {
 	if p.params.DbName == "" {
-		p.params.DbName = broker.GetRandomName("db", 16)
+		p.params.DbName = broker.GetRandomName(requestContext.NamePrefix("db"), 16)
 	}
-	outParams := serviceParameters{DbName: p.params.DbName}
-
-	client := p.clientFactory.GetStardogAdminClient(
-		p.url,
-		broker.DatabaseCredentials{
-			Username: p.adminName,
-			Password: p.adminPw})
+	err := broker.ValidateDatabaseName(p.params.DbName)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	options, err := p.options.CreateOptions(p.params.DatabaseOptions)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	outParams := serviceParameters{DbName: p.params.DbName, DatabaseOptions: options}
 
 	// Create an instance database for storing bindings
-	err := client.CreateDatabase(outParams.DbName)
+	code, err := p.access().CreateDatabase(ctx, outParams.DatabaseOptions)
 	if err != nil {
-		return http.StatusInternalServerError, nil, err
+		return code, nil, err
 	}
+	p.params.DatabaseOptions = options
+	p.params.Roles = true
+	outParams.Roles = true
 	return http.StatusCreated, outParams, nil
 }
 
-func (p *newDatabasePlan) RemoveInstance() (int, interface{}, error) {
+func (p *newDatabasePlan) RemoveInstance(ctx context.Context) (int, interface{}, error) {
 	// Delete the db if it was created
-	client := p.clientFactory.GetStardogAdminClient(
-		p.url,
-		broker.DatabaseCredentials{
-			Username: p.adminName,
-			Password: p.adminPw})
-	err := client.DeleteDatabase(p.params.DbName)
+	code, err := p.access().DeleteDatabase(ctx)
 	if err != nil {
-		return http.StatusInternalServerError, nil, err
+		return code, nil, err
 	}
 	return http.StatusOK, &broker.CreateGetServiceInstanceResponse{}, nil
 }
 
-func (p *newDatabasePlan) Bind(parameters interface{}) (int, interface{}, error) {
-	var params newDatabaseBindParameters
-
+// UpdateServiceInstance sets new options on the instance's database.  The
+// database name cannot change since that would mean losing its data.
+func (p *newDatabasePlan) UpdateServiceInstance(ctx context.Context, parameters interface{}) (int, interface{}, error) {
+	var params serviceParameters
 	err := broker.ReSerializeInterface(parameters, &params)
 	if err != nil {
-		return http.StatusBadRequest, nil, fmt.Errorf("The parameters were not properly formed")
-	}
-
-	if params.Username == "" {
-		params.Username = broker.GetRandomName("stardog", 8)
+		return http.StatusBadRequest, nil, broker.NewBadRequestError("The parameters were not properly formed")
 	}
-	if params.Password == "" {
-		params.Password = broker.GetRandomName("", 24)
+	if params.DbName != "" && params.DbName != p.params.DbName {
+		return http.StatusBadRequest, nil, broker.NewBadRequestError("The database name cannot be changed")
 	}
-
-	client := p.clientFactory.GetStardogAdminClient(
-		p.url,
-		broker.DatabaseCredentials{
-			Username: p.adminName,
-			Password: p.adminPw})
-	responseCred := NewDatabaseBindResponse{
-		Username:   params.Username,
-		Password:   params.Password,
-		DbName:     p.params.DbName,
-		StardogURL: p.url,
-	}
-
-	e, err := client.UserExists(responseCred.Username)
+	err = p.options.Validate(params.DatabaseOptions)
 	if err != nil {
-		p.logger.Logf(broker.WARN, "UserExists check failed: %s", err)
-		return http.StatusInternalServerError, nil, fmt.Errorf("UserExists check failed")
-	}
-	if e {
-		return http.StatusConflict, nil, fmt.Errorf("Failed to create the user because %s already exists", responseCred.Username)
+		return http.StatusBadRequest, nil, err
 	}
-	err = client.NewUser(responseCred.Username, responseCred.Password)
+
+	access := p.access()
+	err = access.Client.SetDatabaseOptions(ctx, p.params.DbName, params.DatabaseOptions)
 	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to create the user %s", err)
-		return http.StatusInternalServerError, nil, fmt.Errorf("Failed to create the user")
+		p.logger.Logf(broker.WARN, "Failed to set the options on %s: %s", p.params.DbName, err)
+		return http.StatusInternalServerError, nil, access.StardogError(err)
 	}
-	err = client.GrantUserAccessToDb(responseCred.DbName, responseCred.Username)
-	if err != nil {
-		p.logger.Logf(broker.INFO, "Failed to grant access on %s to the user %s: %s", responseCred.DbName, responseCred.Username, err)
-		return http.StatusInternalServerError, nil, fmt.Errorf("Failed to grant access on %s to the user %s", responseCred.DbName, responseCred.Username)
+	p.params.DatabaseOptions = broker.MergeOptions(p.params.DatabaseOptions, params.DatabaseOptions)
+	outParams := serviceParameters{
+		DbName:          p.params.DbName,
+		DatabaseOptions: p.params.DatabaseOptions,
+		Roles:           p.params.Roles,
 	}
-	return http.StatusOK, &responseCred, nil
+	return http.StatusOK, outParams, nil
 }
 
-func bindingInflate(binding interface{}) (*NewDatabaseBindResponse, error) {
-	b, err := json.Marshal(binding)
-	if err != nil {
-		return nil, err
-	}
-	var bp NewDatabaseBindResponse
-	err = json.Unmarshal(b, &bp)
-	if err != nil {
-		return nil, err
-	}
-	return &bp, nil
+// CanMoveTo allows instances to move between shared database plans that
+// use the same Stardog server.
+func (p *newDatabasePlan) CanMoveTo(planFactory broker.PlanFactory) bool {
+	target, ok := planFactory.(*dataBasePlanFactory)
+	return ok && target.StardogURL == p.url
 }
 
-func (p *newDatabasePlan) UnBind(binding interface{}) (int, error) {
-	client := p.clientFactory.GetStardogAdminClient(p.url,
-		broker.DatabaseCredentials{
-			Username: p.adminName,
-			Password: p.adminPw})
-	serviceBinding, err := bindingInflate(binding)
-	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to inflate the parameters %s", err)
-		return http.StatusInternalServerError, err
-	}
-	err = client.RevokeUserAccess(serviceBinding.DbName, serviceBinding.Username)
+func (p *newDatabasePlan) Bind(ctx context.Context, requestContext *broker.RequestContext, parameters interface{}) (int, interface{}, error) {
+	code, binding, err := p.access().Bind(ctx, requestContext, parameters)
 	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to revoke user accesss %s", err)
-		return http.StatusInternalServerError, err
+		return code, nil, err
 	}
+	return code, (*NewDatabaseBindResponse)(binding), nil
+}
 
-	err = client.DeleteUser(serviceBinding.Username)
-	if err != nil {
-		p.logger.Logf(broker.WARN, "Failed to delete user %s: %s", serviceBinding.Username, err)
-		return http.StatusInternalServerError, err
+func (p *newDatabasePlan) UnBind(ctx context.Context, binding interface{}) (int, error) {
+	return p.access().UnBind(ctx, binding)
+}
+
+// access connects to Stardog with the admin credentials of the plan.
+// Stardog rejecting them means that the plan is misconfigured, which the
+// platform cannot fix.
+func (p *newDatabasePlan) access() *broker.InstanceAccess {
+	return &broker.InstanceAccess{
+		Client: p.clientFactory.GetStardogAdminClient(
+			p.url,
+			broker.DatabaseCredentials{
+				Username: p.adminName,
+				Password: p.adminPw}),
+		DbName:     p.params.DbName,
+		StardogURL: p.url,
+		Roles:      p.params.Roles,
+		Passwords:  p.passwords,
+		Logger:     p.logger,
+		AuthFailure: func(err error) error {
+			p.logger.Logf(broker.ERROR, "The Stardog server of the plan %s rejected its admin credentials: %s", p.planID, err)
+			return broker.NewBrokerError(http.StatusInternalServerError, "", "The Stardog server %s rejected the admin credentials of the plan %s", p.url, p.planID)
+		},
 	}
-	return http.StatusOK, nil
 }
 
 func (p *newDatabasePlan) PlanID() string {
 	return p.planID
 }
 
+func (p *newDatabasePlan) DashboardURL() string {
+	return p.url
+}
+
 func (p *newDatabasePlan) EqualInstance(requestParamsI interface{}) bool {
 	var requestParams newDatabasePlanParameters
 
@@ -252,17 +350,5 @@ func (p *newDatabasePlan) EqualInstance(requestParamsI interface{}) bool {
 }
 
 func (p *newDatabasePlan) EqualBinding(bindInstance *broker.BindInstance, bindRequest *broker.BindRequest) bool {
-	var bindParams newDatabaseBindParameters
-
-	err := broker.ReSerializeInterface(bindRequest.Parameters, &bindParams)
-	if err != nil {
-		return false
-	}
-	var bindInstanceParams NewDatabaseBindResponse
-	err = broker.ReSerializeInterface(bindInstance.PlanParams, &bindInstanceParams)
-	if err != nil {
-		return false
-	}
-
-	return bindParams.Password == bindInstanceParams.Password && bindParams.Username == bindInstanceParams.Username
+	return broker.EqualUserBinding(bindInstance, bindRequest)
 }
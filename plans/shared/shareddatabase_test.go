@@ -16,10 +16,12 @@
 package shared
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stardog-union/service-broker/broker"
@@ -38,8 +40,14 @@ type fakeClientFactory struct {
 	deleteUser []fakeClientCommands
 	grantUser  []fakeClientCommands
 	revokeUser []fakeClientCommands
+	setOptions []fakeClientCommands
+	createRole []fakeClientCommands
+	deleteRole []fakeClientCommands
+	grantRole  []fakeClientCommands
+	assignRole []fakeClientCommands
 
 	failures          map[string]bool
+	stardogErrors     map[string]*broker.StardogError
 	userExistResponse bool
 }
 
@@ -52,7 +60,9 @@ func createFakeClientFactory(userExistsResponse bool, failures ...string) *fakeC
 	cf.deleteUser = make([]fakeClientCommands, 0, 10)
 	cf.grantUser = make([]fakeClientCommands, 0, 10)
 	cf.revokeUser = make([]fakeClientCommands, 0, 10)
+	cf.setOptions = make([]fakeClientCommands, 0, 10)
 	cf.failures = make(map[string]bool)
+	cf.stardogErrors = make(map[string]*broker.StardogError)
 	cf.userExistResponse = userExistsResponse
 	for _, f := range failures {
 		cf.failures[f] = true
@@ -68,41 +78,63 @@ type fakeClientCommands struct {
 	dbName   string
 	username string
 	pw       string
+	options  map[string]interface{}
+	actions  []string
+	role     string
+	ctxErr   error
 }
 
 type fakeClient struct {
 	factory *fakeClientFactory
 }
 
-func (c *fakeClient) CreateDatabase(dbName string) error {
-	c.factory.createDb = append(c.factory.createDb, fakeClientCommands{dbName: dbName})
+func (c *fakeClient) CreateDatabase(ctx context.Context, dbName string, options map[string]interface{}) error {
+	c.factory.createDb = append(c.factory.createDb, fakeClientCommands{dbName: dbName, options: options})
 	if c.factory.failures["CreateDatabase"] {
 		return fmt.Errorf("Mock test forced error")
 	}
+	if se, ok := c.factory.stardogErrors["CreateDatabase"]; ok {
+		return se
+	}
 	return nil
 }
 
-func (c *fakeClient) GetDatabaseSize(dbName string) (int, error) {
+func (c *fakeClient) GetDatabaseSize(ctx context.Context, dbName string) (int, error) {
 	return 0, nil
 }
 
-func (c *fakeClient) AddData(dbName string, format string, data string) error {
+func (c *fakeClient) AddData(ctx context.Context, dbName string, format string, data string) error {
 	return nil
 }
 
-func (c *fakeClient) Query(dbName string, data string) ([]byte, error) {
+func (c *fakeClient) Query(ctx context.Context, dbName string, data string) ([]byte, error) {
 	return nil, nil
 }
 
-func (c *fakeClient) DeleteDatabase(dbName string) error {
+func (c *fakeClient) Update(ctx context.Context, dbName string, query string) error {
+	return nil
+}
+
+func (c *fakeClient) DeleteDatabase(ctx context.Context, dbName string) error {
 	c.factory.deleteDb = append(c.factory.deleteDb, fakeClientCommands{dbName: dbName})
 	if c.factory.failures["DeleteDatabase"] {
 		return fmt.Errorf("Mock test forced error")
 	}
+	if se, ok := c.factory.stardogErrors["DeleteDatabase"]; ok {
+		return se
+	}
+	return nil
+}
+
+func (c *fakeClient) SetDatabaseOptions(ctx context.Context, dbName string, options map[string]interface{}) error {
+	c.factory.setOptions = append(c.factory.setOptions, fakeClientCommands{dbName: dbName, options: options})
+	if c.factory.failures["SetDatabaseOptions"] {
+		return fmt.Errorf("Mock test forced error")
+	}
 	return nil
 }
 
-func (c *fakeClient) UserExists(username string) (bool, error) {
+func (c *fakeClient) UserExists(ctx context.Context, username string) (bool, error) {
 	c.factory.userExists = append(c.factory.userExists, fakeClientCommands{username: username})
 	if c.factory.failures["UserExists"] {
 		return false, fmt.Errorf("Mock test forced error")
@@ -110,33 +142,71 @@ func (c *fakeClient) UserExists(username string) (bool, error) {
 	return c.factory.userExistResponse, nil
 }
 
-func (c *fakeClient) NewUser(username string, pw string) error {
+func (c *fakeClient) NewUser(ctx context.Context, username string, pw string) error {
 	c.factory.newUser = append(c.factory.newUser, fakeClientCommands{username: username, pw: pw})
 	if c.factory.failures["NewUser"] {
 		return fmt.Errorf("Mock test forced error")
 	}
+	if se, ok := c.factory.stardogErrors["NewUser"]; ok {
+		return se
+	}
 	return nil
 }
 
-func (c *fakeClient) DeleteUser(username string) error {
-	c.factory.deleteUser = append(c.factory.deleteUser, fakeClientCommands{username: username})
+func (c *fakeClient) DeleteUser(ctx context.Context, username string) error {
+	c.factory.deleteUser = append(c.factory.deleteUser, fakeClientCommands{username: username, ctxErr: ctx.Err()})
 	if c.factory.failures["DeleteUser"] {
 		return fmt.Errorf("Mock test forced error")
 	}
+	if se, ok := c.factory.stardogErrors["DeleteUser"]; ok {
+		return se
+	}
+	return nil
+}
+
+func (c *fakeClient) GrantUserPermissions(ctx context.Context, dbName string, username string, actions []string) error {
+	c.factory.grantUser = append(c.factory.grantUser, fakeClientCommands{dbName: dbName, username: username, actions: actions})
+	if c.factory.failures["GrantUserPermissions"] {
+		return fmt.Errorf("Mock test forced error")
+	}
+	return nil
+}
+
+func (c *fakeClient) CreateRole(ctx context.Context, role string) error {
+	c.factory.createRole = append(c.factory.createRole, fakeClientCommands{role: role})
+	if c.factory.failures["CreateRole"] {
+		return fmt.Errorf("Mock test forced error")
+	}
+	return nil
+}
+
+func (c *fakeClient) DeleteRole(ctx context.Context, role string) error {
+	c.factory.deleteRole = append(c.factory.deleteRole, fakeClientCommands{role: role})
+	if c.factory.failures["DeleteRole"] {
+		return fmt.Errorf("Mock test forced error")
+	}
 	return nil
 }
 
-func (c *fakeClient) GrantUserAccessToDb(dbName string, username string) error {
-	c.factory.grantUser = append(c.factory.grantUser, fakeClientCommands{dbName: dbName, username: username})
-	if c.factory.failures["GrantUserAccessToDb"] {
+func (c *fakeClient) GrantRolePermissions(ctx context.Context, dbName string, role string, actions []string) error {
+	c.factory.grantRole = append(c.factory.grantRole, fakeClientCommands{dbName: dbName, role: role, actions: actions})
+	if c.factory.failures["GrantRolePermissions"] {
 		return fmt.Errorf("Mock test forced error")
 	}
 	return nil
 }
 
-func (c *fakeClient) RevokeUserAccess(dbName string, username string) error {
-	c.factory.revokeUser = append(c.factory.revokeUser, fakeClientCommands{dbName: dbName, username: username})
-	if c.factory.failures["RevokeUserAccess"] {
+func (c *fakeClient) AssignRole(ctx context.Context, username string, role string) error {
+	c.factory.assignRole = append(c.factory.assignRole, fakeClientCommands{username: username, role: role})
+	if c.factory.failures["AssignRole"] {
+		return fmt.Errorf("Mock test forced error")
+	}
+	return nil
+}
+
+func (c *fakeClient) RevokeUserPermissions(ctx context.Context, dbName string, username string, actions []string) error {
+	c.factory.revokeUser = append(c.factory.revokeUser, fakeClientCommands{dbName: dbName, username: username, actions: actions})
+	if c.factory.failures["RevokeUserPermissions"] {
 		return fmt.Errorf("Mock test forced error")
 	}
 	return nil
@@ -195,7 +265,7 @@ func TestSimpleUnitSharedDbPlan(t *testing.T) {
 		return
 	}
 
-	code, dataI, err := plan.CreateServiceInstance()
+	code, dataI, err := plan.CreateServiceInstance(context.Background(), nil)
 	if code != http.StatusCreated {
 		t.Fatalf("The status should be ok %s\n", err)
 		return
@@ -215,14 +285,22 @@ func TestSimpleUnitSharedDbPlan(t *testing.T) {
 		t.Fatalf("The db name was not correct %s", err)
 		return
 	}
+	if !data.Roles || len(clientFactory.createRole) != 3 {
+		t.Fatalf("The roles of the instance were not created")
+		return
+	}
+	if clientFactory.grantRole[1].role != dbName+"_write" || strings.Join(clientFactory.grantRole[1].actions, ",") != "read,write" {
+		t.Fatalf("The write role was not granted read and write")
+		return
+	}
 
 	username := "someuser"
 	password := "somepw"
-	dbParams := newDatabaseBindParameters{
+	dbParams := broker.UserBindParameters{
 		Username: username,
 		Password: password,
 	}
-	code, bindDataI, err := plan.Bind(&dbParams)
+	code, bindDataI, err := plan.Bind(context.Background(), nil, &dbParams)
 	if code != http.StatusOK {
 		t.Fatalf("The status should be ok after bind %s", err)
 		return
@@ -259,22 +337,26 @@ func TestSimpleUnitSharedDbPlan(t *testing.T) {
 		return
 	}
 
-	if len(clientFactory.grantUser) != 1 {
-		t.Fatalf("NewUser was not called on stardog")
+	if len(clientFactory.grantUser) != 0 {
+		t.Fatalf("The user should not be granted permissions of its own")
 		return
 	}
-	if clientFactory.grantUser[0].username != username {
+	if len(clientFactory.assignRole) != 1 {
+		t.Fatalf("AssignRole was not called on stardog")
+		return
+	}
+	if clientFactory.assignRole[0].username != username {
 		t.Fatalf("The wrong username was used")
 		return
 	}
-	if clientFactory.grantUser[0].dbName != dbName {
-		t.Fatalf("The wrong password was used")
+	if clientFactory.assignRole[0].role != dbName+"_write" || bindData.Role != dbName+"_write" {
+		t.Fatalf("The wrong role was assigned")
 		return
 	}
 
 	// bind 2
-	dbParams2 := newDatabaseBindParameters{}
-	code, bindDataI2, err := plan.Bind(&dbParams2)
+	dbParams2 := broker.UserBindParameters{}
+	code, bindDataI2, err := plan.Bind(context.Background(), nil, &dbParams2)
 	if code != http.StatusOK {
 		t.Fatalf("The status should be ok after bind %s", err)
 		return
@@ -289,7 +371,7 @@ func TestSimpleUnitSharedDbPlan(t *testing.T) {
 		return
 	}
 
-	code, err = plan.UnBind(bindDataI)
+	code, err = plan.UnBind(context.Background(), bindDataI)
 	if err != nil {
 		t.Fatal("The unbind failed")
 		return
@@ -299,16 +381,8 @@ func TestSimpleUnitSharedDbPlan(t *testing.T) {
 		return
 	}
 
-	if len(clientFactory.revokeUser) != 1 {
-		t.Fatal("NewUser was not called on stardog")
-		return
-	}
-	if clientFactory.revokeUser[0].username != username {
-		t.Fatal("The wrong username was used")
-		return
-	}
-	if clientFactory.revokeUser[0].dbName != dbName {
-		t.Fatal("The wrong password was used")
+	if len(clientFactory.revokeUser) != 0 {
+		t.Fatal("The user of a role should not have permissions revoked")
 		return
 	}
 
@@ -321,9 +395,394 @@ func TestSimpleUnitSharedDbPlan(t *testing.T) {
 		return
 	}
 
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
 		t.Fatal("The remove instance command failed")
 		return
 	}
+	if len(clientFactory.deleteRole) != 3 {
+		t.Fatal("The roles of the instance were not deleted")
+		return
+	}
+}
+
+func TestUpdateSharedDbPlan(t *testing.T) {
+	sdURL := "http://notreal.fake:5820"
+	dbFactory := dataBasePlanFactory{
+		StardogURL: sdURL,
+		AdminName:  "admin",
+		AdminPw:    "admin",
+	}
+	planFactory, err := GetPlanFactory("aplanid", dbFactory)
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	otherServerFactory, err := GetPlanFactory("otherplanid", dataBasePlanFactory{StardogURL: "http://other.fake:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	sameServerFactory, err := GetPlanFactory("sameplanid", dbFactory)
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+
+	logger, err := getLogger()
+	clientFactory := createFakeClientFactory(false)
+	params := newDatabasePlanParameters{DbName: "aDbName"}
+	plan, err := planFactory.InflatePlan(&params, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	updatePlan, ok := plan.(broker.UpdatablePlan)
+	if !ok {
+		t.Fatalf("The shared plan should be updatable")
+	}
+	if !updatePlan.CanMoveTo(sameServerFactory) {
+		t.Fatalf("The plan should be able to move to a plan on the same server")
+	}
+	if updatePlan.CanMoveTo(otherServerFactory) {
+		t.Fatalf("The plan should not be able to move to a plan on another server")
+	}
+
+	options := map[string]interface{}{"search.enabled": true}
+	code, dataI, err := updatePlan.UpdateServiceInstance(context.Background(), map[string]interface{}{"database_options": options})
+	if code != http.StatusOK {
+		t.Fatalf("The update should have succeeded %s", err)
+	}
+	if len(clientFactory.setOptions) != 1 || clientFactory.setOptions[0].dbName != "aDbName" {
+		t.Fatalf("SetDatabaseOptions was not called on the right database")
+	}
+	data := dataI.(serviceParameters)
+	if data.DbName != "aDbName" || data.DatabaseOptions["search.enabled"] != true {
+		t.Fatalf("The updated parameters were not correct %v", data)
+	}
+
+	code, _, err = updatePlan.UpdateServiceInstance(context.Background(), map[string]interface{}{"db_name": "newName"})
+	if code != http.StatusBadRequest || err == nil {
+		t.Fatalf("Changing the database name should fail")
+	}
+}
+
+func TestTenantNamedSharedDbPlan(t *testing.T) {
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	logger, err := getLogger()
+	clientFactory := createFakeClientFactory(false)
+	plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{}, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+
+	requestContext := &broker.RequestContext{
+		Context: broker.PlatformContext{"platform": "kubernetes", "namespace": "team-a"},
+	}
+	code, dataI, err := plan.CreateServiceInstance(context.Background(), requestContext)
+	if code != http.StatusCreated {
+		t.Fatalf("The status should be created %s", err)
+	}
+	data := dataI.(serviceParameters)
+	if !strings.HasPrefix(data.DbName, "dbteam_a_") {
+		t.Fatalf("The database %s was not named after the namespace", data.DbName)
+	}
+
+	code, bindDataI, err := plan.Bind(context.Background(), requestContext, nil)
+	if code != http.StatusOK {
+		t.Fatalf("The status should be ok after bind %s", err)
+	}
+	bindData := bindDataI.(*NewDatabaseBindResponse)
+	if !strings.HasPrefix(bindData.Username, "stardogteam_a_") {
+		t.Fatalf("The user %s was not named after the namespace", bindData.Username)
+	}
+}
+
+func TestPasswordPolicySharedDbPlan(t *testing.T) {
+	policy := &broker.PasswordPolicy{
+		Length:            32,
+		CharacterClasses:  []string{"digits", "symbols"},
+		ExcludeCharacters: "01",
+	}
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820", Passwords: policy})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	logger, err := getLogger()
+	clientFactory := createFakeClientFactory(false)
+	plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{DbName: "aDbName"}, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	code, bindDataI, err := plan.Bind(context.Background(), nil, nil)
+	if code != http.StatusOK {
+		t.Fatalf("The status should be ok after bind %s", err)
+	}
+	pw := bindDataI.(*NewDatabaseBindResponse).Password
+	if len(pw) != 32 {
+		t.Fatalf("The password %s should be 32 characters", pw)
+	}
+	if strings.ContainsAny(pw, "01abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		t.Fatalf("The password %s has characters that the policy does not allow", pw)
+	}
+	if !strings.ContainsAny(pw, "23456789") || !strings.ContainsAny(pw, "!#$%&()*+,-./:;<=>?@[]^_{|}~") {
+		t.Fatalf("The password %s does not have every character class", pw)
+	}
+
+	_, err = GetPlanFactory("aplanid", dataBasePlanFactory{Passwords: &broker.PasswordPolicy{Length: 6}})
+	if err == nil {
+		t.Fatalf("A short password length should be rejected")
+	}
+	_, err = GetPlanFactory("aplanid", dataBasePlanFactory{Passwords: &broker.PasswordPolicy{CharacterClasses: []string{"emoji"}}})
+	if err == nil {
+		t.Fatalf("An unknown character class should be rejected")
+	}
+}
+
+func TestRetryPolicySharedDbPlan(t *testing.T) {
+	_, err := GetPlanFactory("aplanid", dataBasePlanFactory{Retry: &broker.RetryPolicy{MaxAttempts: 3, MaxElapsedSeconds: 10}})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	_, err = GetPlanFactory("aplanid", dataBasePlanFactory{Retry: &broker.RetryPolicy{MaxAttempts: -1}})
+	if err == nil {
+		t.Fatalf("A negative number of attempts should be rejected")
+	}
+	_, err = GetPlanFactory("aplanid", dataBasePlanFactory{Retry: &broker.RetryPolicy{InitialIntervalMillis: 2000, MaxIntervalMillis: 1000}})
+	if err == nil {
+		t.Fatalf("An initial interval longer than the maximum should be rejected")
+	}
+}
+
+func TestAccessLevelSharedDbPlan(t *testing.T) {
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	logger, err := getLogger()
+	clientFactory := createFakeClientFactory(false)
+	plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{DbName: "aDbName"}, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+
+	code, bindDataI, err := plan.Bind(context.Background(), nil, map[string]interface{}{"access": "read"})
+	if code != http.StatusOK {
+		t.Fatalf("The status should be ok after bind %s", err)
+	}
+	if len(clientFactory.grantUser) != 1 || strings.Join(clientFactory.grantUser[0].actions, ",") != "read" {
+		t.Fatalf("A read only binding should only be granted read")
+	}
+	code, err = plan.UnBind(context.Background(), bindDataI)
+	if code != http.StatusOK {
+		t.Fatalf("The status should be ok after unbind %s", err)
+	}
+	if len(clientFactory.revokeUser) != 1 || strings.Join(clientFactory.revokeUser[0].actions, ",") != "read" {
+		t.Fatalf("Unbind should revoke exactly what was granted")
+	}
+
+	// Bindings made before access levels existed were granted read and write
+	code, err = plan.UnBind(context.Background(), map[string]interface{}{"db_name": "aDbName", "username": "olduser"})
+	if code != http.StatusOK {
+		t.Fatalf("The status should be ok after unbind %s", err)
+	}
+	if strings.Join(clientFactory.revokeUser[1].actions, ",") != "read,write" {
+		t.Fatalf("An old binding should have read and write revoked")
+	}
+
+	code, _, err = plan.Bind(context.Background(), nil, map[string]interface{}{"access": "everything"})
+	if code != http.StatusBadRequest {
+		t.Fatalf("An unknown access level should be a bad request, got %d", code)
+	}
+}
+
+func TestRoleFailureSharedDbPlan(t *testing.T) {
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	logger, err := getLogger()
+	clientFactory := createFakeClientFactory(false, "GrantRolePermissions")
+	plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{DbName: "aDbName"}, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
+	if code != http.StatusInternalServerError {
+		t.Fatalf("The create should fail when a role cannot be set up, got %d", code)
+	}
+	if len(clientFactory.deleteRole) != 1 || clientFactory.deleteRole[0].role != "aDbName_read" {
+		t.Fatalf("The role that was created should be deleted")
+	}
+	if len(clientFactory.deleteDb) != 1 {
+		t.Fatalf("The database should be deleted")
+	}
+}
+
+func TestBindFailureSharedDbPlan(t *testing.T) {
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	logger, err := getLogger()
+	for _, roles := range []bool{true, false} {
+		failure := "GrantUserPermissions"
+		if roles {
+			failure = "AssignRole"
+		}
+		clientFactory := createFakeClientFactory(false, failure)
+		plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{DbName: "aDbName", Roles: roles}, clientFactory, logger)
+		if err != nil {
+			t.Fatalf("Failed to inflate the plan %s", err)
+		}
+		// A cancelled request must still clean up the user it created
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		code, _, err := plan.Bind(ctx, nil, map[string]interface{}{"username": "someuser"})
+		if code != http.StatusInternalServerError {
+			t.Fatalf("The bind should fail when %s fails, got %d", failure, code)
+		}
+		if len(clientFactory.deleteUser) != 1 || clientFactory.deleteUser[0].username != "someuser" {
+			t.Fatalf("The user should be deleted when %s fails", failure)
+		}
+		if clientFactory.deleteUser[0].ctxErr != nil {
+			t.Fatalf("The user should be deleted with a context that is not cancelled")
+		}
+	}
+
+	clientFactory := createFakeClientFactory(false, "GrantUserPermissions", "DeleteUser")
+	plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{DbName: "aDbName"}, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	code, _, err := plan.Bind(context.Background(), nil, nil)
+	if code != http.StatusInternalServerError || err == nil {
+		t.Fatalf("The grant failure should be returned when the user cannot be deleted, got %d", code)
+	}
+}
+
+func TestSharedDbPlanSchemas(t *testing.T) {
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	schemas := planFactory.Schemas()
+	if schemas.InstanceCreateSchema() == nil || schemas.InstanceUpdateSchema() == nil || schemas.BindingCreateSchema() == nil {
+		t.Fatalf("The plan should publish all of its schemas")
+	}
+
+	errs := broker.ValidateParameters(schemas.InstanceCreateSchema(), map[string]interface{}{"db_name": "aDbName"})
+	if len(errs) != 0 {
+		t.Fatalf("A valid db_name was rejected %v", errs)
+	}
+	errs = broker.ValidateParameters(schemas.InstanceCreateSchema(), map[string]interface{}{"db_name": "1 bad", "other": true})
+	if len(errs) != 2 {
+		t.Fatalf("Expected two errors but got %v", errs)
+	}
+	if errs[0].Field != "parameters.db_name" || errs[1].Field != "parameters.other" {
+		t.Fatalf("The errors were not reported on the right fields %v", errs)
+	}
+	errs = broker.ValidateParameters(schemas.BindingCreateSchema(), map[string]interface{}{"username": 5})
+	if len(errs) != 1 || errs[0].Field != "parameters.username" {
+		t.Fatalf("A username that is not a string was not rejected %v", errs)
+	}
+	errs = broker.ValidateParameters(schemas.BindingCreateSchema(), nil)
+	if len(errs) != 0 {
+		t.Fatalf("Binding without parameters should be allowed %v", errs)
+	}
+}
+
+func TestStardogErrorsSharedDbPlan(t *testing.T) {
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820"})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	logger, _ := getLogger()
+	clientFactory := createFakeClientFactory(false)
+	clientFactory.stardogErrors["CreateDatabase"] = &broker.StardogError{Operation: "POST /admin/databases", Status: http.StatusConflict, Code: "0D0DU2"}
+	plan, err := planFactory.InflatePlan(&newDatabasePlanParameters{DbName: "aDbName"}, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
+	if code != http.StatusConflict || broker.ErrorStatus(err, 0) != http.StatusConflict {
+		t.Fatalf("An existing database should be a conflict but got %d %s", code, err)
+	}
+
+	clientFactory.stardogErrors["CreateDatabase"] = &broker.StardogError{Operation: "POST /admin/databases", Status: http.StatusUnauthorized}
+	code, _, err = plan.CreateServiceInstance(context.Background(), nil)
+	if code != http.StatusInternalServerError || err == nil || !strings.Contains(err.Error(), "admin credentials") {
+		t.Fatalf("Rejected admin credentials should be a configuration error but got %d %s", code, err)
+	}
+
+	clientFactory.stardogErrors["NewUser"] = &broker.StardogError{Operation: "POST /admin/users", Status: http.StatusConflict}
+	code, _, err = plan.Bind(context.Background(), nil, nil)
+	if code != http.StatusConflict || broker.ErrorStatus(err, 0) != http.StatusConflict {
+		t.Fatalf("An existing user should be a conflict but got %d %s", code, err)
+	}
+
+	clientFactory.stardogErrors["DeleteDatabase"] = &broker.StardogError{Operation: "DELETE /admin/databases/aDbName", Status: http.StatusNotFound}
+	code, _, err = plan.RemoveInstance(context.Background())
+	if code != http.StatusOK || err != nil {
+		t.Fatalf("Removing a database that is already gone should succeed but got %d %s", code, err)
+	}
+}
+
+func TestDatabaseOptionsSharedDbPlan(t *testing.T) {
+	policy := &broker.DatabaseOptionsPolicy{
+		Allowed:  []string{"search.enabled", "reasoning.type", "strict.parsing"},
+		Defaults: map[string]interface{}{"strict.parsing": false, "reasoning.type": "SL"},
+	}
+	planFactory, err := GetPlanFactory("aplanid", dataBasePlanFactory{StardogURL: "http://fake.stardog.com:5820", DatabaseOptions: policy})
+	if err != nil {
+		t.Fatalf("Failed to get the factory %s", err)
+	}
+	schema := planFactory.Schemas().InstanceCreateSchema()
+	errs := broker.ValidateParameters(schema, map[string]interface{}{"database_options": map[string]interface{}{"spatial.enabled": true}})
+	if len(errs) != 1 {
+		t.Fatalf("The create schema should only allow the options of the plan %v", errs)
+	}
+
+	logger, _ := getLogger()
+	clientFactory := createFakeClientFactory(false)
+	params := map[string]interface{}{"db_name": "aDbName", "database_options": map[string]interface{}{"search.enabled": true, "reasoning.type": "QL"}}
+	plan, err := planFactory.InflatePlan(params, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	code, dataI, err := plan.CreateServiceInstance(context.Background(), nil)
+	if code != http.StatusCreated {
+		t.Fatalf("The create should have succeeded %s", err)
+	}
+	options := clientFactory.createDb[0].options
+	if options["search.enabled"] != true || options["reasoning.type"] != "QL" || options["strict.parsing"] != false {
+		t.Fatalf("The database was not created with the requested options and defaults %v", options)
+	}
+	data := dataI.(serviceParameters)
+	if data.DatabaseOptions["strict.parsing"] != false {
+		t.Fatalf("The defaults were not kept with the instance %v", data)
+	}
+
+	code, _, err = plan.(broker.UpdatablePlan).UpdateServiceInstance(context.Background(), map[string]interface{}{"database_options": map[string]interface{}{"spatial.enabled": true}})
+	if code != http.StatusBadRequest || err == nil {
+		t.Fatalf("An option that the plan does not allow should not be updated")
+	}
+
+	params = map[string]interface{}{"db_name": "otherDb", "database_options": map[string]interface{}{"search.enabled": map[string]interface{}{}}}
+	plan, err = planFactory.InflatePlan(params, clientFactory, logger)
+	if err != nil {
+		t.Fatalf("Failed to inflate the plan %s", err)
+	}
+	code, _, err = plan.CreateServiceInstance(context.Background(), nil)
+	if code != http.StatusBadRequest || len(clientFactory.createDb) != 1 {
+		t.Fatalf("An option with an object value should be rejected before the database is created %d %s", code, err)
+	}
+
+	_, err = GetPlanFactory("aplanid", dataBasePlanFactory{DatabaseOptions: &broker.DatabaseOptionsPolicy{Allowed: []string{"search.enabled"}, Defaults: map[string]interface{}{"spatial.enabled": true}}})
+	if err == nil {
+		t.Fatalf("A default for an option that is not allowed should be rejected")
+	}
+	_, err = GetPlanFactory("aplanid", dataBasePlanFactory{DatabaseOptions: &broker.DatabaseOptionsPolicy{Allowed: []string{"database.name"}}})
+	if err == nil {
+		t.Fatalf("The database name should not be allowed as an option")
+	}
 }
@@ -16,6 +16,7 @@
 package plans
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -134,7 +135,7 @@ func happyPathSharedDbPlan(tester planTester) (bool, error) {
 	}
 
 	fmt.Printf("pre CreateServiceInstance")
-	code, _, err := plan.CreateServiceInstance()
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
 	if err != nil {
 		return false, err
 	}
@@ -144,7 +145,7 @@ func happyPathSharedDbPlan(tester planTester) (bool, error) {
 
 	fmt.Printf("pre GetBindParameters")
 	bindParams := tester.GetBindParameters()
-	code, bindI, err := plan.Bind(bindParams)
+	code, bindI, err := plan.Bind(context.Background(), nil, bindParams)
 	if err != nil {
 		return false, err
 	}
@@ -152,7 +153,7 @@ func happyPathSharedDbPlan(tester planTester) (bool, error) {
 		return false, fmt.Errorf("Bind returned an unsuccessful code %d", code)
 	}
 
-	code, err = plan.UnBind(bindI)
+	code, err = plan.UnBind(context.Background(), bindI)
 	if err != nil {
 		return false, err
 	}
@@ -160,7 +161,7 @@ func happyPathSharedDbPlan(tester planTester) (bool, error) {
 		return false, fmt.Errorf("Unbind returned an unsuccessful code %d", code)
 	}
 
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
 		return false, err
 	}
@@ -196,7 +197,7 @@ func sharedDbPlanRemoveTwice(tester planTester) (bool, error) {
 		return true, nil
 	}
 
-	code, _, err := plan.CreateServiceInstance()
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
 	if err != nil {
 		return false, err
 	}
@@ -204,14 +205,14 @@ func sharedDbPlanRemoveTwice(tester planTester) (bool, error) {
 		return false, fmt.Errorf("Create service returned an unsuccessful code %d", code)
 	}
 
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
-		return false, fmt.Errorf(err.Error())
+		return false, err
 	}
 	if code != http.StatusOK {
 		return false, fmt.Errorf("Unbind returned an unsuccessful code %d", code)
 	}
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err == nil {
 		return false, fmt.Errorf("We should not have been able to delete twice")
 	}
@@ -247,7 +248,7 @@ func sharedDbPlanInspectBind(tester planTester) (bool, error) {
 		return true, nil
 	}
 
-	code, _, err := plan.CreateServiceInstance()
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
 	if err != nil {
 		return false, err
 	}
@@ -260,7 +261,7 @@ func sharedDbPlanInspectBind(tester planTester) (bool, error) {
 	// 	return false, fmt.Errorf("A db name should have been created")
 	// }
 
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
 		return false, err
 	}
@@ -296,18 +297,18 @@ func sharedDbPlanConflictingDbName(tester planTester) (bool, error) {
 		return true, nil
 	}
 
-	code, _, err := plan.CreateServiceInstance()
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
 	if err != nil {
 		return false, err
 	}
 	if code != http.StatusCreated {
 		return false, fmt.Errorf("Create service returned an unsuccessful code %d", code)
 	}
-	code, _, err = plan.CreateServiceInstance()
+	code, _, err = plan.CreateServiceInstance(context.Background(), nil)
 	if err == nil || code == http.StatusCreated {
 		return false, fmt.Errorf("The second create should have failed")
 	}
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
 		return false, err
 	}
@@ -343,7 +344,7 @@ func sharedDbPlanBindTwice(tester planTester) (bool, error) {
 		return true, nil
 	}
 
-	code, _, err := plan.CreateServiceInstance()
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
 	if err != nil {
 		return false, err
 	}
@@ -352,7 +353,7 @@ func sharedDbPlanBindTwice(tester planTester) (bool, error) {
 	}
 
 	bindParams := tester.GetBindParameters()
-	code, bindI, err := plan.Bind(bindParams)
+	code, bindI, err := plan.Bind(context.Background(), nil, bindParams)
 	if err != nil {
 		return false, err
 	}
@@ -360,12 +361,12 @@ func sharedDbPlanBindTwice(tester planTester) (bool, error) {
 		return false, fmt.Errorf("Bind returned an unsuccessful code %d", code)
 	}
 	bindParams = tester.GetBindParameters()
-	code, _, err = plan.Bind(bindParams)
+	code, _, err = plan.Bind(context.Background(), nil, bindParams)
 	if err != nil {
 		return false, err
 	}
 
-	code, err = plan.UnBind(bindI)
+	code, err = plan.UnBind(context.Background(), bindI)
 	if err != nil {
 		return false, err
 	}
@@ -373,7 +374,7 @@ func sharedDbPlanBindTwice(tester planTester) (bool, error) {
 		return false, fmt.Errorf("Unbind returned an unsuccessful code %d", code)
 	}
 
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
 		return false, err
 	}
@@ -409,7 +410,7 @@ func sharedDbPlanUnbindTwice(tester planTester) (bool, error) {
 		return true, nil
 	}
 
-	code, _, err := plan.CreateServiceInstance()
+	code, _, err := plan.CreateServiceInstance(context.Background(), nil)
 	if err != nil {
 		return false, err
 	}
@@ -418,7 +419,7 @@ func sharedDbPlanUnbindTwice(tester planTester) (bool, error) {
 	}
 
 	bindParams := tester.GetBindParameters()
-	code, bindI, err := plan.Bind(bindParams)
+	code, bindI, err := plan.Bind(context.Background(), nil, bindParams)
 	if err != nil {
 		return false, err
 	}
@@ -426,19 +427,19 @@ func sharedDbPlanUnbindTwice(tester planTester) (bool, error) {
 		return false, fmt.Errorf("Bind returned an unsuccessful code %d", code)
 	}
 
-	code, err = plan.UnBind(bindI)
+	code, err = plan.UnBind(context.Background(), bindI)
 	if err != nil {
 		return false, err
 	}
 	if code != http.StatusOK {
 		return false, fmt.Errorf("Unbind returned an unsuccessful code %d", code)
 	}
-	code, err = plan.UnBind(bindI)
+	code, err = plan.UnBind(context.Background(), bindI)
 	if err == nil || code == http.StatusOK {
 		return false, fmt.Errorf("The second unbind should have failed")
 	}
 
-	code, _, err = plan.RemoveInstance()
+	code, _, err = plan.RemoveInstance(context.Background())
 	if err != nil {
 		return false, err
 	}
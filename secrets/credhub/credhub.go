@@ -0,0 +1,166 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/stardog-union/service-broker/broker"
+)
+
+// credHubParameters take the same TLS and timeout settings as a Stardog
+// server, such as ca_file and request_timeout_seconds.
+type credHubParameters struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	broker.StardogTLSConfig
+	broker.StardogClientConfig
+}
+
+type credHubSecretStore struct {
+	url        string
+	token      string
+	httpClient *http.Client
+	logger     broker.SdLogger
+}
+
+type setRequest struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type getResponse struct {
+	Data []struct {
+		Value interface{} `json:"value"`
+	} `json:"data"`
+}
+
+// NewCredHubSecretStore creates a SecretStore that keeps credentials in a
+// server that speaks the CredHub data API, such as CredHub itself or a
+// local server that mimics it.  Credentials are saved with the json type.
+// When a token is given it is sent as a bearer token.  One client, with
+// the timeouts and TLS settings of the parameters, is shared by every
+// request.
+func NewCredHubSecretStore(logger broker.SdLogger, parameters interface{}) (broker.SecretStore, error) {
+	var params credHubParameters
+	err := broker.ReSerializeInterface(parameters, &params)
+	if err != nil {
+		return nil, err
+	}
+	if params.URL == "" {
+		return nil, fmt.Errorf("The CredHub secret store requires a url")
+	}
+	tlsConfig, err := broker.NewStardogTLSConfig(&params.StardogTLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &credHubSecretStore{
+		url:        strings.TrimRight(params.URL, "/"),
+		token:      params.Token,
+		httpClient: broker.NewHTTPClient(&params.StardogClientConfig, tlsConfig),
+		logger:     logger,
+	}, nil
+}
+
+func (s *credHubSecretStore) do(ctx context.Context, method string, query url.Values, body io.Reader) (*http.Response, error) {
+	urlStr := s.url + "/api/v1/data"
+	if query != nil {
+		urlStr = urlStr + "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, urlStr, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to contact CredHub: %s", err)
+	}
+	return resp, nil
+}
+
+func (s *credHubSecretStore) SetCredentials(ctx context.Context, name string, value interface{}) error {
+	data, err := json.Marshal(&setRequest{Name: name, Type: "json", Value: value})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, "PUT", nil, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CredHub returned %d when saving %s", resp.StatusCode, name)
+	}
+	s.logger.Logf(broker.INFO, "Saved the credentials %s", name)
+	return nil
+}
+
+func (s *credHubSecretStore) GetCredentials(ctx context.Context, name string) (interface{}, error) {
+	resp, err := s.do(ctx, "GET", url.Values{"name": {name}, "current": {"true"}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, broker.NewNotFoundError("The credentials %s do not exist", name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CredHub returned %d when reading %s", resp.StatusCode, name)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var res getResponse
+	err = json.Unmarshal(content, &res)
+	if err != nil {
+		return nil, fmt.Errorf("CredHub returned an invalid document for %s: %s", name, err)
+	}
+	if len(res.Data) < 1 {
+		return nil, broker.NewNotFoundError("The credentials %s do not exist", name)
+	}
+	return res.Data[0].Value, nil
+}
+
+func (s *credHubSecretStore) DeleteCredentials(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, "DELETE", url.Values{"name": {name}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return broker.NewNotFoundError("The credentials %s do not exist", name)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CredHub returned %d when deleting %s", resp.StatusCode, name)
+	}
+	s.logger.Logf(broker.INFO, "Deleted the credentials %s", name)
+	return nil
+}
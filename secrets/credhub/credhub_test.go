@@ -0,0 +1,180 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credhub
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stardog-union/service-broker/broker"
+)
+
+// fakeCredHub implements the parts of the CredHub data API that the
+// secret store uses.
+type fakeCredHub struct {
+	values map[string]interface{}
+	lock   sync.Mutex
+}
+
+func (f *fakeCredHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	switch r.Method {
+	case "PUT":
+		var req setRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil || req.Type != "json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.values[req.Name] = req.Value
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		value, ok := f.values[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []interface{}{map[string]interface{}{"name": name, "value": value}},
+		})
+	case "DELETE":
+		if _, ok := f.values[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.values, name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func getLogger() broker.SdLogger {
+	baseLogger := log.New(os.Stderr, "", log.Ldate|log.Ltime)
+	logger, _ := broker.NewSdLogger(baseLogger, "DEBUG")
+	return logger
+}
+
+func TestCredHubSecretStore(t *testing.T) {
+	logger := getLogger()
+
+	server := httptest.NewServer(&fakeCredHub{values: make(map[string]interface{})})
+	defer server.Close()
+
+	s, err := NewCredHubSecretStore(logger, map[string]string{"url": server.URL, "token": "tok"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	name := "/c/broker/service/binding/credentials"
+	err = s.SetCredentials(context.Background(), name, map[string]string{"password": "pw"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	value, err := s.GetCredentials(context.Background(), name)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if value.(map[string]interface{})["password"] != "pw" {
+		t.Fatalf("The wrong credentials were returned %v", value)
+	}
+	err = s.DeleteCredentials(context.Background(), name)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	_, err = s.GetCredentials(context.Background(), name)
+	if !broker.IsNotFound(err) {
+		t.Fatalf("The credentials were not deleted: %v", err)
+	}
+}
+
+func TestCredHubSecretStoreTLS(t *testing.T) {
+	server := httptest.NewTLSServer(&fakeCredHub{values: make(map[string]interface{})})
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "credhub")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err = ioutil.WriteFile(caFile, caPEM, 0600)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	s, err := NewCredHubSecretStore(getLogger(), map[string]string{"url": server.URL, "token": "tok"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = s.SetCredentials(context.Background(), "/c/creds", "pw")
+	if err == nil {
+		t.Fatalf("A server with an unknown certificate should be refused")
+	}
+
+	s, err = NewCredHubSecretStore(getLogger(), map[string]string{"url": server.URL, "token": "tok", "ca_file": caFile})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = s.SetCredentials(context.Background(), "/c/creds", "pw")
+	if err != nil {
+		t.Fatalf("The ca_file should be trusted: %s", err)
+	}
+
+	_, err = NewCredHubSecretStore(getLogger(), map[string]string{"url": server.URL, "ca_file": filepath.Join(dir, "missing.pem")})
+	if err == nil {
+		t.Fatalf("A missing ca_file should fail")
+	}
+}
+
+func TestCredHubSecretStoreCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	s, err := NewCredHubSecretStore(getLogger(), map[string]string{"url": server.URL})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = s.GetCredentials(ctx, "/c/creds")
+	if err == nil {
+		t.Fatalf("A cancelled request should fail")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("The request was not abandoned when its context was cancelled")
+	}
+}
@@ -0,0 +1,152 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/stardog-union/service-broker/broker"
+)
+
+type fileSecretParameters struct {
+	Path string `json:"path"`
+}
+
+type fileSecretStore struct {
+	path   string
+	logger broker.SdLogger
+	lock   sync.Mutex
+}
+
+// NewFileSecretStore creates a SecretStore that keeps credentials in a
+// JSON file which only the broker user can read.  It is meant for
+// development and for brokers that do not have CredHub available.
+func NewFileSecretStore(logger broker.SdLogger, parameters interface{}) (broker.SecretStore, error) {
+	var params fileSecretParameters
+	err := broker.ReSerializeInterface(parameters, &params)
+	if err != nil {
+		return nil, err
+	}
+	if params.Path == "" {
+		return nil, fmt.Errorf("The file secret store requires a path")
+	}
+	s := &fileSecretStore{
+		path:   params.Path,
+		logger: logger,
+	}
+	// Fail now rather than on the first bind if the file is not usable
+	_, err = s.load()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSecretStore) load() (map[string]interface{}, error) {
+	secrets := make(map[string]interface{})
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the secrets file %s: %s", s.path, err)
+	}
+	if len(data) == 0 {
+		return secrets, nil
+	}
+	err = json.Unmarshal(data, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("The secrets file %s is not valid JSON: %s", s.path, err)
+	}
+	return secrets, nil
+}
+
+// save replaces the file so that a failed write cannot leave it half
+// written.
+func (s *fileSecretStore) save(secrets map[string]interface{}) error {
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to write the secrets file %s: %s", tmpPath, err)
+	}
+	err = os.Rename(tmpPath, s.path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to replace the secrets file %s: %s", s.path, err)
+	}
+	return nil
+}
+
+func (s *fileSecretStore) SetCredentials(ctx context.Context, name string, value interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	err = s.save(secrets)
+	if err != nil {
+		return err
+	}
+	s.logger.Logf(broker.INFO, "Saved the credentials %s", name)
+	return nil
+}
+
+func (s *fileSecretStore) GetCredentials(ctx context.Context, name string) (interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return nil, broker.NewNotFoundError("The credentials %s do not exist", name)
+	}
+	return value, nil
+}
+
+func (s *fileSecretStore) DeleteCredentials(ctx context.Context, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return broker.NewNotFoundError("The credentials %s do not exist", name)
+	}
+	delete(secrets, name)
+	err = s.save(secrets)
+	if err != nil {
+		return err
+	}
+	s.logger.Logf(broker.INFO, "Deleted the credentials %s", name)
+	return nil
+}
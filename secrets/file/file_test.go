@@ -0,0 +1,76 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stardog-union/service-broker/broker"
+)
+
+func TestFileSecretStore(t *testing.T) {
+	baseLogger := log.New(os.Stderr, "", log.Ldate|log.Ltime)
+	logger, _ := broker.NewSdLogger(baseLogger, "DEBUG")
+
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets.json")
+
+	s, err := NewFileSecretStore(logger, map[string]string{"path": path})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = s.SetCredentials(context.Background(), "/c/broker/service/binding/credentials", map[string]string{"password": "pw"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("The secrets file has the mode %s", info.Mode())
+	}
+
+	// A new store must see what the first one saved
+	s, err = NewFileSecretStore(logger, map[string]string{"path": path})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	value, err := s.GetCredentials(context.Background(), "/c/broker/service/binding/credentials")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if value.(map[string]interface{})["password"] != "pw" {
+		t.Fatalf("The wrong credentials were returned %v", value)
+	}
+	err = s.DeleteCredentials(context.Background(), "/c/broker/service/binding/credentials")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	_, err = s.GetCredentials(context.Background(), "/c/broker/service/binding/credentials")
+	if !broker.IsNotFound(err) {
+		t.Fatalf("The credentials were not deleted: %v", err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stardog-union/service-broker/broker"
 	"github.com/stardog-union/service-broker/plans/shared"
@@ -78,13 +79,13 @@ func getShardedDbPlanServer() (*testBrokerClient, error) {
 		fmt.Fprintf(os.Stderr, "Failed create the logger %s\n", err)
 		return nil, err
 	}
-	store, err := stardogstore.NewStardogStore(conf.BrokerID, logger, conf.Storage.Parameters)
+	clientFactory := broker.NewClientFactory(logger, conf.StardogClient)
+	store, err := stardogstore.NewStardogStore(conf.BrokerID, logger, clientFactory, conf.Storage.Parameters)
 	if err != nil {
 		return nil, fmt.Errorf("Error setting up the data store: %s", err)
 	}
 
-	clientFactory := broker.NewClientFactory(logger)
-	s, err := broker.CreateServer(dbPlanMap, &conf, clientFactory, logger, store)
+	s, err := broker.CreateServer(dbPlanMap, &conf, clientFactory, logger, store, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -153,8 +154,8 @@ func getShardedDbMySQLPlanServer() (*testBrokerClient, error) {
 		return nil, fmt.Errorf("Error setting up the data store: %s", err)
 	}
 
-	clientFactory := broker.NewClientFactory(logger)
-	s, err := broker.CreateServer(dbPlanMap, &conf, clientFactory, logger, store)
+	clientFactory := broker.NewClientFactory(logger, nil)
+	s, err := broker.CreateServer(dbPlanMap, &conf, clientFactory, logger, store, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -195,6 +196,7 @@ func doRequestResponse(c *testBrokerClient, method, path string, body io.Reader,
 	client := &http.Client{}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", contentType)
+	req.Header.Set(broker.APIVersionHeader, "2.14")
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("Failed do the post %s", err)
@@ -234,7 +236,7 @@ func testDriver(t *testing.T, meatFunc testMeat, planFunc getPlanFunc) {
 
 	err = meatFunc(c)
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatalf("%s", err)
 	}
 }
 
@@ -369,6 +371,176 @@ func TestControllerMakeDeleteInstanceSql(t *testing.T) {
 	testDriver(t, controllerMakeDeleteInstance, getShardedDbMySQLPlanServer)
 }
 
+func controllerBindServiceAsync(c *testBrokerClient) error {
+	serviceID := testUUID()
+	servicePath := fmt.Sprintf("/v2/service_instances/%s", serviceID)
+
+	serviceInstanceReq := broker.CreateServiceInstanceRequest{
+		ServiceID:        c.conf.BrokerID,
+		PlanID:           c.conf.Plans[0].PlanID,
+		OrganizationGUID: testUUID(),
+		SpaceGUID:        testUUID(),
+	}
+	data, err := json.Marshal(serviceInstanceReq)
+	if err != nil {
+		return err
+	}
+	bodyBuf := strings.NewReader(string(data))
+	_, err = doRequestResponse(c, "PUT", servicePath, bodyBuf, "application/json", 201)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("%s/service_bindings/%s", servicePath, testUUID())
+	bindReq := broker.BindRequest{
+		ServiceID: c.conf.BrokerID,
+		PlanID:    c.conf.Plans[0].PlanID,
+	}
+	data, err = json.Marshal(bindReq)
+	if err != nil {
+		return err
+	}
+	bodyBuf = strings.NewReader(string(data))
+	resp, err := doRequestResponse(c, "PUT", path+"?accepts_incomplete=true", bodyBuf, "application/json", 202)
+	if err != nil {
+		return err
+	}
+	var bindResp broker.AsyncBindResponse
+	err = json.Unmarshal(resp, &bindResp)
+	if err != nil {
+		return err
+	}
+	lastOp, err := waitForLastOperation(c, path, bindResp.Operation)
+	if err != nil {
+		return err
+	}
+	if lastOp.State != broker.OperationSucceeded {
+		return fmt.Errorf("The bind failed: %s", lastOp.Description)
+	}
+
+	byteBuf := &bytes.Buffer{}
+	resp, err = doRequestResponse(c, "DELETE", path+"?accepts_incomplete=true", byteBuf, "application/json", 202)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(resp, &bindResp)
+	if err != nil {
+		return err
+	}
+	lastOp, err = waitForLastOperation(c, path, bindResp.Operation)
+	if err != nil {
+		return err
+	}
+	if lastOp.State != broker.OperationSucceeded {
+		return fmt.Errorf("The unbind failed: %s", lastOp.Description)
+	}
+
+	byteBuf = &bytes.Buffer{}
+	_, err = doRequestResponse(c, "DELETE", servicePath, byteBuf, "application/json", 200)
+	return err
+}
+
+func TestControllerBindServiceAsync(t *testing.T) {
+	testDriver(t, controllerBindServiceAsync, getShardedDbPlanServer)
+}
+
+func TestControllerBindServiceAsyncSql(t *testing.T) {
+	testDriver(t, controllerBindServiceAsync, getShardedDbMySQLPlanServer)
+}
+
+func waitForLastOperation(c *testBrokerClient, path string, operation string) (*broker.LastOperation, error) {
+	opPath := fmt.Sprintf("%s/last_operation?operation=%s", path, operation)
+	for i := 0; i < 60; i++ {
+		byteBuf := &bytes.Buffer{}
+		data, err := doRequestResponse(c, "GET", opPath, byteBuf, "application/json", 200)
+		if err != nil {
+			return nil, err
+		}
+		var lastOp broker.LastOperation
+		err = json.Unmarshal(data, &lastOp)
+		if err != nil {
+			return nil, err
+		}
+		if lastOp.State != broker.OperationInProgress {
+			return &lastOp, nil
+		}
+		time.Sleep(time.Second)
+	}
+	return nil, fmt.Errorf("The operation %s never completed", operation)
+}
+
+func controllerMakeDeleteInstanceAsync(c *testBrokerClient) error {
+	path := fmt.Sprintf("/v2/service_instances/%s", testUUID())
+
+	serviceInstanceReq := broker.CreateServiceInstanceRequest{
+		ServiceID:        c.conf.BrokerID,
+		PlanID:           c.conf.Plans[0].PlanID,
+		OrganizationGUID: testUUID(),
+		SpaceGUID:        testUUID(),
+	}
+
+	data, err := json.Marshal(serviceInstanceReq)
+	if err != nil {
+		return err
+	}
+	bodyBuf := strings.NewReader(string(data))
+
+	resp, err := doRequestResponse(c, "PUT", path+"?accepts_incomplete=true", bodyBuf, "application/json", 202)
+	if err != nil {
+		return err
+	}
+	var createResp broker.CreateGetServiceInstanceResponse
+	err = json.Unmarshal(resp, &createResp)
+	if err != nil {
+		return err
+	}
+	if createResp.Operation == "" {
+		return fmt.Errorf("No operation was returned for the asynchronous create")
+	}
+	lastOp, err := waitForLastOperation(c, path, createResp.Operation)
+	if err != nil {
+		return err
+	}
+	if lastOp.State != broker.OperationSucceeded {
+		return fmt.Errorf("The create failed: %s", lastOp.Description)
+	}
+	byteBuf := &bytes.Buffer{}
+	_, err = doRequestResponse(c, "GET", path, byteBuf, "application/json", 200)
+	if err != nil {
+		return err
+	}
+
+	byteBuf = &bytes.Buffer{}
+	resp, err = doRequestResponse(c, "DELETE", path+"?accepts_incomplete=true", byteBuf, "application/json", 202)
+	if err != nil {
+		return err
+	}
+	var deleteResp broker.CreateGetServiceInstanceResponse
+	err = json.Unmarshal(resp, &deleteResp)
+	if err != nil {
+		return err
+	}
+	lastOp, err = waitForLastOperation(c, path, deleteResp.Operation)
+	if err != nil {
+		return err
+	}
+	if lastOp.State != broker.OperationSucceeded {
+		return fmt.Errorf("The delete failed: %s", lastOp.Description)
+	}
+
+	byteBuf = &bytes.Buffer{}
+	_, err = doRequestResponse(c, "GET", path, byteBuf, "application/json", 404)
+	return err
+}
+
+func TestControllerMakeDeleteInstanceAsync(t *testing.T) {
+	testDriver(t, controllerMakeDeleteInstanceAsync, getShardedDbPlanServer)
+}
+
+func TestControllerMakeDeleteInstanceAsyncSql(t *testing.T) {
+	testDriver(t, controllerMakeDeleteInstanceAsync, getShardedDbMySQLPlanServer)
+}
+
 func controllerBindNoService(c *testBrokerClient) error {
 	serviceID := testUUID()
 	bindID := testUUID()
@@ -550,6 +722,25 @@ func controllerBindServiceExists(c *testBrokerClient) error {
 		return fmt.Errorf("The credentials were not properly set")
 	}
 
+	getBuf := &bytes.Buffer{}
+	resp, err = doRequestResponse(c, "GET", path, getBuf, "application/json", 200)
+	if err != nil {
+		return err
+	}
+	var getR broker.BindResponse
+	err = json.Unmarshal(resp, &getR)
+	if err != nil {
+		return err
+	}
+	var getCreds shared.NewDatabaseBindResponse
+	err = broker.ReSerializeInterface(&getR.Credentials, &getCreds)
+	if err != nil {
+		return err
+	}
+	if getCreds != creds {
+		return fmt.Errorf("The fetched credentials do not match the bind credentials")
+	}
+
 	_, err = doRequestResponse(c, "DELETE", path, bodyBuf, "application/json", 200)
 	if err != nil {
 		return err
@@ -0,0 +1,466 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/stardog-union/service-broker/broker"
+)
+
+const keySize = 32
+
+// sealedParams is kept by the underlying Store in place of the plan
+// parameters of an instance, binding or orphan.  The parameters are the
+// only part of a record that hold secrets, the rest is left readable so
+// that the drivers can still look records up.
+type sealedParams struct {
+	KeyID      string `json:"encryption_key_id"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Keyring holds the AES-256-GCM keys used to encrypt records.  New
+// records are always encrypted with the primary key.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+type encryptedStore struct {
+	broker.Store
+	keys   *Keyring
+	logger broker.SdLogger
+}
+
+// NewKeyring loads the keys described in the encryption configuration.
+func NewKeyring(conf *broker.EncryptionConfig) (*Keyring, error) {
+	if conf == nil || len(conf.Keys) == 0 {
+		return nil, fmt.Errorf("At least one encryption key is required")
+	}
+	kr := &Keyring{
+		primary: conf.PrimaryKeyID,
+		aeads:   make(map[string]cipher.AEAD),
+	}
+	for _, k := range conf.Keys {
+		if k.ID == "" {
+			return nil, fmt.Errorf("Every encryption key must have an id")
+		}
+		if kr.aeads[k.ID] != nil {
+			return nil, fmt.Errorf("The encryption key %s is listed more than once", k.ID)
+		}
+		key, err := loadKey(k)
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("The encryption key %s could not be used: %s", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("The encryption key %s could not be used: %s", k.ID, err)
+		}
+		kr.aeads[k.ID] = aead
+	}
+	if kr.primary == "" {
+		kr.primary = conf.Keys[0].ID
+	}
+	if kr.aeads[kr.primary] == nil {
+		return nil, fmt.Errorf("The primary encryption key %s is not defined", kr.primary)
+	}
+	return kr, nil
+}
+
+func loadKey(k broker.EncryptionKey) ([]byte, error) {
+	encoded := k.Key
+	if encoded == "" && k.KeyEnv != "" {
+		encoded = os.Getenv(k.KeyEnv)
+	}
+	if encoded == "" && k.KeyFile != "" {
+		data, err := ioutil.ReadFile(k.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the encryption key %s: %s", k.ID, err)
+		}
+		encoded = string(data)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("No value was given for the encryption key %s", k.ID)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("The encryption key %s is not base64 encoded: %s", k.ID, err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("The encryption key %s must be %d bytes long", k.ID, keySize)
+	}
+	return key, nil
+}
+
+// seal encrypts params with the primary key.  aad names the record the
+// parameters belong to so that they cannot be copied into another record.
+func (kr *Keyring) seal(params interface{}, aad string) (*sealedParams, error) {
+	plain, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	aead := kr.aeads[kr.primary]
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to make a nonce: %s", err)
+	}
+	ciphertext := aead.Seal(nonce, nonce, plain, []byte(aad))
+	return &sealedParams{
+		KeyID:      kr.primary,
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// open decrypts the parameters of a record and returns them with the ID of
+// the key that they were encrypted with.  Parameters written before
+// encryption was enabled are returned unchanged with an empty key ID.
+func (kr *Keyring) open(stored interface{}, aad string) (interface{}, string, error) {
+	sp, ok := asSealed(stored)
+	if !ok {
+		return stored, "", nil
+	}
+	aead := kr.aeads[sp.KeyID]
+	if aead == nil {
+		return nil, "", fmt.Errorf("The encryption key %s is not known", sp.KeyID)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(sp.Ciphertext)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to decode the encrypted parameters of %s: %s", aad, err)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, "", fmt.Errorf("The encrypted parameters of %s are truncated", aad)
+	}
+	n := aead.NonceSize()
+	plain, err := aead.Open(nil, ciphertext[:n], ciphertext[n:], []byte(aad))
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to decrypt the parameters of %s: %s", aad, err)
+	}
+	var params interface{}
+	err = json.Unmarshal(plain, &params)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to unmarshal the parameters of %s: %s", aad, err)
+	}
+	return params, sp.KeyID, nil
+}
+
+// reseal encrypts the parameters of a record with the primary key.  Nil
+// is returned if they already are.
+func (kr *Keyring) reseal(stored interface{}, aad string) (*sealedParams, error) {
+	params, keyID, err := kr.open(stored, aad)
+	if err != nil {
+		return nil, err
+	}
+	if keyID == kr.primary {
+		return nil, nil
+	}
+	return kr.seal(params, aad)
+}
+
+func asSealed(stored interface{}) (*sealedParams, bool) {
+	switch v := stored.(type) {
+	case *sealedParams:
+		return v, true
+	case map[string]interface{}:
+		if len(v) != 2 {
+			return nil, false
+		}
+		keyID, ok := v["encryption_key_id"].(string)
+		if !ok {
+			return nil, false
+		}
+		ciphertext, ok := v["ciphertext"].(string)
+		if !ok {
+			return nil, false
+		}
+		return &sealedParams{KeyID: keyID, Ciphertext: ciphertext}, true
+	}
+	return nil, false
+}
+
+func instanceAAD(instanceID string) string {
+	return "instance/" + instanceID
+}
+
+func bindingAAD(instanceID string, bindingID string) string {
+	return "binding/" + instanceID + "/" + bindingID
+}
+
+func orphanAAD(orphanID string) string {
+	return "orphan/" + orphanID
+}
+
+// NewEncryptedStore wraps a Store so that the plan parameters of service
+// instances, bindings and orphans are encrypted before they are handed to
+// it.  Any storage driver can be wrapped.  Records that were written
+// before encryption was enabled can still be read.
+func NewEncryptedStore(store broker.Store, keys *Keyring, logger broker.SdLogger) broker.Store {
+	return &encryptedStore{
+		Store:  store,
+		keys:   keys,
+		logger: logger,
+	}
+}
+
+func (s *encryptedStore) AddInstance(id string, instance *broker.ServiceInstance) error {
+	sealed, err := s.sealInstance(id, instance)
+	if err != nil {
+		return err
+	}
+	return s.Store.AddInstance(id, sealed)
+}
+
+func (s *encryptedStore) GetInstance(id string) (*broker.ServiceInstance, error) {
+	si, err := s.Store.GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.openInstance(id, si)
+}
+
+func (s *encryptedStore) GetAllInstances() (map[string]*broker.ServiceInstance, error) {
+	instances, err := s.Store.GetAllInstances()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*broker.ServiceInstance, len(instances))
+	for id, si := range instances {
+		out[id], err = s.openInstance(id, si)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (s *encryptedStore) UpdateInstance(id string, instance *broker.ServiceInstance) error {
+	sealed, err := s.sealInstance(id, instance)
+	if err != nil {
+		return err
+	}
+	return s.Store.UpdateInstance(id, sealed)
+}
+
+func (s *encryptedStore) AddBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) error {
+	sealed, err := s.sealBinding(instanceID, bindingID, bindInstance)
+	if err != nil {
+		return err
+	}
+	return s.Store.AddBinding(instanceID, bindingID, sealed)
+}
+
+func (s *encryptedStore) GetBinding(instanceID string, bindingID string) (*broker.BindInstance, error) {
+	bi, err := s.Store.GetBinding(instanceID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+	return s.openBinding(instanceID, bindingID, bi)
+}
+
+func (s *encryptedStore) GetAllBindings(instanceID string) (map[string]*broker.BindInstance, error) {
+	bindings, err := s.Store.GetAllBindings(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*broker.BindInstance, len(bindings))
+	for id, bi := range bindings {
+		out[id], err = s.openBinding(instanceID, id, bi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (s *encryptedStore) UpdateBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) error {
+	sealed, err := s.sealBinding(instanceID, bindingID, bindInstance)
+	if err != nil {
+		return err
+	}
+	return s.Store.UpdateBinding(instanceID, bindingID, sealed)
+}
+
+func (s *encryptedStore) AddOrphan(orphan *broker.Orphan) error {
+	sp, err := s.keys.seal(orphan.PlanParams, orphanAAD(orphan.OrphanID))
+	if err != nil {
+		return err
+	}
+	sealed := *orphan
+	sealed.PlanParams = sp
+	return s.Store.AddOrphan(&sealed)
+}
+
+func (s *encryptedStore) GetAllOrphans() ([]*broker.Orphan, error) {
+	orphans, err := s.Store.GetAllOrphans()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*broker.Orphan, len(orphans))
+	for i, o := range orphans {
+		params, _, err := s.keys.open(o.PlanParams, orphanAAD(o.OrphanID))
+		if err != nil {
+			return nil, err
+		}
+		opened := *o
+		opened.PlanParams = params
+		out[i] = &opened
+	}
+	return out, nil
+}
+
+func (s *encryptedStore) sealInstance(id string, instance *broker.ServiceInstance) (*broker.ServiceInstance, error) {
+	sp, err := s.keys.seal(instance.InstanceParams, instanceAAD(id))
+	if err != nil {
+		return nil, err
+	}
+	sealed := *instance
+	sealed.InstanceParams = sp
+	return &sealed, nil
+}
+
+func (s *encryptedStore) openInstance(id string, si *broker.ServiceInstance) (*broker.ServiceInstance, error) {
+	params, _, err := s.keys.open(si.InstanceParams, instanceAAD(id))
+	if err != nil {
+		return nil, err
+	}
+	opened := *si
+	opened.InstanceParams = params
+	return &opened, nil
+}
+
+func (s *encryptedStore) sealBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) (*broker.BindInstance, error) {
+	sp, err := s.keys.seal(bindInstance.PlanParams, bindingAAD(instanceID, bindingID))
+	if err != nil {
+		return nil, err
+	}
+	sealed := *bindInstance
+	sealed.PlanParams = sp
+	return &sealed, nil
+}
+
+func (s *encryptedStore) openBinding(instanceID string, bindingID string, bi *broker.BindInstance) (*broker.BindInstance, error) {
+	params, _, err := s.keys.open(bi.PlanParams, bindingAAD(instanceID, bindingID))
+	if err != nil {
+		return nil, err
+	}
+	opened := *bi
+	opened.PlanParams = params
+	return &opened, nil
+}
+
+// ReEncrypt rewrites every record in store that is not encrypted with the
+// primary key, including records written before encryption was enabled.
+// It is run after a new primary key is added so that the old one can be
+// retired.  store must be the storage driver itself and not one returned
+// by NewEncryptedStore.  The number of records rewritten is returned.
+func ReEncrypt(store broker.Store, keys *Keyring, logger broker.SdLogger) (int, error) {
+	count := 0
+	instances, err := store.GetAllInstances()
+	if err != nil {
+		return count, err
+	}
+	for id, si := range instances {
+		sp, err := keys.reseal(si.InstanceParams, instanceAAD(id))
+		if err != nil {
+			return count, err
+		}
+		if sp != nil {
+			updated := *si
+			updated.InstanceParams = sp
+			err = store.UpdateInstance(id, &updated)
+			if err != nil {
+				return count, fmt.Errorf("Failed to re-encrypt the instance %s: %s", id, err)
+			}
+			logger.Logf(broker.INFO, "Re-encrypted the instance %s", id)
+			count++
+		}
+
+		bindings, err := store.GetAllBindings(id)
+		if err != nil {
+			return count, err
+		}
+		for bindingID, bi := range bindings {
+			sp, err := keys.reseal(bi.PlanParams, bindingAAD(id, bindingID))
+			if err != nil {
+				return count, err
+			}
+			if sp == nil {
+				continue
+			}
+			updated := *bi
+			updated.PlanParams = sp
+			err = store.UpdateBinding(id, bindingID, &updated)
+			if err != nil {
+				return count, fmt.Errorf("Failed to re-encrypt the binding %s: %s", bindingID, err)
+			}
+			logger.Logf(broker.INFO, "Re-encrypted the binding %s", bindingID)
+			count++
+		}
+	}
+
+	orphans, err := store.GetAllOrphans()
+	if err != nil {
+		return count, err
+	}
+	for _, o := range orphans {
+		params, keyID, err := keys.open(o.PlanParams, orphanAAD(o.OrphanID))
+		if err != nil {
+			return count, err
+		}
+		if keyID == keys.primary {
+			continue
+		}
+		// Stores cannot update orphans so the record is replaced.  The
+		// copy is added under a new ID, since the ID is its key, before
+		// the original is deleted so that the orphan is never lost.
+		updated := *o
+		updated.OrphanID = broker.GetRandomName("orphan", 16)
+		sp, err := keys.seal(params, orphanAAD(updated.OrphanID))
+		if err != nil {
+			return count, err
+		}
+		updated.PlanParams = sp
+		err = store.AddOrphan(&updated)
+		if err != nil {
+			return count, fmt.Errorf("Failed to re-encrypt the orphan %s: %s", o.OrphanID, err)
+		}
+		err = store.DeleteOrphan(o.OrphanID)
+		if err != nil {
+			cleanupErr := store.DeleteOrphan(updated.OrphanID)
+			if cleanupErr != nil {
+				logger.Logf(broker.ERROR, "The orphan %s is now also recorded as %s: %s", o.OrphanID, updated.OrphanID, cleanupErr)
+			}
+			return count, fmt.Errorf("Failed to re-encrypt the orphan %s: %s", o.OrphanID, err)
+		}
+		logger.Logf(broker.INFO, "Re-encrypted the orphan %s as %s", o.OrphanID, updated.OrphanID)
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,196 @@
+//
+//  Copyright (c) 2017, Stardog Union. <http://stardog.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/stardog-union/service-broker/broker"
+	"github.com/stardog-union/service-broker/store/memory"
+)
+
+func getLogger(t *testing.T) broker.SdLogger {
+	logger, err := broker.NewSdLogger(log.New(os.Stderr, "", log.Ldate|log.Ltime), "DEBUG")
+	if err != nil {
+		t.Fatalf("Failed to make the logger %s", err)
+	}
+	return logger
+}
+
+func newKey(t *testing.T, id string) broker.EncryptionKey {
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return broker.EncryptionKey{ID: id, Key: base64.StdEncoding.EncodeToString(key)}
+}
+
+func newKeyring(t *testing.T, primary string, keys ...broker.EncryptionKey) *Keyring {
+	kr, err := NewKeyring(&broker.EncryptionConfig{PrimaryKeyID: primary, Keys: keys})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return kr
+}
+
+func secret() map[string]interface{} {
+	return map[string]interface{}{"username": "admin", "password": "secret"}
+}
+
+func TestSealOpen(t *testing.T) {
+	kr := newKeyring(t, "k1", newKey(t, "k1"))
+	sp, err := kr.seal(secret(), instanceAAD("inst1"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if sp.KeyID != "k1" {
+		t.Fatalf("The primary key should be used, got %s", sp.KeyID)
+	}
+	params, keyID, err := kr.open(sp, instanceAAD("inst1"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if keyID != "k1" || !reflect.DeepEqual(params, secret()) {
+		t.Fatalf("The parameters did not survive the round trip %s %v", keyID, params)
+	}
+
+	// The stores give back the sealed parameters as a generic document
+	doc := map[string]interface{}{"encryption_key_id": sp.KeyID, "ciphertext": sp.Ciphertext}
+	params, _, err = kr.open(doc, instanceAAD("inst1"))
+	if err != nil || !reflect.DeepEqual(params, secret()) {
+		t.Fatalf("The stored document should be opened: %v", err)
+	}
+
+	plain := map[string]interface{}{"db_name": "db"}
+	params, keyID, err = kr.open(plain, instanceAAD("inst1"))
+	if err != nil || keyID != "" || !reflect.DeepEqual(params, plain) {
+		t.Fatalf("Parameters written before encryption should be returned unchanged")
+	}
+
+	_, _, err = kr.open(sp, instanceAAD("inst2"))
+	if err == nil {
+		t.Fatalf("Parameters moved to another instance should not be opened")
+	}
+	_, _, err = kr.open(sp, bindingAAD("inst1", "b1"))
+	if err == nil {
+		t.Fatalf("Instance parameters moved to a binding should not be opened")
+	}
+
+	other := newKeyring(t, "k2", newKey(t, "k2"))
+	_, _, err = other.open(sp, instanceAAD("inst1"))
+	if err == nil {
+		t.Fatalf("Parameters sealed with an unknown key should not be opened")
+	}
+}
+
+func TestMovedRecord(t *testing.T) {
+	raw := memory.NewInMemoryStore(getLogger(t))
+	store := NewEncryptedStore(raw, newKeyring(t, "k1", newKey(t, "k1")), getLogger(t))
+	err := store.AddInstance("inst1", &broker.ServiceInstance{InstanceGUID: "inst1", InstanceParams: secret()})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	sealed, err := raw.GetInstance("inst1")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if reflect.DeepEqual(sealed.InstanceParams, secret()) {
+		t.Fatalf("The driver should only be given encrypted parameters")
+	}
+
+	// Someone with access to the database copies the parameters over
+	err = raw.AddInstance("inst2", &broker.ServiceInstance{InstanceGUID: "inst2", InstanceParams: sealed.InstanceParams})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	_, err = store.GetInstance("inst2")
+	if err == nil {
+		t.Fatalf("Parameters copied from another instance should not be opened")
+	}
+	si, err := store.GetInstance("inst1")
+	if err != nil || !reflect.DeepEqual(si.InstanceParams, secret()) {
+		t.Fatalf("The original instance should still be opened: %v", err)
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	oldKey := newKey(t, "old")
+	newKeyValue := newKey(t, "new")
+	logger := getLogger(t)
+	raw := memory.NewInMemoryStore(logger)
+
+	oldStore := NewEncryptedStore(raw, newKeyring(t, "old", oldKey), logger)
+	err := oldStore.AddInstance("inst1", &broker.ServiceInstance{InstanceGUID: "inst1", InstanceParams: secret()})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = oldStore.AddBinding("inst1", "b1", &broker.BindInstance{BindGUID: "b1", PlanParams: secret()})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = oldStore.AddOrphan(&broker.Orphan{OrphanID: "orphan1", Created: "2030-01-01T00:00:00Z", Kind: broker.OrphanBinding, PlanParams: secret()})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	// Written before encryption was enabled
+	err = raw.AddInstance("inst2", &broker.ServiceInstance{InstanceGUID: "inst2", InstanceParams: secret()})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	keys := newKeyring(t, "new", oldKey, newKeyValue)
+	count, err := ReEncrypt(raw, keys, logger)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if count != 4 {
+		t.Fatalf("Every record should have been rewritten, not %d", count)
+	}
+	count, err = ReEncrypt(raw, keys, logger)
+	if err != nil || count != 0 {
+		t.Fatalf("Nothing should be left to rewrite %d %v", count, err)
+	}
+
+	// The old key can now be retired
+	newStore := NewEncryptedStore(raw, newKeyring(t, "new", newKeyValue), logger)
+	for _, id := range []string{"inst1", "inst2"} {
+		si, err := newStore.GetInstance(id)
+		if err != nil || !reflect.DeepEqual(si.InstanceParams, secret()) {
+			t.Fatalf("The instance %s should be opened with the new key: %v", id, err)
+		}
+	}
+	bi, err := newStore.GetBinding("inst1", "b1")
+	if err != nil || !reflect.DeepEqual(bi.PlanParams, secret()) {
+		t.Fatalf("The binding should be opened with the new key: %v", err)
+	}
+	orphans, err := newStore.GetAllOrphans()
+	if err != nil {
+		t.Fatalf("The orphan should be opened with the new key: %s", err)
+	}
+	if len(orphans) != 1 || !reflect.DeepEqual(orphans[0].PlanParams, secret()) {
+		t.Fatalf("The orphan should have been kept %v", orphans)
+	}
+	o := orphans[0]
+	if o.OrphanID == "orphan1" || o.Created != "2030-01-01T00:00:00Z" || o.Kind != broker.OrphanBinding {
+		t.Fatalf("The orphan should be recorded again under a new ID %v", o)
+	}
+}
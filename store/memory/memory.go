@@ -16,7 +16,8 @@
 package memory
 
 import (
-	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/stardog-union/service-broker/broker"
 )
@@ -27,23 +28,33 @@ type instanceWrapper struct {
 }
 
 type inMemoryStore struct {
-	instanceMap map[string]*instanceWrapper
-	logger      broker.SdLogger
+	instanceMap  map[string]*instanceWrapper
+	operationMap map[string]*broker.AsyncOperation
+	bindOpMap    map[string]*broker.AsyncOperation
+	orphanMap    map[string]*broker.Orphan
+	logger       broker.SdLogger
+	lock         sync.Mutex
 }
 
 // NewInMemoryStore creates a Store object that only keeps information
 // in main memory.  This is used for testing.
 func NewInMemoryStore(logger broker.SdLogger) broker.Store {
 	return &inMemoryStore{
-		instanceMap: make(map[string]*instanceWrapper),
-		logger:      logger,
+		instanceMap:  make(map[string]*instanceWrapper),
+		operationMap: make(map[string]*broker.AsyncOperation),
+		bindOpMap:    make(map[string]*broker.AsyncOperation),
+		orphanMap:    make(map[string]*broker.Orphan),
+		logger:       logger,
 	}
 }
 
 func (m *inMemoryStore) AddInstance(id string, instance *broker.ServiceInstance) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	inst := m.instanceMap[id]
 	if inst != nil {
-		return fmt.Errorf("The instance already exists")
+		return broker.NewConflictError("The instance already exists")
 	}
 	w := &instanceWrapper{
 		inst:       instance,
@@ -55,66 +66,206 @@ func (m *inMemoryStore) AddInstance(id string, instance *broker.ServiceInstance)
 }
 
 func (m *inMemoryStore) GetInstance(id string) (*broker.ServiceInstance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	w := m.instanceMap[id]
 	if w == nil {
-		return nil, fmt.Errorf("The instance does not exists")
+		return nil, broker.NewNotFoundError("The instance does not exists")
 	}
 	return w.inst, nil
 }
 
+func (m *inMemoryStore) GetAllInstances() (map[string]*broker.ServiceInstance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	instances := make(map[string]*broker.ServiceInstance, len(m.instanceMap))
+	for k, w := range m.instanceMap {
+		instances[k] = w.inst
+	}
+	return instances, nil
+}
+
+func (m *inMemoryStore) UpdateInstance(id string, instance *broker.ServiceInstance) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	w := m.instanceMap[id]
+	if w == nil {
+		return broker.NewNotFoundError("The instance does not exists")
+	}
+	w.inst = instance
+	m.logger.Logf(broker.INFO, "Updated instance %s", id)
+	return nil
+}
+
 func (m *inMemoryStore) DeleteInstance(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	w := m.instanceMap[id]
 	if w == nil {
-		return fmt.Errorf("The instance does not exists")
+		return broker.NewNotFoundError("The instance does not exists")
 	}
 	delete(m.instanceMap, id)
 	return nil
 }
 
 func (m *inMemoryStore) GetAllBindings(instanceID string) (map[string]*broker.BindInstance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	w := m.instanceMap[instanceID]
 	if w == nil {
-		return nil, fmt.Errorf("The instance does not exists")
+		return nil, broker.NewNotFoundError("The instance does not exists")
+	}
+	// Hand back a copy so that callers can range over it without the lock
+	bindingMap := make(map[string]*broker.BindInstance, len(w.bindingMap))
+	for k, v := range w.bindingMap {
+		bindingMap[k] = v
 	}
-	return w.bindingMap, nil
+	return bindingMap, nil
 }
 
 func (m *inMemoryStore) AddBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	w := m.instanceMap[instanceID]
 	if w == nil {
-		return fmt.Errorf("The instance does not exists %s", instanceID)
+		return broker.NewNotFoundError("The instance does not exists %s", instanceID)
 	}
 	b := w.bindingMap[bindingID]
 	if b != nil {
-		return fmt.Errorf("The binding already exists %s", bindingID)
+		return broker.NewConflictError("The binding already exists %s", bindingID)
 	}
 	m.logger.Logf(broker.INFO, "Memory store binding %s %s", instanceID, bindingID)
-	w.bindingMap[bindingID] = bindInstance
+	bi := *bindInstance
+	w.bindingMap[bindingID] = &bi
 	return nil
 }
 
 func (m *inMemoryStore) DeleteBinding(instanceID string, bindingID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	w := m.instanceMap[instanceID]
 	if w == nil {
-		return fmt.Errorf("The instance does not exists %s", instanceID)
+		return broker.NewNotFoundError("The instance does not exists %s", instanceID)
 	}
 	b := w.bindingMap[bindingID]
 	if b == nil {
-		return fmt.Errorf("The binding does not exists %s", bindingID)
+		return broker.NewNotFoundError("The binding does not exists %s", bindingID)
 	}
 	m.logger.Logf(broker.INFO, "Memory store deleted binding %s %s", instanceID, bindingID)
 	delete(w.bindingMap, bindingID)
 	return nil
 }
 
+func (m *inMemoryStore) UpdateBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	w := m.instanceMap[instanceID]
+	if w == nil {
+		return broker.NewNotFoundError("The instance does not exists %s", instanceID)
+	}
+	if w.bindingMap[bindingID] == nil {
+		return broker.NewNotFoundError("The binding does not exists %s", bindingID)
+	}
+	bi := *bindInstance
+	w.bindingMap[bindingID] = &bi
+	m.logger.Logf(broker.INFO, "Memory store updated binding %s %s", instanceID, bindingID)
+	return nil
+}
+
 func (m *inMemoryStore) GetBinding(instanceID string, bindingID string) (*broker.BindInstance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	w := m.instanceMap[instanceID]
 	if w == nil {
-		return nil, fmt.Errorf("The instance does not exists")
+		return nil, broker.NewNotFoundError("The instance does not exists")
 	}
 	b := w.bindingMap[bindingID]
 	if b == nil {
-		return nil, fmt.Errorf("The binding does not exists")
+		return nil, broker.NewNotFoundError("The binding does not exists")
 	}
 	return b, nil
 }
+
+func (m *inMemoryStore) SetInstanceOperation(instanceID string, op *broker.AsyncOperation) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	o := *op
+	m.operationMap[instanceID] = &o
+	return nil
+}
+
+func (m *inMemoryStore) GetInstanceOperation(instanceID string) (*broker.AsyncOperation, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	o := m.operationMap[instanceID]
+	if o == nil {
+		return nil, broker.NewNotFoundError("No operation exists for the instance %s", instanceID)
+	}
+	op := *o
+	return &op, nil
+}
+
+func (m *inMemoryStore) SetBindingOperation(instanceID string, bindingID string, op *broker.AsyncOperation) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	o := *op
+	m.bindOpMap[instanceID+"/"+bindingID] = &o
+	return nil
+}
+
+func (m *inMemoryStore) GetBindingOperation(instanceID string, bindingID string) (*broker.AsyncOperation, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	o := m.bindOpMap[instanceID+"/"+bindingID]
+	if o == nil {
+		return nil, broker.NewNotFoundError("No operation exists for the binding %s", bindingID)
+	}
+	op := *o
+	return &op, nil
+}
+
+func (m *inMemoryStore) AddOrphan(orphan *broker.Orphan) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	o := *orphan
+	m.orphanMap[orphan.OrphanID] = &o
+	return nil
+}
+
+func (m *inMemoryStore) GetAllOrphans() ([]*broker.Orphan, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	orphans := make([]*broker.Orphan, 0, len(m.orphanMap))
+	for _, o := range m.orphanMap {
+		orphan := *o
+		orphans = append(orphans, &orphan)
+	}
+	sort.Sort(broker.OrphansByCreated(orphans))
+	return orphans, nil
+}
+
+func (m *inMemoryStore) DeleteOrphan(orphanID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.orphanMap[orphanID] == nil {
+		return broker.NewNotFoundError("The orphan %s does not exist", orphanID)
+	}
+	delete(m.orphanMap, orphanID)
+	return nil
+}
@@ -52,6 +52,12 @@ func getNewParameters(logger broker.SdLogger, parameters interface{}) (*mysqlNew
 }
 
 func createMetaDatabase(logger broker.SdLogger, driverName string, contactString string, dbName string) error {
+	// The name cannot be passed as a query parameter so it is checked and
+	// quoted instead
+	err := broker.ValidateSQLName(dbName)
+	if err != nil {
+		return err
+	}
 	dbConn, err := sql.Open(driverName, contactString)
 	if err != nil {
 		return fmt.Errorf("Failed to connect to the database: %s", err)
@@ -59,13 +65,13 @@ func createMetaDatabase(logger broker.SdLogger, driverName string, contactString
 	defer dbConn.Close()
 
 	logger.Logf(broker.DEBUG, "Create the database %s", dbName)
-	_, err = dbConn.Exec("CREATE DATABASE IF NOT EXISTS " + dbName)
+	_, err = dbConn.Exec("CREATE DATABASE IF NOT EXISTS `" + dbName + "`")
 	if err != nil {
 		return fmt.Errorf("failed to create to the database: %s", err)
 	}
 
 	logger.Logf(broker.DEBUG, "Set the database for use")
-	_, err = dbConn.Exec("USE " + dbName)
+	_, err = dbConn.Exec("USE `" + dbName + "`")
 	if err != nil {
 		return fmt.Errorf("failed to set the database in use: %s", err)
 	}
@@ -96,6 +102,38 @@ func createMetaDatabase(logger broker.SdLogger, driverName string, contactString
 	if err != nil {
 		return fmt.Errorf("Failed to create the service_instance table: %s", err)
 	}
+	operationTable := `CREATE TABLE IF NOT EXISTS instance_operations (
+		service_guid varchar(64) NOT NULL PRIMARY KEY,
+		data TEXT
+	)
+	`
+	logger.Logf(broker.DEBUG, "Create the instance operations table")
+	_, err = dbConn.Exec(operationTable)
+	if err != nil {
+		return fmt.Errorf("Failed to create the instance_operations table: %s", err)
+	}
+	bindOperationTable := `CREATE TABLE IF NOT EXISTS binding_operations (
+		binding_guid varchar(64) NOT NULL PRIMARY KEY,
+		service_guid varchar(64),
+		data TEXT
+	)
+	`
+	logger.Logf(broker.DEBUG, "Create the binding operations table")
+	_, err = dbConn.Exec(bindOperationTable)
+	if err != nil {
+		return fmt.Errorf("Failed to create the binding_operations table: %s", err)
+	}
+	orphanTable := `CREATE TABLE IF NOT EXISTS orphans (
+		orphan_id varchar(64) NOT NULL PRIMARY KEY,
+		created varchar(32),
+		data TEXT
+	)
+	`
+	logger.Logf(broker.DEBUG, "Create the orphans table")
+	_, err = dbConn.Exec(orphanTable)
+	if err != nil {
+		return fmt.Errorf("Failed to create the orphans table: %s", err)
+	}
 	return nil
 }
 
@@ -246,6 +284,65 @@ func (m *mysqlStore) GetInstance(serviceGUID string) (*broker.ServiceInstance, e
 	return &si, nil
 }
 
+func (m *mysqlStore) GetAllInstances() (map[string]*broker.ServiceInstance, error) {
+	rows, err := m.dbConn.Query("select service_guid, data from service_instance")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the service instances: %s", err)
+	}
+	defer rows.Close()
+	instances := make(map[string]*broker.ServiceInstance)
+	for rows.Next() {
+		var serviceGUID, data string
+		err = rows.Scan(&serviceGUID, &data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the instance value %s", err)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode the instance value %s", err)
+		}
+		var si broker.ServiceInstance
+		err = json.Unmarshal(decoded, &si)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal the instance value %s", err)
+		}
+		instances[serviceGUID] = &si
+	}
+	return instances, nil
+}
+
+func (m *mysqlStore) UpdateInstance(serviceGUID string, instance *broker.ServiceInstance) error {
+	instanceData, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(instanceData)
+
+	stmt, err := m.dbConn.Prepare("UPDATE service_instance SET data = ? WHERE service_guid = ?")
+	if err != nil {
+		return fmt.Errorf("Failure to create the prepared statement: %s", err)
+	}
+	defer stmt.Close()
+	res, err := stmt.Exec(encodedData, serviceGUID)
+	if err != nil {
+		return fmt.Errorf("Failure to execute the update: %s", err)
+	}
+	c, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to get the number of updated rows: %s", err)
+	}
+	if c < 1 {
+		// MySQL counts the rows that changed rather than the ones that
+		// matched, so an instance saved again unchanged is not missing
+		_, err = m.GetInstance(serviceGUID)
+		return err
+	}
+	if c > 1 {
+		m.logger.Logf(broker.WARN, "Multiple rows (%d) were updated with the service id %s", c, serviceGUID)
+	}
+	return nil
+}
+
 func (m *mysqlStore) DeleteInstance(serviceGUID string) error {
 	stmt, err := m.dbConn.Prepare("DELETE FROM service_instance WHERE service_guid = ?")
 	if err != nil {
@@ -258,7 +355,7 @@ func (m *mysqlStore) DeleteInstance(serviceGUID string) error {
 	}
 	c, err := res.RowsAffected()
 	if c < 1 {
-		return fmt.Errorf("No rows were deleted")
+		return broker.NewNotFoundError("No rows were deleted")
 	}
 	if c > 1 {
 		m.logger.Logf(broker.WARN, "Multiple rows (%d) were deleted with the service id %s", c, serviceGUID)
@@ -273,7 +370,7 @@ func (m *mysqlStore) getServiceRow(tx *sql.Tx, serviceGUID string) (*serviceRow,
 	}
 	defer rows.Close()
 	if !rows.Next() {
-		return nil, fmt.Errorf("The service instance %s was not found", serviceGUID)
+		return nil, broker.NewNotFoundError("The service instance %s was not found", serviceGUID)
 	}
 	var resultRow serviceRow
 	err = rows.Scan(&resultRow.id, &resultRow.serviceGUID, &resultRow.data)
@@ -321,7 +418,7 @@ func (m *mysqlStore) GetBinding(serviceGUID string, bindingGUID string) (*broker
 	}
 	defer rows.Close()
 	if !rows.Next() {
-		return nil, fmt.Errorf("The service instance %s was not found", serviceGUID)
+		return nil, broker.NewNotFoundError("The service instance %s was not found", serviceGUID)
 	}
 	var data string
 	err = rows.Scan(&data)
@@ -377,6 +474,34 @@ func (m *mysqlStore) GetAllBindings(serviceGUID string) (map[string]*broker.Bind
 	return bindingMap, nil
 }
 
+func (m *mysqlStore) UpdateBinding(serviceGUID string, bindingGUID string, bindInstance *broker.BindInstance) error {
+	bindData, err := json.Marshal(bindInstance)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(bindData)
+
+	stmt, err := m.dbConn.Prepare("UPDATE bindings SET data = ? WHERE binding_guid = ?")
+	if err != nil {
+		return fmt.Errorf("Failure to create the prepared statement: %s", err)
+	}
+	defer stmt.Close()
+	res, err := stmt.Exec(encodedData, bindingGUID)
+	if err != nil {
+		return fmt.Errorf("Failure to execute the update: %s", err)
+	}
+	c, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to get the number of updated rows: %s", err)
+	}
+	if c < 1 {
+		// As for instances a binding saved again unchanged is not missing
+		_, err = m.GetBinding(serviceGUID, bindingGUID)
+		return err
+	}
+	return nil
+}
+
 func (m *mysqlStore) DeleteBinding(serviceGUID string, bindingGUID string) error {
 	stmt, err := m.dbConn.Prepare("DELETE FROM bindings WHERE binding_guid = ?")
 	if err != nil {
@@ -389,10 +514,163 @@ func (m *mysqlStore) DeleteBinding(serviceGUID string, bindingGUID string) error
 	}
 	c, err := res.RowsAffected()
 	if c < 1 {
-		return fmt.Errorf("No rows were deleted")
+		return broker.NewNotFoundError("No rows were deleted")
 	}
 	if c > 1 {
 		m.logger.Logf(broker.WARN, "Multiple rows (%d) were deleted with the binding id %s", c, bindingGUID)
 	}
 	return nil
 }
+
+func (m *mysqlStore) SetInstanceOperation(serviceGUID string, op *broker.AsyncOperation) error {
+	opData, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(opData)
+
+	stmt, err := m.dbConn.Prepare("INSERT INTO instance_operations(service_guid, data) VALUES (?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data)")
+	if err != nil {
+		return fmt.Errorf("Failure to create the prepared statement: %s", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(serviceGUID, encodedData)
+	if err != nil {
+		return fmt.Errorf("Failure to execute the operation insert: %s", err)
+	}
+	return nil
+}
+
+func (m *mysqlStore) GetInstanceOperation(serviceGUID string) (*broker.AsyncOperation, error) {
+	rows, err := m.dbConn.Query("select data from instance_operations where service_guid = ?", serviceGUID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the operation: %s", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, broker.NewNotFoundError("No operation exists for the service instance %s", serviceGUID)
+	}
+	var data string
+	err = rows.Scan(&data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the operation for instance %s: %s", serviceGUID, err)
+	}
+	opB, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the base64 data: %s", err)
+	}
+	var op broker.AsyncOperation
+	err = json.Unmarshal(opB, &op)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal the JSON: %s", err)
+	}
+	return &op, nil
+}
+
+func (m *mysqlStore) SetBindingOperation(serviceGUID string, bindingGUID string, op *broker.AsyncOperation) error {
+	opData, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(opData)
+
+	stmt, err := m.dbConn.Prepare("INSERT INTO binding_operations(binding_guid, service_guid, data) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE service_guid = VALUES(service_guid), data = VALUES(data)")
+	if err != nil {
+		return fmt.Errorf("Failure to create the prepared statement: %s", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(bindingGUID, serviceGUID, encodedData)
+	if err != nil {
+		return fmt.Errorf("Failure to execute the operation insert: %s", err)
+	}
+	return nil
+}
+
+func (m *mysqlStore) GetBindingOperation(serviceGUID string, bindingGUID string) (*broker.AsyncOperation, error) {
+	rows, err := m.dbConn.Query("select data from binding_operations where binding_guid = ? and service_guid = ?", bindingGUID, serviceGUID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the operation: %s", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, broker.NewNotFoundError("No operation exists for the binding %s", bindingGUID)
+	}
+	var data string
+	err = rows.Scan(&data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the operation for binding %s: %s", bindingGUID, err)
+	}
+	opB, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode the base64 data: %s", err)
+	}
+	var op broker.AsyncOperation
+	err = json.Unmarshal(opB, &op)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal the JSON: %s", err)
+	}
+	return &op, nil
+}
+
+func (m *mysqlStore) AddOrphan(orphan *broker.Orphan) error {
+	orphanData, err := json.Marshal(orphan)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(orphanData)
+
+	stmt, err := m.dbConn.Prepare("INSERT INTO orphans(orphan_id, created, data) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("Failure to create the prepared statement: %s", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(orphan.OrphanID, orphan.Created, encodedData)
+	if err != nil {
+		return fmt.Errorf("Failure to execute the orphan insert: %s", err)
+	}
+	return nil
+}
+
+func (m *mysqlStore) GetAllOrphans() ([]*broker.Orphan, error) {
+	rows, err := m.dbConn.Query("select data from orphans order by created")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the orphans: %s", err)
+	}
+	defer rows.Close()
+	orphans := []*broker.Orphan{}
+	for rows.Next() {
+		var data string
+		err = rows.Scan(&data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the orphan value %s", err)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode the orphan value %s", err)
+		}
+		var orphan broker.Orphan
+		err = json.Unmarshal(decoded, &orphan)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal the orphan value %s", err)
+		}
+		orphans = append(orphans, &orphan)
+	}
+	return orphans, nil
+}
+
+func (m *mysqlStore) DeleteOrphan(orphanID string) error {
+	stmt, err := m.dbConn.Prepare("DELETE FROM orphans WHERE orphan_id = ?")
+	if err != nil {
+		return fmt.Errorf("Failure to create the prepared statement: %s", err)
+	}
+	defer stmt.Close()
+	res, err := stmt.Exec(orphanID)
+	if err != nil {
+		return fmt.Errorf("Failure to execute the delete: %s", err)
+	}
+	c, err := res.RowsAffected()
+	if c < 1 {
+		return broker.NewNotFoundError("No rows were deleted")
+	}
+	return nil
+}
@@ -16,13 +16,18 @@
 package stardog
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/stardog-union/service-broker/broker"
 )
 
+// stardogStore keeps the broker records in a Stardog database.  The Store
+// interface does not take a context so its requests to Stardog are only
+// limited by the default timeouts of the client.
 type stardogStore struct {
 	client broker.StardogClient
 	logger broker.SdLogger
@@ -49,14 +54,16 @@ type boolReply struct {
 }
 
 type stardogMetadataStore struct {
-	StardogURL string `json:"stardog_url"`
-	AdminName  string `json:"admin_username"`
-	AdminPw    string `json:"admin_password"`
+	StardogURL string                   `json:"stardog_url"`
+	AdminName  string                   `json:"admin_username"`
+	AdminPw    string                   `json:"admin_password"`
+	TLS        *broker.StardogTLSConfig `json:"tls,omitempty"`
 }
 
 // NewStardogStore creates a Store object that will persist the broker information to a
-// Stardog database.
-func NewStardogStore(BrokerID string, logger broker.SdLogger, parameters interface{}) (broker.Store, error) {
+// Stardog database.  The clients are made by clientFactory so that they
+// share the stardog_client timeouts of the broker.
+func NewStardogStore(BrokerID string, logger broker.SdLogger, clientFactory broker.StardogClientFactory, parameters interface{}) (broker.Store, error) {
 	var sdStoreParameters stardogMetadataStore
 	err := broker.ReSerializeInterface(parameters, &sdStoreParameters)
 	if err != nil {
@@ -67,22 +74,29 @@ func NewStardogStore(BrokerID string, logger broker.SdLogger, parameters interfa
 	logger.Logf(broker.DEBUG, "Setting up persist with @@ %s", sdStoreParameters)
 
 	// Create database for storing instance info
-	client := broker.NewStardogClient(
+	clientFactory, err = broker.ClientFactoryWithTLS(clientFactory, sdStoreParameters.TLS)
+	if err != nil {
+		return nil, err
+	}
+	client := clientFactory.GetStardogAdminClient(
 		sdStoreParameters.StardogURL,
 		broker.DatabaseCredentials{
 			Username: sdStoreParameters.AdminName,
 			Password: sdStoreParameters.AdminPw,
-		},
-		logger)
+		})
 	sdStore := stardogStore{
 		client: client,
 		logger: logger,
 	}
 	sdStore.dbName = fmt.Sprintf("metadata%s", BrokerID)
-	_, err = client.GetDatabaseSize(sdStore.dbName)
+	err = broker.ValidateDatabaseName(sdStore.dbName)
+	if err != nil {
+		return nil, fmt.Errorf("The broker_id cannot be used to name the metadata database: %s", err)
+	}
+	_, err = client.GetDatabaseSize(context.Background(), sdStore.dbName)
 	if err != nil {
 		logger.Logf(broker.INFO, "The database %s does not exist.  Try making it: %s|", sdStore.dbName, sdStoreParameters.StardogURL)
-		err := client.CreateDatabase(sdStore.dbName)
+		err := client.CreateDatabase(context.Background(), sdStore.dbName, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -91,7 +105,24 @@ func NewStardogStore(BrokerID string, logger broker.SdLogger, parameters interfa
 	return &sdStore, nil
 }
 
+// checkIDs rejects IDs that could change the meaning of the queries and
+// documents that they are put in.  The IDs are part of IRIs, where they
+// cannot be escaped.
+func checkIDs(ids ...string) error {
+	for _, id := range ids {
+		err := broker.ValidateGUID("ID", id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *stardogStore) AddInstance(id string, instance *broker.ServiceInstance) error {
+	err := checkIDs(id)
+	if err != nil {
+		return err
+	}
 	instanceData, err := json.Marshal(instance)
 	if err != nil {
 		return nil
@@ -104,12 +135,16 @@ func (s *stardogStore) AddInstance(id string, instance *broker.ServiceInstance)
 	sdcf:instance%s sdcf:datais "%s"^^xsd:string .`
 
 	payload := fmt.Sprintf(insert, id, id, id, id, encodedData)
-	err = s.client.AddData(s.dbName, "text/turtle", payload)
+	err = s.client.AddData(context.Background(), s.dbName, "text/turtle", payload)
 
 	return err
 }
 
 func (s *stardogStore) GetInstance(id string) (*broker.ServiceInstance, error) {
+	err := checkIDs(id)
+	if err != nil {
+		return nil, err
+	}
 	qS := `
 	PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
 
@@ -119,8 +154,8 @@ select ?instance_data where {
   ?instance sdcf:datais ?instance_data .
 }
 	`
-	q := fmt.Sprintf(qS, id)
-	b, err := s.client.Query(s.dbName, q)
+	q := fmt.Sprintf(qS, broker.EscapeSPARQLString(id))
+	b, err := s.client.Query(context.Background(), s.dbName, q)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +166,7 @@ select ?instance_data where {
 		return nil, err
 	}
 	if len(res.Results.Bindings) < 1 {
-		return nil, fmt.Errorf("There was no instance data in the query results")
+		return nil, broker.NewNotFoundError("There was no instance data in the query results")
 	}
 	instanceData, ok := res.Results.Bindings[0]["instance_data"]
 	if !ok {
@@ -149,14 +184,94 @@ select ?instance_data where {
 	return &si, nil
 }
 
+func (s *stardogStore) GetAllInstances() (map[string]*broker.ServiceInstance, error) {
+	q := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+select ?guid ?instance_data where {
+  ?instance sdcf:isa sdcf:instance .
+  ?instance sdcf:GUID ?guid .
+  ?instance sdcf:datais ?instance_data .
+}`
+	b, err := s.client.Query(context.Background(), s.dbName, q)
+	if err != nil {
+		return nil, err
+	}
+	var res jsonReply
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return nil, err
+	}
+	instances := make(map[string]*broker.ServiceInstance)
+	for _, v := range res.Results.Bindings {
+		guid, ok := v["guid"]
+		if !ok {
+			return nil, fmt.Errorf("Bad protocol response")
+		}
+		instanceData, ok := v["instance_data"]
+		if !ok {
+			return nil, fmt.Errorf("Bad protocol response")
+		}
+		siB, err := base64.StdEncoding.DecodeString(instanceData.Value)
+		if err != nil {
+			return nil, err
+		}
+		var si broker.ServiceInstance
+		err = json.Unmarshal(siB, &si)
+		if err != nil {
+			return nil, err
+		}
+		instances[guid.Value] = &si
+	}
+	return instances, nil
+}
+
+// replaceData swaps the data of a record in a single update.  Nothing is
+// inserted when the record no longer exists.
+const replaceData = `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+PREFIX xsd: <http://www.w3.org/2001/XMLSchema#>
+
+DELETE {
+	sdcf:%[1]s sdcf:datais ?d .
+}
+INSERT {
+	sdcf:%[1]s sdcf:datais "%[2]s"^^xsd:string .
+}
+WHERE {
+	sdcf:%[1]s sdcf:datais ?d .
+}`
+
+func (s *stardogStore) UpdateInstance(id string, instance *broker.ServiceInstance) error {
+	err := checkIDs(id)
+	if err != nil {
+		return err
+	}
+	_, err = s.GetInstance(id)
+	if err != nil {
+		return err
+	}
+	instanceData, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(instanceData)
+
+	// Only the data triple is replaced so that bindings stay attached.
+	// The delete and insert are one update so that a failure cannot leave
+	// the instance without its data.
+	return s.client.Update(context.Background(), s.dbName, fmt.Sprintf(replaceData, "instance"+id, encodedData))
+}
+
 func (s *stardogStore) DeleteInstance(id string) error {
+	err := checkIDs(id)
+	if err != nil {
+		return err
+	}
 	d := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
 
 	DELETE WHERE {
 		sdcf:instance%s ?o ?p .
 	}`
 
-	_, err := s.client.Query(s.dbName, fmt.Sprintf(d, id))
+	_, err = s.client.Query(context.Background(), s.dbName, fmt.Sprintf(d, id))
 	if err != nil {
 		return err
 	}
@@ -165,6 +280,10 @@ func (s *stardogStore) DeleteInstance(id string) error {
 }
 
 func (s *stardogStore) GetAllBindings(instanceID string) (map[string]*broker.BindInstance, error) {
+	err := checkIDs(instanceID)
+	if err != nil {
+		return nil, err
+	}
 	q := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
 select ?data_binding where {
   ?instance sdcf:isa sdcf:instance .
@@ -173,7 +292,7 @@ select ?data_binding where {
   ?binding sdcf:boundto ?instance .
   ?binding sdcf:datais ?data_binding .
 }`
-	b, err := s.client.Query(s.dbName, fmt.Sprintf(q, instanceID))
+	b, err := s.client.Query(context.Background(), s.dbName, fmt.Sprintf(q, broker.EscapeSPARQLString(instanceID)))
 	if err != nil {
 		return nil, err
 	}
@@ -205,7 +324,11 @@ select ?data_binding where {
 }
 
 func (s *stardogStore) AddBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) error {
-	_, err := s.GetInstance(instanceID)
+	err := checkIDs(instanceID, bindingID)
+	if err != nil {
+		return err
+	}
+	_, err = s.GetInstance(instanceID)
 	if err != nil {
 		return err
 	}
@@ -222,19 +345,41 @@ func (s *stardogStore) AddBinding(instanceID string, bindingID string, bindInsta
 	sdcf:binding%s sdcf:boundto sdcf:instance%s .
 	`
 	payload := fmt.Sprintf(insert, bindingID, bindingID, bindingID, bindingID, encodedData, bindingID, instanceID)
-	err = s.client.AddData(s.dbName, "text/turtle", payload)
+	err = s.client.AddData(context.Background(), s.dbName, "text/turtle", payload)
 
 	return err
 }
 
+func (s *stardogStore) UpdateBinding(instanceID string, bindingID string, bindInstance *broker.BindInstance) error {
+	err := checkIDs(instanceID, bindingID)
+	if err != nil {
+		return err
+	}
+	_, err = s.GetBinding(instanceID, bindingID)
+	if err != nil {
+		return err
+	}
+	bindData, err := json.Marshal(bindInstance)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(bindData)
+
+	return s.client.Update(context.Background(), s.dbName, fmt.Sprintf(replaceData, "binding"+bindingID, encodedData))
+}
+
 func (s *stardogStore) DeleteBinding(instanceID string, bindingID string) error {
+	err := checkIDs(instanceID, bindingID)
+	if err != nil {
+		return err
+	}
 	d := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
 
 	delete where {
       		sdcf:binding%s ?o ?p .
       	}`
 
-	r, err := s.client.Query(s.dbName, fmt.Sprintf(d, bindingID))
+	r, err := s.client.Query(context.Background(), s.dbName, fmt.Sprintf(d, bindingID))
 	if err != nil {
 		return err
 	}
@@ -250,6 +395,10 @@ func (s *stardogStore) DeleteBinding(instanceID string, bindingID string) error
 }
 
 func (s *stardogStore) GetBinding(instanceID string, bindingID string) (*broker.BindInstance, error) {
+	err := checkIDs(instanceID, bindingID)
+	if err != nil {
+		return nil, err
+	}
 	q := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
 select ?data_binding where {
   ?instance sdcf:isa sdcf:instance .
@@ -259,7 +408,7 @@ select ?data_binding where {
   ?binding sdcf:GUID "%s" .
   ?binding sdcf:datais ?data_binding .
 }`
-	b, err := s.client.Query(s.dbName, fmt.Sprintf(q, instanceID, bindingID))
+	b, err := s.client.Query(context.Background(), s.dbName, fmt.Sprintf(q, broker.EscapeSPARQLString(instanceID), broker.EscapeSPARQLString(bindingID)))
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +418,7 @@ select ?data_binding where {
 		return nil, err
 	}
 	if len(res.Results.Bindings) < 1 {
-		return nil, fmt.Errorf("There was no binding data in the query results")
+		return nil, broker.NewNotFoundError("There was no binding data in the query results")
 	}
 	v, ok := res.Results.Bindings[0]["data_binding"]
 	if !ok {
@@ -286,3 +435,232 @@ select ?data_binding where {
 	}
 	return &bi, nil
 }
+
+func (s *stardogStore) SetInstanceOperation(instanceID string, op *broker.AsyncOperation) error {
+	err := checkIDs(instanceID)
+	if err != nil {
+		return err
+	}
+	opData, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(opData)
+
+	// Only the latest operation is kept so remove whatever was there before
+	d := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+
+	DELETE WHERE {
+		sdcf:operation%s ?o ?p .
+	}`
+	_, err = s.client.Query(context.Background(), s.dbName, fmt.Sprintf(d, instanceID))
+	if err != nil {
+		return err
+	}
+
+	insert := `@prefix sdcf: <http://github.com/stardog-union/service-broker/> .
+	sdcf:operation%s sdcf:GUID "%s"^^xsd:string .
+	sdcf:operation%s sdcf:isa sdcf:operation .
+	sdcf:operation%s sdcf:datais "%s"^^xsd:string .`
+
+	payload := fmt.Sprintf(insert, instanceID, instanceID, instanceID, instanceID, encodedData)
+	return s.client.AddData(context.Background(), s.dbName, "text/turtle", payload)
+}
+
+func (s *stardogStore) GetInstanceOperation(instanceID string) (*broker.AsyncOperation, error) {
+	err := checkIDs(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	qS := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+select ?operation_data where {
+  ?operation sdcf:isa sdcf:operation .
+  ?operation sdcf:GUID "%s"^^xsd:string .
+  ?operation sdcf:datais ?operation_data .
+}`
+	b, err := s.client.Query(context.Background(), s.dbName, fmt.Sprintf(qS, broker.EscapeSPARQLString(instanceID)))
+	if err != nil {
+		return nil, err
+	}
+	var res jsonReply
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results.Bindings) < 1 {
+		return nil, broker.NewNotFoundError("There was no operation data in the query results")
+	}
+	opData, ok := res.Results.Bindings[0]["operation_data"]
+	if !ok {
+		return nil, fmt.Errorf("There was no operation data in the query results")
+	}
+	opB, err := base64.StdEncoding.DecodeString(opData.Value)
+	if err != nil {
+		return nil, err
+	}
+	var op broker.AsyncOperation
+	err = json.Unmarshal(opB, &op)
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+func (s *stardogStore) SetBindingOperation(instanceID string, bindingID string, op *broker.AsyncOperation) error {
+	err := checkIDs(instanceID, bindingID)
+	if err != nil {
+		return err
+	}
+	opData, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(opData)
+
+	d := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+
+	DELETE WHERE {
+		sdcf:bindingoperation%s ?o ?p .
+	}`
+	_, err = s.client.Query(context.Background(), s.dbName, fmt.Sprintf(d, bindingID))
+	if err != nil {
+		return err
+	}
+
+	insert := `@prefix sdcf: <http://github.com/stardog-union/service-broker/> .
+	sdcf:bindingoperation%s sdcf:GUID "%s"^^xsd:string .
+	sdcf:bindingoperation%s sdcf:isa sdcf:bindingoperation .
+	sdcf:bindingoperation%s sdcf:instanceGUID "%s"^^xsd:string .
+	sdcf:bindingoperation%s sdcf:datais "%s"^^xsd:string .`
+
+	payload := fmt.Sprintf(insert, bindingID, bindingID, bindingID, bindingID, instanceID, bindingID, encodedData)
+	return s.client.AddData(context.Background(), s.dbName, "text/turtle", payload)
+}
+
+func (s *stardogStore) GetBindingOperation(instanceID string, bindingID string) (*broker.AsyncOperation, error) {
+	err := checkIDs(instanceID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+	qS := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+select ?operation_data where {
+  ?operation sdcf:isa sdcf:bindingoperation .
+  ?operation sdcf:GUID "%s"^^xsd:string .
+  ?operation sdcf:instanceGUID "%s"^^xsd:string .
+  ?operation sdcf:datais ?operation_data .
+}`
+	b, err := s.client.Query(context.Background(), s.dbName, fmt.Sprintf(qS, broker.EscapeSPARQLString(bindingID), broker.EscapeSPARQLString(instanceID)))
+	if err != nil {
+		return nil, err
+	}
+	var res jsonReply
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results.Bindings) < 1 {
+		return nil, broker.NewNotFoundError("There was no operation data in the query results")
+	}
+	opData, ok := res.Results.Bindings[0]["operation_data"]
+	if !ok {
+		return nil, fmt.Errorf("There was no operation data in the query results")
+	}
+	opB, err := base64.StdEncoding.DecodeString(opData.Value)
+	if err != nil {
+		return nil, err
+	}
+	var op broker.AsyncOperation
+	err = json.Unmarshal(opB, &op)
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+func (s *stardogStore) AddOrphan(orphan *broker.Orphan) error {
+	err := checkIDs(orphan.OrphanID)
+	if err != nil {
+		return err
+	}
+	orphanData, err := json.Marshal(orphan)
+	if err != nil {
+		return err
+	}
+	encodedData := base64.StdEncoding.EncodeToString(orphanData)
+
+	insert := `@prefix sdcf: <http://github.com/stardog-union/service-broker/> .
+	sdcf:orphan%s sdcf:GUID "%s"^^xsd:string .
+	sdcf:orphan%s sdcf:isa sdcf:orphan .
+	sdcf:orphan%s sdcf:datais "%s"^^xsd:string .`
+
+	payload := fmt.Sprintf(insert, orphan.OrphanID, orphan.OrphanID, orphan.OrphanID, orphan.OrphanID, encodedData)
+	return s.client.AddData(context.Background(), s.dbName, "text/turtle", payload)
+}
+
+func (s *stardogStore) GetAllOrphans() ([]*broker.Orphan, error) {
+	q := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+select ?orphan_data where {
+  ?orphan sdcf:isa sdcf:orphan .
+  ?orphan sdcf:datais ?orphan_data .
+}`
+	b, err := s.client.Query(context.Background(), s.dbName, q)
+	if err != nil {
+		return nil, err
+	}
+	var res jsonReply
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []*broker.Orphan{}
+	for _, v := range res.Results.Bindings {
+		encodedEnt, ok := v["orphan_data"]
+		if !ok {
+			return nil, fmt.Errorf("Bad protocol response")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encodedEnt.Value)
+		if err != nil {
+			return nil, err
+		}
+		var orphan broker.Orphan
+		err = json.Unmarshal(decoded, &orphan)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, &orphan)
+	}
+	sort.Sort(broker.OrphansByCreated(orphans))
+	return orphans, nil
+}
+
+func (s *stardogStore) DeleteOrphan(orphanID string) error {
+	err := checkIDs(orphanID)
+	if err != nil {
+		return err
+	}
+	q := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+ask where {
+  sdcf:orphan%s sdcf:isa sdcf:orphan .
+}`
+	r, err := s.client.Query(context.Background(), s.dbName, fmt.Sprintf(q, orphanID))
+	if err != nil {
+		return err
+	}
+	var exists boolReply
+	err = json.Unmarshal(r, &exists)
+	if err != nil {
+		return err
+	}
+	if !exists.Boolean {
+		return broker.NewNotFoundError("The orphan %s does not exist", orphanID)
+	}
+
+	d := `PREFIX sdcf: <http://github.com/stardog-union/service-broker/>
+
+	delete where {
+		sdcf:orphan%s ?o ?p .
+	}`
+	_, err = s.client.Query(context.Background(), s.dbName, fmt.Sprintf(d, orphanID))
+	return err
+}
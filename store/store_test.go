@@ -20,9 +20,12 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stardog-union/service-broker/broker"
+	"github.com/stardog-union/service-broker/store/encrypted"
+	"github.com/stardog-union/service-broker/store/memory"
 	storesql "github.com/stardog-union/service-broker/store/sql"
 	storestardog "github.com/stardog-union/service-broker/store/stardog"
 )
@@ -56,6 +59,14 @@ func simpleWalkthrough(store broker.Store) error {
 	if err != nil {
 		return err
 	}
+	fmt.Printf("Pre GetAllInstances\n")
+	instances, err := store.GetAllInstances()
+	if err != nil {
+		return err
+	}
+	if instances[instanceGUID] == nil {
+		return fmt.Errorf("The instance %s was not listed", instanceGUID)
+	}
 
 	w = someData{Word: "bind_word_0"}
 	bindGUID := fmt.Sprintf("Binding1-%d", rand.Int63())
@@ -100,6 +111,12 @@ func simpleWalkthrough(store broker.Store) error {
 		if bi.BindGUID != v.BindGUID {
 			return fmt.Errorf("GUID not the same %s != %s", bi.BindGUID, v.BindGUID)
 		}
+		fmt.Printf("Pre UpdateBinding\n")
+		bi.PlanParams = &someData{Word: "updated"}
+		err = store.UpdateBinding(instanceGUID, bi.BindGUID, bi)
+		if err != nil {
+			return err
+		}
 		fmt.Printf("Pre DeleteBinding\n")
 		err = store.DeleteBinding(instanceGUID, bi.BindGUID)
 		if err != nil {
@@ -112,6 +129,41 @@ func simpleWalkthrough(store broker.Store) error {
 		}
 	}
 
+	orphan := broker.Orphan{
+		OrphanID:     fmt.Sprintf("orphan%d", rand.Int63()),
+		Kind:         broker.OrphanBinding,
+		InstanceGUID: instanceGUID,
+		PlanParams:   &w,
+		Created:      "2017-01-01T00:00:00Z",
+	}
+	fmt.Printf("Pre AddOrphan\n")
+	err = store.AddOrphan(&orphan)
+	if err != nil {
+		return err
+	}
+	orphans, err := store.GetAllOrphans()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, o := range orphans {
+		if o.OrphanID == orphan.OrphanID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("The orphan %s was not found", orphan.OrphanID)
+	}
+	fmt.Printf("Pre DeleteOrphan\n")
+	err = store.DeleteOrphan(orphan.OrphanID)
+	if err != nil {
+		return err
+	}
+	err = store.DeleteOrphan(orphan.OrphanID)
+	if err == nil {
+		return fmt.Errorf("The orphan %s was deleted twice", orphan.OrphanID)
+	}
+
 	fmt.Printf("Pre DeleteInstance\n")
 	err = store.DeleteInstance(instanceGUID)
 	if err != nil {
@@ -140,6 +192,7 @@ func TestStardogPersistence(t *testing.T) {
 	store, err := storestardog.NewStardogStore(
 		"stardog-service-0A48E1D9-DCC9-4A61-8677-CB10B3C05512",
 		logger,
+		broker.NewClientFactory(logger, nil),
 		&paramsMap)
 	if err != nil {
 		fmt.Printf("ERROR %s\n", err)
@@ -178,3 +231,99 @@ func TestSQLPersistence(t *testing.T) {
 		t.Fatalf("%s", err)
 	}
 }
+
+func TestMemoryPersistence(t *testing.T) {
+	baseLogger := log.New(os.Stderr, "", log.Ldate|log.Ltime)
+	logger, _ := broker.NewSdLogger(baseLogger, "DEBUG")
+
+	err := simpleWalkthrough(memory.NewInMemoryStore(logger))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+}
+
+func testKeyring(t *testing.T, primary string) *encrypted.Keyring {
+	keys, err := encrypted.NewKeyring(&broker.EncryptionConfig{
+		PrimaryKeyID: primary,
+		Keys: []broker.EncryptionKey{
+			{ID: "k1", Key: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="},
+			{ID: "k2", Key: "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="},
+		},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return keys
+}
+
+func TestEncryptedPersistence(t *testing.T) {
+	baseLogger := log.New(os.Stderr, "", log.Ldate|log.Ltime)
+	logger, _ := broker.NewSdLogger(baseLogger, "DEBUG")
+
+	store := encrypted.NewEncryptedStore(memory.NewInMemoryStore(logger), testKeyring(t, "k1"), logger)
+	err := simpleWalkthrough(store)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	baseLogger := log.New(os.Stderr, "", log.Ldate|log.Ltime)
+	logger, _ := broker.NewSdLogger(baseLogger, "DEBUG")
+
+	raw := memory.NewInMemoryStore(logger)
+	// A record from before encryption was enabled
+	err := raw.AddInstance("plain", &broker.ServiceInstance{InstanceParams: someData{Word: "secretplain"}})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	old := encrypted.NewEncryptedStore(raw, testKeyring(t, "k1"), logger)
+	err = old.AddInstance("inst", &broker.ServiceInstance{InstanceParams: someData{Word: "secretinst"}})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = old.AddBinding("inst", "bind", &broker.BindInstance{BindGUID: "bind", PlanParams: someData{Word: "secretbind"}})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	bi, err := raw.GetBinding("inst", "bind")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if strings.Contains(fmt.Sprintf("%v", bi.PlanParams), "secretbind") {
+		t.Fatalf("The binding was stored in plain text")
+	}
+
+	keys := testKeyring(t, "k2")
+	count, err := encrypted.ReEncrypt(raw, keys, logger)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records to be re-encrypted but got %d", count)
+	}
+	count, err = encrypted.ReEncrypt(raw, keys, logger)
+	if err != nil || count != 0 {
+		t.Fatalf("Expected nothing to be re-encrypted but got %d %v", count, err)
+	}
+
+	k2Only, err := encrypted.NewKeyring(&broker.EncryptionConfig{
+		Keys: []broker.EncryptionKey{{ID: "k2", Key: "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="}},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	store := encrypted.NewEncryptedStore(raw, k2Only, logger)
+	for _, id := range []string{"plain", "inst"} {
+		si, err := store.GetInstance(id)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var w someData
+		broker.ReSerializeInterface(si.InstanceParams, &w)
+		if w.Word != "secret"+id {
+			t.Fatalf("The instance %s was not decrypted: %v", id, si.InstanceParams)
+		}
+	}
+}